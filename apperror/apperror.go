@@ -0,0 +1,197 @@
+package apperror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/validator.v2"
+	"gorm.io/gorm"
+)
+
+// Stable error codes, part of the API: clients match on them, not on messages
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeUnprocessable    = "unprocessable"
+	CodePaymentRejected  = "payment_rejected"
+	CodeRiskDenied       = "risk_denied"
+	CodeDlocalError      = "dlocal_error"
+	CodeDlocalTimeout    = "dlocal_timeout"
+	CodeRateLimited      = "rate_limited"
+	CodeTooManyFailures  = "too_many_failures"
+	CodeInternal         = "internal_error"
+)
+
+// FieldError - validation error of one request field
+type FieldError struct {
+	Field   string `json:"field" example:"currency"`
+	Message string `json:"message" example:"zero value"`
+}
+
+// Error - error with the HTTP status and code it's answered with
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(status int, code string, message string, err error) *Error {
+	return &Error{Status: status, Code: code, Message: message, Err: err}
+}
+
+// Invalid - the request is malformed (400)
+func Invalid(message string, err error) *Error {
+	return newError(http.StatusBadRequest, CodeInvalidRequest, message, err)
+}
+
+// NotFound - the resource doesn't exist (404)
+func NotFound(message string, err error) *Error {
+	return newError(http.StatusNotFound, CodeNotFound, message, err)
+}
+
+// PaymentRequired - the payment wasn't made, by dlocal or by the risk
+// rules (402)
+func PaymentRequired(code string, format string, args ...interface{}) *Error {
+	return newError(http.StatusPaymentRequired, code, fmt.Sprintf(format, args...), nil)
+}
+
+// Conflict - the resource's state doesn't allow the change (409)
+func Conflict(code string, format string, args ...interface{}) *Error {
+	return newError(http.StatusConflict, code, fmt.Sprintf(format, args...), nil)
+}
+
+// Unprocessable - the request is well formed but breaks a business rule (422)
+func Unprocessable(code string, format string, args ...interface{}) *Error {
+	return newError(http.StatusUnprocessableEntity, code, fmt.Sprintf(format, args...), nil)
+}
+
+// TooManyRequests - the client went over a limit, it can retry later (429)
+func TooManyRequests(code string, format string, args ...interface{}) *Error {
+	return newError(http.StatusTooManyRequests, code, fmt.Sprintf(format, args...), nil)
+}
+
+// Upstream - dlocal answered with an error (502)
+func Upstream(message string, err error) *Error {
+	return newError(http.StatusBadGateway, CodeDlocalError, message, err)
+}
+
+// Timeout - dlocal didn't answer in time (504)
+func Timeout(message string, err error) *Error {
+	return newError(http.StatusGatewayTimeout, CodeDlocalTimeout, message, err)
+}
+
+// Coder - errors of other packages that know their Error
+type Coder interface {
+	AppError() *Error
+}
+
+// From - Error of err, nil if it isn't one and can't be classified
+//
+// Besides Error and Coder it recognizes records not found, validation and
+// JSON decoding errors and timeouts.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.AppError()
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFound("Record not found", err)
+	}
+	var errMap validator.ErrorMap
+	if errors.As(err, &errMap) {
+		return validation(nil, errMap)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		e := newError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload", err)
+		e.Fields = []FieldError{{Field: typeErr.Field, Message: fmt.Sprintf("must be %s", typeErr.Type)}}
+		return e
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return Timeout("Request timed out", err)
+	}
+	return nil
+}
+
+// Validation - Error of validator.Validate(v), with v's json field names
+//
+// Returns err unchanged if it isn't a validator.ErrorMap.
+func Validation(v interface{}, err error) error {
+	var errMap validator.ErrorMap
+	if !errors.As(err, &errMap) {
+		return err
+	}
+	return validation(reflect.TypeOf(v), errMap)
+}
+
+func validation(t reflect.Type, errMap validator.ErrorMap) *Error {
+	e := newError(http.StatusUnprocessableEntity, CodeValidationFailed, "Validation failed", errMap)
+	for path, errs := range errMap {
+		for _, err := range errs {
+			e.Fields = append(e.Fields, FieldError{Field: jsonPath(t, path), Message: err.Error()})
+		}
+	}
+	sort.Slice(e.Fields, func(i, j int) bool {
+		return e.Fields[i].Field < e.Fields[j].Field
+	})
+	return e
+}
+
+// JSON name of a validator field path ("Address.ZipCode" -> "address.zip_code")
+func jsonPath(t reflect.Type, path string) string {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		index := ""
+		if n := strings.IndexByte(name, '['); n >= 0 {
+			name, index = name[:n], name[n:]
+		}
+		for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			t = nil
+			continue
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			t = nil
+			continue
+		}
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			names[i] = tag + index
+		}
+		t = field.Type
+	}
+	return strings.Join(names, ".")
+}
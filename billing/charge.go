@@ -0,0 +1,217 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"systempayment/apperror"
+	"systempayment/dlocal"
+	"systempayment/metrics"
+	"systempayment/model"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrNotSaved - dlocal approved the payment but it couldn't be saved
+var ErrNotSaved = errors.New("payment approved but not saved")
+
+// DlocalError - dlocal answered but didn't approve the payment
+type DlocalError struct {
+	Code     int
+	Response map[string]interface{}
+}
+
+func (e *DlocalError) Error() string {
+	status, _ := e.Response["status"].(string)
+	detail, _ := e.Response["status_detail"].(string)
+	if detail == "" {
+		detail, _ = e.Response["message"].(string)
+	}
+	return fmt.Sprintf("dlocal payment not approved (%d %s): %s", e.Code, status, detail)
+}
+
+// AppError - rejected payments are answered with 402, dlocal errors with 502
+func (e *DlocalError) AppError() *apperror.Error {
+	detail, _ := e.Response["status_detail"].(string)
+	if detail == "" {
+		detail, _ = e.Response["message"].(string)
+	}
+	if e.Code == http.StatusPaymentRequired {
+		return &apperror.Error{Status: http.StatusPaymentRequired, Code: apperror.CodePaymentRejected,
+			Message: "Rejected by dlocal: " + detail, Err: e}
+	}
+	return apperror.Upstream("dlocal error: "+detail, e)
+}
+
+// Declined - The charge didn't happen for sure: dlocal declined it (4xx or
+// REJECTED) or the risk rules denied it. Timeouts and dlocal's 5xx aren't,
+// the card may have been charged
+func Declined(err error) bool {
+	var dlocalErr *DlocalError
+	if errors.As(err, &dlocalErr) {
+		return dlocalErr.Code < 500
+	}
+	var appErr *apperror.Error
+	return errors.As(err, &appErr) && appErr.Code == apperror.CodeRiskDenied
+}
+
+// charge - Charges the order's current installment with the card, the
+// result is kept on the attempt
+//
+// On approval the order moves to its next installment and the payment is
+// saved, both in one transaction. The payment is invoiced afterwards. The
+// risk rules aren't checked here, callers go through ScreenAndCharge.
+func charge(db *gorm.DB, order *model.Order, payer model.Payer, card model.Card, attempt *model.PaymentAttempt) (model.Payment, int, error) {
+	var payment model.Payment
+
+	// taxes of this installment, before PaymentSuccessful moves to the next one
+	breakdown, err := order.Tax(order.InstallmentAmount())
+	if err != nil {
+		return payment, 400, err
+	}
+
+	code, response, err := dlocal.MakePayment(db.Statement.Context, *order, payer, card)
+	if err != nil {
+		metrics.Payments.WithLabelValues(metrics.PaymentFailed).Inc()
+		record(db, attempt, model.AttemptFailed, err.Error())
+		return payment, code, err
+	}
+	if code != 200 {
+		metrics.Payments.WithLabelValues(metrics.PaymentFailed).Inc()
+		dlocalErr := &DlocalError{Code: code, Response: response}
+		// 5xx are dlocal's, not the card's
+		if code >= 500 {
+			record(db, attempt, model.AttemptFailed, dlocalErr.Error())
+		} else {
+			record(db, attempt, model.AttemptRejected, dlocalErr.Error())
+		}
+		notifyFailed(db, order, card, dlocalErr)
+		return payment, code, dlocalErr
+	}
+	if status, _ := response["status"].(string); status == dlocal.StatusRejected {
+		metrics.Payments.WithLabelValues(metrics.PaymentRejected).Inc()
+		dlocalErr := &DlocalError{Code: http.StatusPaymentRequired, Response: response}
+		record(db, attempt, model.AttemptRejected, dlocalErr.Error())
+		notifyFailed(db, order, card, dlocalErr)
+		return payment, http.StatusPaymentRequired, dlocalErr
+	}
+	metrics.Payments.WithLabelValues(metrics.PaymentApproved).Inc()
+
+	payment = model.Payment{
+		OrderID:     order.ID,
+		CardID:      card.ID,
+		Installment: order.CurrentFee,
+		FxRateID:    order.FxRateID,
+		FxRate:      order.FxRate,
+		NetAmount:   breakdown.Net,
+		TaxAmount:   breakdown.Tax,
+		TaxLines:    breakdown.Lines,
+	}
+	code = 500
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		// installment data before PaymentSuccessful moves to the next one
+		data := order.NotificationData(tx, payment.Amount)
+		if code, err = order.PaymentSuccessful(tx); err != nil {
+			return err
+		}
+		if code, err = payment.SavePaymentFromResponse(tx, response); err != nil {
+			return err
+		}
+
+		code = 500
+		if err = model.QPostPayment(tx, payment); err != nil {
+			return err
+		}
+		data["amount"] = fmt.Sprintf("%.2f", payment.Amount)
+		data["brand"], data["last4"] = deref(card.Brand), deref(card.Last4)
+		if err = model.QEnqueueNotification(tx, order.PayerID, model.NotifyPaymentSucceeded, data); err != nil {
+			return err
+		}
+		if err = model.QEmitOrderEvent(tx, order.ID, model.EventPaymentSucceeded, payment); err != nil {
+			return err
+		}
+		if !order.Finished {
+			return nil
+		}
+		if err = model.QEnqueueNotification(tx, order.PayerID, model.NotifyOrderFinished, data); err != nil {
+			return err
+		}
+		return model.QEmitOrderEvent(tx, order.ID, model.EventOrderFinished, order)
+	})
+	if err != nil {
+		// dlocal already charged the card, this needs manual attention
+		log.WithContext(db.Statement.Context).Error("charge - order ", order.ID, " charged but not saved: ", err)
+		record(db, attempt, model.AttemptApproved, ErrNotSaved.Error())
+		return payment, code, fmt.Errorf("%w: %v", ErrNotSaved, err)
+	}
+	attempt.PaymentID = &payment.ID
+	record(db, attempt, model.AttemptApproved, "")
+
+	var invoice model.Invoice
+	if _, err := invoice.QIssueInvoice(db, payment); err != nil {
+		// not lost, it's issued when the receipt is requested
+		log.WithContext(db.Statement.Context).Error("charge - payment ", payment.ID, " not invoiced: ", err)
+	}
+
+	return payment, 200, nil
+}
+
+// newAttempt - attempt of charging the order's current installment
+func newAttempt(order *model.Order, card model.Card) model.PaymentAttempt {
+	return model.PaymentAttempt{
+		Kind:        model.AttemptPayment,
+		PayerID:     order.PayerID,
+		CardID:      &card.ID,
+		OrderID:     &order.ID,
+		Installment: order.CurrentFee,
+		Amount:      order.InstallmentAmount(),
+		Currency:    deref(order.Currency),
+	}
+}
+
+// record - Saves the attempt with its result. In a savepoint, a failure is
+// only logged and doesn't break the caller's transaction
+func record(db *gorm.DB, attempt *model.PaymentAttempt, result string, reason string) {
+	attempt.Result, attempt.Reason = result, reason
+	db.Transaction(func(tx *gorm.DB) error {
+		_, err := attempt.QSavePaymentAttempt(tx)
+		return err
+	})
+}
+
+// Lets the payer and the merchant know the charge was declined
+func notifyFailed(db *gorm.DB, order *model.Order, card model.Card, dlocalErr *DlocalError) {
+	data := order.NotificationData(db, order.InstallmentAmount())
+	data["brand"], data["last4"] = deref(card.Brand), deref(card.Last4)
+	data["reason"], _ = dlocalErr.Response["status_detail"].(string)
+
+	event := map[string]interface{}{
+		"order_id":      order.ID,
+		"order_number":  order.OrderId,
+		"card_id":       card.ID,
+		"amount":        order.InstallmentAmount(),
+		"currency":      deref(order.Currency),
+		"installment":   order.CurrentFee,
+		"status":        dlocalErr.Response["status"],
+		"status_code":   dlocalErr.Response["status_code"],
+		"status_detail": dlocalErr.Response["status_detail"],
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := model.QEnqueueNotification(tx, order.PayerID, model.NotifyPaymentFailed, data); err != nil {
+			return err
+		}
+		return model.QEmitOrderEvent(tx, order.ID, model.EventPaymentFailed, event)
+	})
+	if err != nil {
+		log.WithContext(db.Statement.Context).Error("charge - order ", order.ID, " failure not notified: ", err)
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
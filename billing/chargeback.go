@@ -0,0 +1,121 @@
+package billing
+
+import (
+	"errors"
+	"strings"
+	"systempayment/dlocal"
+	"systempayment/model"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Layouts of the dates in dlocal notifications
+var dlocalDateLayouts = []string{"2006-01-02T15:04:05.000-0700", time.RFC3339}
+
+// ChargebackNotified - Records a chargeback notification from dlocal
+//
+// The first notification of a chargeback opens it, later ones move it to
+// won when dlocal reverses or cancels it. Notifications of closed
+// chargebacks are ignored so dlocal stops retrying them.
+func ChargebackNotified(db *gorm.DB, n dlocal.ChargebackNotification) (model.Chargeback, int, error) {
+	var chargeback model.Chargeback
+	if n.ID == "" || n.PaymentID == "" {
+		return chargeback, 400, errors.New("chargeback notification without id or payment_id")
+	}
+
+	status := model.ChargebackOpen
+	switch strings.ToUpper(n.Status) {
+	case dlocal.ChargebackReversed, dlocal.ChargebackCancelled:
+		status = model.ChargebackWon
+	}
+
+	code := 500
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		chargeback = model.Chargeback{DlocalID: &n.ID}
+		code, err = chargeback.QLockChargeback(tx)
+		if err == nil {
+			if !chargeback.Open() {
+				log.WithContext(db.Statement.Context).Warn("ChargebackNotified - chargeback ", n.ID, " already ", chargeback.Status)
+				return nil
+			}
+			code, err = chargeback.QSetStatus(tx, status, n.StatusDetail)
+			return err
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		paymentID, err := model.QPaymentIDByDlocalID(tx, n.PaymentID)
+		if err != nil {
+			code = 400
+			return err
+		}
+		currency := n.Currency
+		chargeback = model.Chargeback{
+			PaymentID:     paymentID,
+			DlocalID:      &n.ID,
+			ReasonCode:    n.ReasonCode,
+			Reason:        n.StatusDetail,
+			Amount:        n.Amount,
+			Currency:      &currency,
+			EvidenceDueAt: parseDlocalDate(n.DisputeDueDate),
+		}
+		if code, err = chargeback.QCreateChargeback(tx); err != nil {
+			return err
+		}
+		if status != model.ChargebackOpen {
+			code, err = chargeback.QSetStatus(tx, status, n.StatusDetail)
+		}
+		return err
+	})
+	if err != nil {
+		log.WithContext(db.Statement.Context).Error("ChargebackNotified - ", n.ID, ": ", err)
+		return chargeback, code, err
+	}
+	return chargeback, 200, nil
+}
+
+// OpenChargeback - Records a chargeback entered by hand
+func OpenChargeback(db *gorm.DB, chargeback *model.Chargeback) (int, error) {
+	code := 500
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		code, err = chargeback.QCreateChargeback(tx)
+		return err
+	})
+	if err != nil {
+		return code, err
+	}
+	return 200, nil
+}
+
+// UpdateChargeback - Moves a chargeback to a new status
+func UpdateChargeback(db *gorm.DB, id int, status string, note string) (model.Chargeback, int, error) {
+	var chargeback = model.Chargeback{ID: id}
+	code := 500
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if code, err = chargeback.QLockChargeback(tx); err != nil {
+			return err
+		}
+		code, err = chargeback.QSetStatus(tx, status, note)
+		return err
+	})
+	if err != nil {
+		return chargeback, code, err
+	}
+	return chargeback, 200, nil
+}
+
+// Zero time when empty or not a date
+func parseDlocalDate(value string) time.Time {
+	for _, layout := range dlocalDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
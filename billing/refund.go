@@ -0,0 +1,219 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"systempayment/apperror"
+	"systempayment/dlocal"
+	"systempayment/model"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrRefundNotSaved - dlocal made the refund but it couldn't be saved
+var ErrRefundNotSaved = errors.New("refund made but not saved")
+
+// RefundPayment - Refunds an amount of a payment, all that's left when amount is 0
+//
+// The refund is saved as requested, with the payment locked so concurrent
+// refunds can't go over the paid amount, and sent to dlocal once that's
+// committed. Its answer is saved afterwards, and a successful refund is
+// taken from the payment and the ledger. Pending ones wait for
+// SettlePendingRefunds. Refunds dlocal didn't answer, or whose answer
+// couldn't be saved, stay requested and keep their amount out of later
+// refunds until someone checks them.
+func RefundPayment(db *gorm.DB, paymentID int, amount float64, reason string) (model.Refund, int, error) {
+	var refund model.Refund
+	var payment = model.Payment{ID: paymentID}
+	code := 500
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if code, err = payment.QLockPayment(tx); err != nil {
+			return err
+		}
+		if payment.Status == model.PaymentChargedBack {
+			code = 400
+			return apperror.Conflict("payment_charged_back", "payment is charged back, it can't be refunded")
+		}
+		if payment.DlocalID == nil || *payment.DlocalID == "" {
+			code = 400
+			return errors.New("payment has no dlocal id, it can't be refunded")
+		}
+
+		unsettled, err := model.QUnsettledRefunds(tx, payment.ID)
+		if err != nil {
+			code = 500
+			return err
+		}
+		refundable := math.Round((payment.Refundable()-unsettled)*100) / 100
+		if amount == 0 {
+			amount = refundable
+		}
+		amount = math.Round(amount*100) / 100
+		if amount <= 0 || amount > refundable {
+			code = 400
+			return apperror.Unprocessable("invalid_amount", "refund amount must be between 0 and %.2f", refundable)
+		}
+
+		refund = model.Refund{
+			PaymentID: payment.ID,
+			Amount:    amount,
+			Currency:  payment.Currency,
+			Status:    model.RefundRequested,
+			Reason:    reason,
+		}
+		code, err = refund.QCreateRefund(tx)
+		return err
+	})
+	if err != nil {
+		return refund, code, err
+	}
+
+	code, response, err := dlocal.MakeRefund(db.Statement.Context, *payment.DlocalID, amount, *payment.Currency)
+	if err != nil {
+		// it may have been made, left requested
+		log.WithContext(db.Statement.Context).Error("RefundPayment - refund ", refund.ID, " not answered by dlocal: ", err)
+		return refund, code, err
+	}
+	status, _ := response["status"].(string)
+	if code >= 500 {
+		// dlocal's error, the refund may have been made too
+		log.WithContext(db.Statement.Context).Error("RefundPayment - refund ", refund.ID, " failed at dlocal: ", code)
+		return refund, code, &DlocalError{Code: code, Response: response}
+	}
+	if code != 200 || status == dlocal.RefundRejected {
+		if code == 200 {
+			code = http.StatusPaymentRequired
+		}
+		dlocalErr := &DlocalError{Code: code, Response: response}
+		refund.Status, refund.Error = model.RefundFailed, dlocalErr.Error()
+		if _, err := refund.QUpdateRefund(db); err != nil {
+			log.WithContext(db.Statement.Context).Error("RefundPayment - refund ", refund.ID, " failure not saved: ", err)
+		}
+		return refund, code, dlocalErr
+	}
+
+	dlocalID, _ := response["id"].(string)
+	refund.DlocalID = &dlocalID
+	refund.Status = model.RefundSuccess
+	if status == dlocal.RefundPending {
+		// settled later by SettlePendingRefunds
+		refund.Status = model.RefundPending
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if _, err := payment.QLockPayment(tx); err != nil {
+			return err
+		}
+		if _, err := refund.QUpdateRefund(tx); err != nil {
+			return err
+		}
+		if refund.Status == model.RefundPending {
+			return nil
+		}
+		return settleRefund(tx, &payment, refund)
+	})
+	if err != nil {
+		// dlocal already refunded, still requested so it isn't refunded
+		// again, this needs manual attention
+		log.WithContext(db.Statement.Context).Error("RefundPayment - refund ", refund.ID, " of payment ", paymentID, " refunded but not saved: ", err)
+		return refund, 500, fmt.Errorf("%w: %v", ErrRefundNotSaved, err)
+	}
+	return refund, 200, nil
+}
+
+// SettlePendingRefunds - Asks dlocal about the pending refunds, the ones it
+// made are settled like a successful refund and the rejected ones fail.
+// Returns how many were settled.
+func SettlePendingRefunds(ctx context.Context, db *gorm.DB) (int, error) {
+	ids, err := model.QPendingRefunds(db)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return settled, ctx.Err()
+		}
+		ok, err := SettlePendingRefund(db, id)
+		if err != nil {
+			log.Error("SettlePendingRefunds - refund ", id, ": ", err)
+		}
+		if ok {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+// SettlePendingRefund - Saves dlocal's current status of a pending refund.
+// Returns true when it's settled.
+func SettlePendingRefund(db *gorm.DB, id int) (bool, error) {
+	var refund = model.Refund{ID: id}
+	if _, err := refund.QGetRefund(db); err != nil {
+		return false, err
+	}
+	if refund.Status != model.RefundPending || refund.DlocalID == nil {
+		return false, nil
+	}
+
+	code, response, err := dlocal.GetRefundStatus(db.Statement.Context, *refund.DlocalID)
+	if err != nil {
+		return false, err
+	}
+	if code != 200 {
+		return false, &DlocalError{Code: code, Response: response}
+	}
+	status, _ := response["status"].(string)
+	if status != dlocal.RefundSuccess && status != dlocal.RefundRejected {
+		// still pending
+		return false, nil
+	}
+
+	settled := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var payment = model.Payment{ID: refund.PaymentID}
+		if _, err := payment.QLockPayment(tx); err != nil {
+			return err
+		}
+		if _, err := refund.QLockRefund(tx); err != nil {
+			return err
+		}
+		if refund.Status != model.RefundPending {
+			// settled meanwhile
+			return nil
+		}
+		if status == dlocal.RefundRejected {
+			refund.Status = model.RefundFailed
+			refund.Error = (&DlocalError{Code: http.StatusPaymentRequired, Response: response}).Error()
+			_, err := refund.QUpdateRefund(tx)
+			return err
+		}
+		refund.Status = model.RefundSuccess
+		if _, err := refund.QUpdateRefund(tx); err != nil {
+			return err
+		}
+		settled = true
+		return settleRefund(tx, &payment, refund)
+	})
+	if err != nil {
+		return false, err
+	}
+	return settled, nil
+}
+
+// settleRefund - Takes a successful refund from the payment and the ledger
+// and lets the merchant know. The payment has to be locked
+func settleRefund(tx *gorm.DB, payment *model.Payment, refund model.Refund) error {
+	if _, err := payment.Refunded(tx, refund.Amount); err != nil {
+		return err
+	}
+	if err := model.QPostRefund(tx, *payment, refund); err != nil {
+		return err
+	}
+	return model.QEmitOrderEvent(tx, payment.OrderID, model.EventRefundCreated, refund)
+}
@@ -0,0 +1,163 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"systempayment/apperror"
+	"systempayment/model"
+	"systempayment/risk"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// HeldError - the risk rules held the payment for a manual review
+type HeldError struct {
+	Attempt model.PaymentAttempt
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("payment held for review %d: %s", e.Attempt.ID, e.Attempt.RiskReasons)
+}
+
+// ScreenAndCharge - Charges the order's current installment with the card
+// if the risk rules allow it. Denied payments fail with 402, the ones to
+// review aren't sent to dlocal and fail with a HeldError until a reviewer
+// decides.
+//
+// Orders with a pending review can't be charged and a denied review denies
+// the order. An approved review only covers the payment it held, later
+// ones are checked again.
+func ScreenAndCharge(db *gorm.DB, order *model.Order, payer model.Payer, card model.Card, ip string) (model.Payment, int, error) {
+	var payment model.Payment
+	attempt := newAttempt(order, card)
+	attempt.IP = ip
+	if !risk.Enabled() {
+		return charge(db, order, payer, card, &attempt)
+	}
+
+	var review model.PaymentAttempt
+	code, err := review.QLastOrderReview(db, order.ID)
+	switch {
+	case err != nil && code != 400:
+		return payment, code, err
+	case err == nil && review.ReviewStatus == model.ReviewPending:
+		return payment, http.StatusConflict, apperror.Conflict("payment_in_review", "order %d has a payment waiting for review %d", order.ID, review.ID)
+	case err == nil && review.ReviewStatus == model.ReviewDenied:
+		attempt.RiskOutcome, attempt.RiskReasons = risk.Deny, risk.ReasonReviewDenied
+		record(db, &attempt, model.AttemptBlocked, fmt.Sprintf("review %d", review.ID))
+		return payment, http.StatusPaymentRequired, apperror.PaymentRequired(apperror.CodeRiskDenied, "order denied in review %d", review.ID)
+	}
+
+	decision, code, err := risk.Evaluate(db, risk.Input{
+		Payer:    payer,
+		Card:     &card,
+		Amount:   attempt.Amount,
+		Currency: attempt.Currency,
+	})
+	if err != nil {
+		return payment, code, err
+	}
+	attempt.RiskOutcome, attempt.RiskReasons = decision.Outcome, strings.Join(decision.Reasons, ",")
+
+	switch decision.Outcome {
+	case risk.Deny:
+		record(db, &attempt, model.AttemptBlocked, "")
+		return payment, http.StatusPaymentRequired, apperror.PaymentRequired(apperror.CodeRiskDenied, "payment denied by risk rules: %s", attempt.RiskReasons)
+	case risk.Review:
+		attempt.ReviewStatus = model.ReviewPending
+		attempt.Result = model.AttemptHeld
+		if code, err := attempt.QSavePaymentAttempt(db); err != nil {
+			return payment, code, err
+		}
+		return payment, http.StatusAccepted, &HeldError{Attempt: attempt}
+	}
+	return charge(db, order, payer, card, &attempt)
+}
+
+// ApproveReview - Approves a held payment and charges it. Only what was
+// held is charged: the order has to be still due on the same installment
+// and amount, and it's charged to the same card. A held subscription
+// renewal gets the result of the charge.
+func ApproveReview(db *gorm.DB, id int, note string) (model.Payment, int, error) {
+	var payment model.Payment
+	var attempt = model.PaymentAttempt{ID: id}
+	if code, err := attempt.QGetPaymentAttempt(db); err != nil {
+		return payment, code, err
+	}
+	if attempt.ReviewStatus != model.ReviewPending {
+		// not a review or decided, QDecideReview answers why
+		code, err := attempt.QDecideReview(db, model.ReviewApproved, note)
+		return payment, code, err
+	}
+	if attempt.OrderID == nil || attempt.CardID == nil {
+		return payment, 500, errors.New("review without order or card")
+	}
+
+	var order = model.Order{ID: *attempt.OrderID}
+	if _, err := order.GetOrderForPayment(db); err != nil {
+		return payment, http.StatusConflict, apperror.Conflict("order_not_payable", "order %d not found or already finished", order.ID)
+	}
+	if order.CurrentFee != attempt.Installment || order.InstallmentAmount() != attempt.Amount ||
+		deref(order.Currency) != attempt.Currency {
+		return payment, http.StatusConflict, apperror.Conflict("order_not_payable",
+			"order %d isn't due on the held installment anymore", order.ID)
+	}
+	var payer = model.Payer{ID: order.PayerID}
+	if code, err := payer.QGetPayer(db); err != nil {
+		return payment, code, err
+	}
+	var card = model.Card{ID: *attempt.CardID}
+	if code, err := card.QGetCard(db); err != nil {
+		return payment, code, err
+	}
+
+	if code, err := attempt.QDecideReview(db, model.ReviewApproved, note); err != nil {
+		return payment, code, err
+	}
+	// the held attempt gets the result of the charge
+	payment, code, err := charge(db, &order, payer, card, &attempt)
+	if subscription, ok := heldRenewal(db, order); ok {
+		if _, err := renewalResult(db, &subscription, err); err != nil {
+			log.WithContext(db.Statement.Context).Error("ApproveReview - subscription ", subscription.ID, " renewal result not saved: ", err)
+		}
+	}
+	return payment, code, err
+}
+
+// DenyReview - Denies a held payment, the order can't be charged anymore.
+// A held subscription renewal fails like a declined one.
+func DenyReview(db *gorm.DB, id int, note string) (model.PaymentAttempt, int, error) {
+	var attempt = model.PaymentAttempt{ID: id}
+	if code, err := attempt.QDecideReview(db, model.ReviewDenied, note); err != nil {
+		return attempt, code, err
+	}
+	if attempt.OrderID == nil {
+		return attempt, 200, nil
+	}
+	var order = model.Order{ID: *attempt.OrderID}
+	if _, err := order.QGetOrder(db); err != nil {
+		// denied all the same, QGetOrder logged why
+		return attempt, 200, nil
+	}
+	if subscription, ok := heldRenewal(db, order); ok {
+		if _, err := subscription.RenewalFailed(db); err != nil {
+			log.WithContext(db.Statement.Context).Error("DenyReview - subscription ", subscription.ID, " renewal result not saved: ", err)
+		}
+	}
+	return attempt, 200, nil
+}
+
+// heldRenewal - The order's subscription, if its renewal is in review
+func heldRenewal(db *gorm.DB, order model.Order) (model.Subscription, bool) {
+	if order.SubscriptionID == nil {
+		return model.Subscription{}, false
+	}
+	var subscription = model.Subscription{ID: *order.SubscriptionID}
+	if _, err := subscription.QGetSubscription(db); err != nil {
+		return subscription, false
+	}
+	return subscription, subscription.Status == model.SubscriptionInReview
+}
@@ -0,0 +1,125 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"systempayment/metrics"
+	"systempayment/model"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RenewDueSubscriptions - Charges every subscription whose period ended,
+// cancels the ones set to cancel at period end. Returns how many were renewed.
+func RenewDueSubscriptions(ctx context.Context, db *gorm.DB) (int, error) {
+	ids, err := model.QGetDueSubscriptions(db, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	renewed := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return renewed, ctx.Err()
+		}
+		ok, err := RenewSubscription(db, id)
+		var held *HeldError
+		if errors.As(err, &held) {
+			metrics.ScheduledCharges.WithLabelValues(metrics.ChargeHeld).Inc()
+			log.Info("RenewDueSubscriptions - subscription ", id, ": ", err)
+		} else if err != nil {
+			metrics.ScheduledCharges.WithLabelValues(metrics.ChargeFailed).Inc()
+			log.Error("RenewDueSubscriptions - subscription ", id, ": ", err)
+		}
+		if ok {
+			metrics.ScheduledCharges.WithLabelValues(metrics.ChargeRenewed).Inc()
+			renewed++
+		}
+	}
+	return renewed, nil
+}
+
+// RenewSubscription - Charges a subscription if its period ended
+//
+// The subscription is locked and marked as renewing in a transaction, the
+// card is charged once it's committed and the result is saved afterwards,
+// so the row isn't locked while dlocal answers. The charge goes through the
+// risk rules like any payment, a held one leaves the subscription in review
+// until the review is decided. A declined or denied charge is recorded on
+// the subscription (retry or cancel) and returned as the error, any other
+// failure leaves the subscription needing attention instead of due again.
+func RenewSubscription(db *gorm.DB, id int) (bool, error) {
+	var subscription = model.Subscription{ID: id}
+	var order model.Order
+	var payer model.Payer
+	var card model.Card
+	due := false
+	var chargeErr error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := subscription.QLockDueSubscription(tx, time.Now()); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// renewed by someone else or not due anymore
+				return nil
+			}
+			return err
+		}
+
+		if subscription.CancelAtPeriodEnd {
+			_, err := subscription.CancelAtEnd(tx)
+			return err
+		}
+
+		var err error
+		if order, _, err = subscription.QRenewalOrder(tx); err != nil {
+			return err
+		}
+
+		payer = model.Payer{ID: subscription.PayerID}
+		if _, err := payer.QGetPayer(tx); err != nil {
+			return err
+		}
+		card = model.Card{ID: subscription.CardID}
+		if _, err := card.QGetCard(tx); err != nil {
+			chargeErr = errors.New("subscription card not found")
+			_, err = subscription.RenewalFailed(tx)
+			return err
+		}
+
+		due = true
+		_, err = subscription.StartRenewal(tx)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if !due {
+		return false, chargeErr
+	}
+
+	_, _, chargeErr = ScreenAndCharge(db, &order, payer, card, "")
+	if _, err := renewalResult(db, &subscription, chargeErr); err != nil {
+		// still renewing, it isn't charged again
+		log.Error("RenewSubscription - subscription ", id, " renewal result not saved: ", err)
+		return chargeErr == nil, err
+	}
+	return chargeErr == nil, chargeErr
+}
+
+// renewalResult - Records the result of a renewal charge on the subscription.
+// Only a definite decline is retried, when the card may have been charged
+// (not saved, dlocal timed out or failed) a retry could charge it twice
+func renewalResult(db *gorm.DB, subscription *model.Subscription, chargeErr error) (int, error) {
+	var held *HeldError
+	switch {
+	case chargeErr == nil:
+		return subscription.RenewalSucceeded(db)
+	case errors.As(chargeErr, &held):
+		return subscription.RenewalHeld(db)
+	case Declined(chargeErr):
+		return subscription.RenewalFailed(db)
+	default:
+		return subscription.RenewalNotSaved(db)
+	}
+}
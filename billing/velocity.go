@@ -0,0 +1,29 @@
+package billing
+
+import (
+	"net/http"
+	"systempayment/apperror"
+	"systempayment/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CheckCardSaves - Fails with 429 once limit card saves of the payer were
+// rejected in the window, so a payer can't be used to test stolen cards.
+// Limit 0 turns the check off
+func CheckCardSaves(db *gorm.DB, payerID int, limit int, window time.Duration) (int, error) {
+	if limit <= 0 {
+		return 200, nil
+	}
+	var attempts = model.PaymentAttempt{Kind: model.AttemptCardSave, PayerID: payerID}
+	count, code, err := attempts.QCountPaymentAttempts(db, []string{model.AttemptRejected}, time.Now().Add(-window))
+	if err != nil {
+		return code, err
+	}
+	if count >= int64(limit) {
+		return http.StatusTooManyRequests, apperror.TooManyRequests(apperror.CodeTooManyFailures,
+			"%d cards of the payer were rejected recently, try again later", count)
+	}
+	return 200, nil
+}
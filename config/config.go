@@ -0,0 +1,247 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Config - settings of the application. Loaded once on startup with Load
+// and passed to the packages that need them
+type Config struct {
+	Port     string   `yaml:"port"`
+	LogLevel string   `yaml:"log_level"`
+	Database Database `yaml:"database"`
+	Dlocal   Dlocal   `yaml:"dlocal"`
+	CORS     CORS     `yaml:"cors"`
+	SMTP     SMTP     `yaml:"smtp"`
+	// Proxies (IPs or CIDRs) whose X-Forwarded-For is believed, none by
+	// default so the client IP is the connection's
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// Bearer token of the /api/v1/admin (back-office) routes
+	AdminToken string `yaml:"admin_token"`
+
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Risk      Risk      `yaml:"risk"`
+
+	PIIKeyFile    string `yaml:"pii_key_file"`
+	FXRatesFile   string `yaml:"fx_rates_file"`
+	NotifyFile    string `yaml:"notify_file"`
+	SettlementDir string `yaml:"settlement_dir"`
+
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// Time between readiness failing and the listener closing, for load
+	// balancers to stop routing requests. Part of ShutdownTimeout
+	DrainDelay       time.Duration `yaml:"drain_delay"`
+	ReadyCheckDlocal bool          `yaml:"ready_check_dlocal"`
+}
+
+// Database - postgres connection, Host with the port (localhost:5432)
+type Database struct {
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Host     string `yaml:"host"`
+	Name     string `yaml:"name"`
+}
+
+// Dlocal - API url and credentials
+type Dlocal struct {
+	URL      string `yaml:"url"`
+	Login    string `yaml:"x_login"`
+	TransKey string `yaml:"x_trans_key"`
+	Secret   string `yaml:"secret"`
+}
+
+// CORS - origins allowed to call the API from a browser
+type CORS struct {
+	Origins []string `yaml:"origins"`
+}
+
+// SMTP - mail server of the notifications, written to NotifyFile or the
+// log when Host is empty
+type SMTP struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// RateLimit - limits of the card endpoints, which charge dlocal. Store is
+// memory (per instance) or postgres (shared by the replicas). PerAPIKey
+// counts by Authorization header, by IP without one
+type RateLimit struct {
+	Store     string `yaml:"store"`
+	PerIP     Limit  `yaml:"per_ip"`
+	PerAPIKey Limit  `yaml:"per_api_key"`
+	PerPayer  Limit  `yaml:"per_payer"`
+	// CardFailures - rejected card saves of a payer before the next ones
+	// are blocked
+	CardFailures Limit `yaml:"card_failures"`
+}
+
+// Limit - Requests allowed in Window, 0 turns the limit off. Written
+// requests/window in variables (10/1m)
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+}
+
+// Risk - rules checked before charging a card. Amounts are by currency,
+// currencies left out aren't checked. Blocked emails and documents deny
+// card saves too
+type Risk struct {
+	Enabled          bool               `yaml:"enabled"`
+	ReviewAmounts    map[string]float64 `yaml:"review_amounts"`
+	DenyAmounts      map[string]float64 `yaml:"deny_amounts"`
+	CountryMismatch  bool               `yaml:"country_mismatch"`
+	PayerVelocity    Limit              `yaml:"payer_velocity"`
+	CardVelocity     Limit              `yaml:"card_velocity"`
+	NewPayerAge      time.Duration      `yaml:"new_payer_age"`
+	NewPayerAmounts  map[string]float64 `yaml:"new_payer_amounts"`
+	BlockedEmails    []string           `yaml:"blocked_emails"`
+	BlockedDocuments []string           `yaml:"blocked_documents"`
+}
+
+// Rate limit stores
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// Default - values of the settings that aren't set
+func Default() Config {
+	return Config{
+		Port:     ":8080",
+		LogLevel: "info",
+		CORS:     CORS{Origins: []string{"http://localhost:3000"}},
+		RateLimit: RateLimit{
+			Store:        StoreMemory,
+			PerIP:        Limit{Requests: 10, Window: time.Minute},
+			PerAPIKey:    Limit{Requests: 60, Window: time.Minute},
+			PerPayer:     Limit{Requests: 5, Window: time.Hour},
+			CardFailures: Limit{Requests: 3, Window: 24 * time.Hour},
+		},
+		Risk: Risk{
+			Enabled:         true,
+			CountryMismatch: true,
+			PayerVelocity:   Limit{Requests: 10, Window: time.Hour},
+			CardVelocity:    Limit{Requests: 10, Window: time.Hour},
+			NewPayerAge:     24 * time.Hour,
+		},
+		ShutdownTimeout: 30 * time.Second,
+		DrainDelay:      5 * time.Second,
+	}
+}
+
+// Errors - every invalid setting, reported together
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid configuration: " + strings.Join(e, "; ")
+}
+
+func (e *Errors) add(format string, args ...interface{}) {
+	*e = append(*e, fmt.Sprintf(format, args...))
+}
+
+// Validate - Checks the required settings are set and the rest are well
+// formed. Settings are named by their file key and variable
+func (c Config) Validate() error {
+	var errs Errors
+	required := func(value string, key string, env string) {
+		if strings.TrimSpace(value) == "" {
+			errs.add("%s (%s) is required", key, env)
+		}
+	}
+
+	required(c.Port, "port", "APPLICATION_PORT")
+	if c.Port != "" {
+		if _, _, err := net.SplitHostPort(c.Port); err != nil {
+			errs.add("port (APPLICATION_PORT) must be [host]:port, got %q", c.Port)
+		}
+	}
+	if _, err := log.ParseLevel(c.LogLevel); err != nil {
+		errs.add("log_level (LOG_LEVEL) must be one of panic, fatal, error, warn, info, debug or trace, got %q", c.LogLevel)
+	}
+
+	required(c.Database.User, "database.user", "POSTGRES_USER")
+	required(c.Database.Host, "database.host", "DATABASE_HOST")
+	required(c.Database.Name, "database.name", "POSTGRES_DB")
+
+	required(c.Dlocal.URL, "dlocal.url", "DLOCAL_URL")
+	required(c.Dlocal.Login, "dlocal.x_login", "DLOCAL_X_LOGIN")
+	required(c.Dlocal.TransKey, "dlocal.x_trans_key", "DLOCAL_X_TRANS_KEY")
+	required(c.Dlocal.Secret, "dlocal.secret", "DLOCAL_SECRET")
+	required(c.AdminToken, "admin_token", "ADMIN_TOKEN")
+	if c.Dlocal.URL != "" {
+		if u, err := url.Parse(c.Dlocal.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add("dlocal.url (DLOCAL_URL) must be an http(s) url, got %q", c.Dlocal.URL)
+		}
+	}
+
+	for _, origin := range c.CORS.Origins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			errs.add("cors.origins (CORS_ORIGINS) must be urls or *, got %q", origin)
+		}
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errs.add("trusted_proxies (TRUSTED_PROXIES) must be IPs or CIDRs, got %q", proxy)
+		}
+	}
+
+	if c.SMTP.Host != "" {
+		required(c.SMTP.Port, "smtp.port", "SMTP_PORT")
+		required(c.SMTP.From, "smtp.from", "SMTP_FROM")
+	}
+
+	if c.RateLimit.Store != StoreMemory && c.RateLimit.Store != StorePostgres {
+		errs.add("rate_limit.store (RATE_LIMIT_STORE) must be memory or postgres, got %q", c.RateLimit.Store)
+	}
+	limit := func(l Limit, key string, env string) {
+		if l.Requests < 0 || (l.Requests > 0 && l.Window <= 0) {
+			errs.add("%s (%s) must be a positive number of requests in a positive window, got %d in %s", key, env, l.Requests, l.Window)
+		}
+	}
+	limit(c.RateLimit.PerIP, "rate_limit.per_ip", "RATE_LIMIT_PER_IP")
+	limit(c.RateLimit.PerAPIKey, "rate_limit.per_api_key", "RATE_LIMIT_PER_API_KEY")
+	limit(c.RateLimit.PerPayer, "rate_limit.per_payer", "RATE_LIMIT_PER_PAYER")
+	limit(c.RateLimit.CardFailures, "rate_limit.card_failures", "RATE_LIMIT_CARD_FAILURES")
+
+	limit(c.Risk.PayerVelocity, "risk.payer_velocity", "RISK_PAYER_VELOCITY")
+	limit(c.Risk.CardVelocity, "risk.card_velocity", "RISK_CARD_VELOCITY")
+	amounts := func(m map[string]float64, key string, env string) {
+		for currency, amount := range m {
+			if len(currency) != 3 || amount <= 0 {
+				errs.add("%s (%s) must be positive amounts by currency code, got %s %v", key, env, currency, amount)
+			}
+		}
+	}
+	amounts(c.Risk.ReviewAmounts, "risk.review_amounts", "RISK_REVIEW_AMOUNTS")
+	amounts(c.Risk.DenyAmounts, "risk.deny_amounts", "RISK_DENY_AMOUNTS")
+	amounts(c.Risk.NewPayerAmounts, "risk.new_payer_amounts", "RISK_NEW_PAYER_AMOUNTS")
+	if c.Risk.NewPayerAge < 0 {
+		errs.add("risk.new_payer_age (RISK_NEW_PAYER_AGE) can't be negative, got %s", c.Risk.NewPayerAge)
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		errs.add("shutdown_timeout (SHUTDOWN_TIMEOUT) must be positive, got %s", c.ShutdownTimeout)
+	}
+	if c.DrainDelay < 0 || c.DrainDelay >= c.ShutdownTimeout {
+		errs.add("drain_delay (DRAIN_DELAY) must be between 0 and shutdown_timeout, got %s", c.DrainDelay)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func valid() Config {
+	c := Default()
+	c.Database = Database{User: "spuser", Host: "localhost:5432", Name: "system_payment"}
+	c.Dlocal = Dlocal{URL: "https://sandbox.dlocal.com", Login: "login", TransKey: "trans", Secret: "secret"}
+	c.AdminToken = "token"
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		errors []string
+	}{
+		{"valid", func(c *Config) {}, nil},
+		{"admin token missing", func(c *Config) { c.AdminToken = " " }, []string{"admin_token (ADMIN_TOKEN) is required"}},
+		{"dlocal missing", func(c *Config) { c.Dlocal = Dlocal{} }, []string{
+			"dlocal.url (DLOCAL_URL) is required",
+			"dlocal.x_login (DLOCAL_X_LOGIN) is required",
+			"dlocal.x_trans_key (DLOCAL_X_TRANS_KEY) is required",
+			"dlocal.secret (DLOCAL_SECRET) is required",
+		}},
+		{"dlocal url without scheme", func(c *Config) { c.Dlocal.URL = "sandbox.dlocal.com" }, []string{
+			`dlocal.url (DLOCAL_URL) must be an http(s) url, got "sandbox.dlocal.com"`,
+		}},
+		{"port without colon", func(c *Config) { c.Port = "8080" }, []string{
+			`port (APPLICATION_PORT) must be [host]:port, got "8080"`,
+		}},
+		{"unknown log level", func(c *Config) { c.LogLevel = "verbose" }, []string{
+			`log_level (LOG_LEVEL) must be one of panic, fatal, error, warn, info, debug or trace, got "verbose"`,
+		}},
+		{"any cors origin", func(c *Config) { c.CORS.Origins = []string{"*"} }, nil},
+		{"trusted proxies", func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1", "proxy"} }, []string{
+			`trusted_proxies (TRUSTED_PROXIES) must be IPs or CIDRs, got "proxy"`,
+		}},
+		{"smtp without from", func(c *Config) { c.SMTP = SMTP{Host: "smtp.mail.com", Port: "587"} }, []string{
+			"smtp.from (SMTP_FROM) is required",
+		}},
+		{"unknown store", func(c *Config) { c.RateLimit.Store = "redis" }, []string{
+			`rate_limit.store (RATE_LIMIT_STORE) must be memory or postgres, got "redis"`,
+		}},
+		{"limit turned off", func(c *Config) { c.RateLimit.PerIP = Limit{} }, nil},
+		{"limit without window", func(c *Config) { c.RateLimit.PerAPIKey = Limit{Requests: 10} }, []string{
+			"rate_limit.per_api_key (RATE_LIMIT_PER_API_KEY) must be a positive number of requests in a positive window, got 10 in 0s",
+		}},
+		{"risk amounts", func(c *Config) { c.Risk.DenyAmounts = map[string]float64{"US": 10} }, []string{
+			"risk.deny_amounts (RISK_DENY_AMOUNTS) must be positive amounts by currency code, got US 10",
+		}},
+		{"drain delay over the shutdown timeout", func(c *Config) { c.DrainDelay = c.ShutdownTimeout }, []string{
+			"drain_delay (DRAIN_DELAY) must be between 0 and shutdown_timeout, got 30s",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.change(&c)
+			err := c.Validate()
+			if tt.errors == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			errs, ok := err.(Errors)
+			if !ok {
+				t.Fatalf("Validate() = %v, want Errors", err)
+			}
+			if !reflect.DeepEqual([]string(errs), tt.errors) {
+				t.Errorf("Validate() = %q, want %q", errs, tt.errors)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Limit
+		err   bool
+	}{
+		{"10/1m", Limit{Requests: 10, Window: time.Minute}, false},
+		{"3/24h", Limit{Requests: 3, Window: 24 * time.Hour}, false},
+		{"0", Limit{}, false},
+		{"10", Limit{}, true},
+		{"ten/1m", Limit{}, true},
+		{"10/minute", Limit{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseLimit(tt.value)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("parseLimit(%q) = %v, %v, want %v (error %v)", tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestParseAmounts(t *testing.T) {
+	tests := []struct {
+		value string
+		want  map[string]float64
+		err   bool
+	}{
+		{"USD:1000,UYU:40000", map[string]float64{"USD": 1000, "UYU": 40000}, false},
+		{" usd : 10.5 ,", map[string]float64{"USD": 10.5}, false},
+		{"USD", nil, true},
+		{"USD:ten", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAmounts(tt.value)
+		if (err != nil) != tt.err || (!tt.err && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("parseAmounts(%q) = %v, %v, want %v (error %v)", tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"a,b", []string{"a", "b"}},
+		{" a , ,b ,", []string{"a", "b"}},
+		{"a\nb\r\n", []string{"a", "b"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitList(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitList(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	yaml := `port: :8081
+database: {user: spuser, host: localhost:5432, name: system_payment}
+dlocal: {url: https://sandbox.dlocal.com, x_login: login, x_trans_key: trans, secret: from-file}
+admin_token: token
+rate_limit:
+  per_ip: {requests: 20, window: 1m}
+risk:
+  enabled: true
+  blocked_emails: [fraud@mail.com]
+`
+	if err := os.WriteFile(file, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "dlocal_secret")
+	if err := os.WriteFile(secret, []byte("from-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APPLICATION_PORT", ":9090")
+	t.Setenv("DLOCAL_SECRET", "")
+	t.Setenv("DLOCAL_SECRET_FILE", secret)
+	t.Setenv("RATE_LIMIT_PER_PAYER", "0")
+	t.Setenv("RISK_DENY_AMOUNTS", "usd:5000")
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if c.Port != ":9090" {
+		t.Errorf("Port = %q, want the variable over the file", c.Port)
+	}
+	if c.Dlocal.Secret != "from-secret" {
+		t.Errorf("Dlocal.Secret = %q, want the _FILE content without the newline", c.Dlocal.Secret)
+	}
+	if c.RateLimit.PerIP != (Limit{Requests: 20, Window: time.Minute}) {
+		t.Errorf("RateLimit.PerIP = %v, want the file's", c.RateLimit.PerIP)
+	}
+	if c.RateLimit.PerAPIKey != Default().RateLimit.PerAPIKey {
+		t.Errorf("RateLimit.PerAPIKey = %v, want the default", c.RateLimit.PerAPIKey)
+	}
+	if c.RateLimit.PerPayer != (Limit{}) {
+		t.Errorf("RateLimit.PerPayer = %v, want turned off", c.RateLimit.PerPayer)
+	}
+	if !c.Risk.Enabled || !reflect.DeepEqual(c.Risk.BlockedEmails, []string{"fraud@mail.com"}) {
+		t.Errorf("Risk = %+v, want the file's", c.Risk)
+	}
+	if !reflect.DeepEqual(c.Risk.DenyAmounts, map[string]float64{"USD": 5000}) {
+		t.Errorf("Risk.DenyAmounts = %v, want USD 5000", c.Risk.DenyAmounts)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("admin_tokn: token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(file); err == nil || !strings.Contains(err.Error(), "admin_tokn") {
+		t.Errorf("Load() = %v, want the misspelled key", err)
+	}
+
+	t.Setenv("RISK_ENABLED", "yes")
+	t.Setenv("DRAIN_DELAY", "5")
+	t.Setenv("ADMIN_TOKEN_FILE", filepath.Join(dir, "missing"))
+	_, err := Load("")
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("Load() = %v, want the three bad variables", err)
+	}
+	for _, name := range []string{"RISK_ENABLED", "DRAIN_DELAY", "ADMIN_TOKEN_FILE"} {
+		if !strings.Contains(errs.Error(), name) {
+			t.Errorf("Load() = %v, want %s", errs, name)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load - Reads the settings from the YAML file at path, if any, and then
+// from the environment, which wins over the file. Every variable can also
+// be read from a file named by the variable with the _FILE suffix
+// (DLOCAL_SECRET_FILE=/run/secrets/dlocal_secret), for docker secrets.
+//
+// The config is returned even when it's invalid, with the error listing
+// every problem.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.fromFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.fromEnv(); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+func (c *Config) fromFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	// Misspelled keys would be ignored otherwise
+	decoder.KnownFields(true)
+	if err = decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return errors.New(path + ": " + err.Error())
+	}
+	return nil
+}
+
+func (c *Config) fromEnv() error {
+	var errs Errors
+	str := func(target *string, name string) {
+		value, ok, err := lookup(name)
+		if err != nil {
+			errs.add("%s", err)
+		} else if ok {
+			*target = value
+		}
+	}
+
+	str(&c.Port, "APPLICATION_PORT")
+	str(&c.LogLevel, "LOG_LEVEL")
+
+	str(&c.Database.User, "POSTGRES_USER")
+	str(&c.Database.Password, "POSTGRES_PASSWORD")
+	str(&c.Database.Host, "DATABASE_HOST")
+	str(&c.Database.Name, "POSTGRES_DB")
+
+	str(&c.Dlocal.URL, "DLOCAL_URL")
+	str(&c.Dlocal.Login, "DLOCAL_X_LOGIN")
+	str(&c.Dlocal.TransKey, "DLOCAL_X_TRANS_KEY")
+	str(&c.Dlocal.Secret, "DLOCAL_SECRET")
+
+	str(&c.SMTP.Host, "SMTP_HOST")
+	str(&c.SMTP.Port, "SMTP_PORT")
+	str(&c.SMTP.User, "SMTP_USER")
+	str(&c.SMTP.Password, "SMTP_PASSWORD")
+	str(&c.SMTP.From, "SMTP_FROM")
+
+	str(&c.PIIKeyFile, "PII_KEY_FILE")
+	str(&c.FXRatesFile, "FX_RATES_FILE")
+	str(&c.NotifyFile, "NOTIFY_FILE")
+	str(&c.SettlementDir, "SETTLEMENT_DIR")
+	str(&c.AdminToken, "ADMIN_TOKEN")
+
+	// Comma separated
+	var origins string
+	str(&origins, "CORS_ORIGINS")
+	if origins != "" {
+		c.CORS.Origins = splitList(origins)
+	}
+
+	boolean := func(target *bool, name string) {
+		var value string
+		str(&value, name)
+		if value == "" {
+			return
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			errs.add("%s must be true or false, got %q", name, value)
+		}
+		*target = parsed
+	}
+	duration := func(target *time.Duration, name string) {
+		var value string
+		str(&value, name)
+		if value == "" {
+			return
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			errs.add("%s must be a duration (30s, 1m), got %q", name, value)
+		}
+		*target = parsed
+	}
+
+	str(&c.RateLimit.Store, "RATE_LIMIT_STORE")
+	limit := func(target *Limit, name string) {
+		var value string
+		str(&value, name)
+		if value == "" {
+			return
+		}
+		parsed, err := parseLimit(value)
+		if err != nil {
+			errs.add("%s must be requests/window (10/1m) or 0, got %q", name, value)
+		}
+		*target = parsed
+	}
+	limit(&c.RateLimit.PerIP, "RATE_LIMIT_PER_IP")
+	limit(&c.RateLimit.PerAPIKey, "RATE_LIMIT_PER_API_KEY")
+	limit(&c.RateLimit.PerPayer, "RATE_LIMIT_PER_PAYER")
+	limit(&c.RateLimit.CardFailures, "RATE_LIMIT_CARD_FAILURES")
+
+	boolean(&c.Risk.Enabled, "RISK_ENABLED")
+	boolean(&c.Risk.CountryMismatch, "RISK_COUNTRY_MISMATCH")
+	amounts := func(target *map[string]float64, name string) {
+		var value string
+		str(&value, name)
+		if value == "" {
+			return
+		}
+		parsed, err := parseAmounts(value)
+		if err != nil {
+			errs.add("%s must be currency:amount pairs (USD:1000,UYU:40000), got %q", name, value)
+		}
+		*target = parsed
+	}
+	amounts(&c.Risk.ReviewAmounts, "RISK_REVIEW_AMOUNTS")
+	amounts(&c.Risk.DenyAmounts, "RISK_DENY_AMOUNTS")
+	amounts(&c.Risk.NewPayerAmounts, "RISK_NEW_PAYER_AMOUNTS")
+	limit(&c.Risk.PayerVelocity, "RISK_PAYER_VELOCITY")
+	limit(&c.Risk.CardVelocity, "RISK_CARD_VELOCITY")
+	duration(&c.Risk.NewPayerAge, "RISK_NEW_PAYER_AGE")
+	list := func(target *[]string, name string) {
+		var value string
+		str(&value, name)
+		if value != "" {
+			*target = splitList(value)
+		}
+	}
+	list(&c.TrustedProxies, "TRUSTED_PROXIES")
+	list(&c.Risk.BlockedEmails, "RISK_BLOCKED_EMAILS")
+	list(&c.Risk.BlockedDocuments, "RISK_BLOCKED_DOCUMENTS")
+
+	duration(&c.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+	duration(&c.DrainDelay, "DRAIN_DELAY")
+
+	boolean(&c.ReadyCheckDlocal, "READY_CHECK_DLOCAL")
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// parseLimit - "10/1m", or "0" for no limit
+func parseLimit(value string) (Limit, error) {
+	if value == "0" {
+		return Limit{}, nil
+	}
+	n := strings.IndexByte(value, '/')
+	if n < 0 {
+		return Limit{}, errors.New("missing window")
+	}
+	requests, err := strconv.Atoi(value[:n])
+	if err != nil {
+		return Limit{}, err
+	}
+	window, err := time.ParseDuration(value[n+1:])
+	if err != nil {
+		return Limit{}, err
+	}
+	return Limit{Requests: requests, Window: window}, nil
+}
+
+// parseAmounts - "USD:1000,UYU:40000"
+func parseAmounts(value string) (map[string]float64, error) {
+	amounts := map[string]float64{}
+	for _, pair := range splitList(value) {
+		n := strings.IndexByte(pair, ':')
+		if n < 0 {
+			return nil, errors.New("missing amount")
+		}
+		amount, err := strconv.ParseFloat(strings.TrimSpace(pair[n+1:]), 64)
+		if err != nil {
+			return nil, err
+		}
+		amounts[strings.ToUpper(strings.TrimSpace(pair[:n]))] = amount
+	}
+	return amounts, nil
+}
+
+// splitList - comma separated values, blanks left out. Newlines separate
+// too, for lists read from files
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// lookup - Value of the variable, or the content of the file named by
+// name_FILE without the trailing newline. Empty variables count as unset,
+// compose passes the ones missing from .env as empty
+func lookup(name string) (string, bool, error) {
+	if value := os.Getenv(name); value != "" {
+		return value, true, nil
+	}
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, errors.New(name + "_FILE: " + err.Error())
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
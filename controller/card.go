@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"systempayment/database"
+	"strings"
+	"systempayment/apperror"
+	"systempayment/billing"
 	"systempayment/dlocal"
 	"systempayment/httputil"
+	"systempayment/logging"
+	"systempayment/metrics"
 	"systempayment/model"
+	"systempayment/risk"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +20,7 @@ import (
 // SaveCard godoc
 //
 //	@Summary		Saves a new Card
-//	@Description	Creates a new payment of 1USD with a CC token, saves card returned by dlocal.
+//	@Description	Creates a new payment of 1USD with a CC token, saves card returned by dlocal. Rate limited by IP, API key and payer, and blocked for a while after too many rejected cards of the payer.
 //	@Tags			Card
 //	@Accept			json
 //
@@ -23,41 +29,104 @@ import (
 //
 //	@Produce		json
 //	@Success		200	{object}	model.PaymentResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		402	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		429	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Failure		502	{object}	httputil.ProblemDetails
+//	@Failure		504	{object}	httputil.ProblemDetails
 //	@Router			/card/save-card [post]
 func (c *Controller) SaveCard(ctx *gin.Context) {
 	var token model.Token
 	if err := ctx.BindJSON(&token); err != nil || token.Token == "" {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
 		return
 	}
 	payer_id, err := strconv.Atoi(ctx.Query("payer_id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: payer_id", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: payer_id", err)
 		return
 	}
 	var payer = model.Payer{ID: payer_id}
-	if code, err := payer.QGetPayer(database.DB); code != 200 {
-		httputil.Error400(ctx, http.StatusBadRequest, "Payer not found", err)
+	if code, err := payer.QGetPayer(db(ctx)); code != 200 {
+		httputil.Problem(ctx, http.StatusNotFound, "Payer not found", err)
 		return
 	}
+	if payer.ErasedAt != nil {
+		httputil.Problem(ctx, http.StatusNotFound, "Payer not found", errors.New("payer erased"))
+		return
+	}
+
+	// every attempt is kept for the velocity check, blocked ones too
+	attempt := model.PaymentAttempt{Kind: model.AttemptCardSave, PayerID: payer.ID, IP: ctx.ClientIP()}
+	record := func(result string, reason string) {
+		attempt.Result, attempt.Reason = result, reason
+		attempt.QSavePaymentAttempt(db(ctx))
+	}
 
-	code, response, err := dlocal.PaymentWithToken(payer, token.Token)
+	failures := c.config.RateLimit.CardFailures
+	if code, err := billing.CheckCardSaves(db(ctx), payer.ID, failures.Requests, failures.Window); err != nil {
+		if code == http.StatusTooManyRequests {
+			record(model.AttemptBlocked, err.Error())
+		}
+		httputil.Problem(ctx, code, "", err)
+		return
+	}
+
+	// only the blocklists apply before there's a card
+	decision, _, err := risk.Evaluate(db(ctx), risk.Input{Payer: payer})
 	if err != nil {
-		ctx.JSON(code, err)
+		httputil.Problem(ctx, http.StatusInternalServerError, "Could not check the risk rules", err)
+		return
+	}
+	attempt.RiskOutcome, attempt.RiskReasons = decision.Outcome, strings.Join(decision.Reasons, ",")
+	if decision.Outcome == risk.Deny {
+		record(model.AttemptBlocked, "")
+		httputil.Problem(ctx, http.StatusPaymentRequired, "",
+			apperror.PaymentRequired(apperror.CodeRiskDenied, "card denied by risk rules: %s", attempt.RiskReasons))
+		return
 	}
 
+	code, response, err := dlocal.PaymentWithToken(logging.Detach(ctx.Request.Context()), payer, token.Token)
+	if err != nil {
+		record(model.AttemptFailed, err.Error())
+		switch code {
+		case 408:
+			httputil.Problem(ctx, http.StatusGatewayTimeout, "dlocal did not respond", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not send the card to dlocal", err)
+		}
+		return
+	}
 	if code != 200 {
-		ctx.JSON(code, response)
+		dlocalErr := &billing.DlocalError{Code: code, Response: response}
+		// 5xx are dlocal's, not the card's
+		if code >= 500 {
+			record(model.AttemptFailed, dlocalErr.Error())
+		} else {
+			record(model.AttemptRejected, dlocalErr.Error())
+		}
+		httputil.Problem(ctx, http.StatusBadGateway, "dlocal did not save the card", dlocalErr)
+		return
+	}
+	if status, _ := response["status"].(string); status == dlocal.StatusRejected {
+		// the declined card isn't saved
+		dlocalErr := &billing.DlocalError{Code: http.StatusPaymentRequired, Response: response}
+		record(model.AttemptRejected, dlocalErr.Error())
+		httputil.Problem(ctx, http.StatusPaymentRequired, "", dlocalErr)
+		return
 	}
 
 	var card = model.Card{PayerID: payer.ID}
-	code, err = card.SaveCardFromResponse(database.DB, response)
+	code, err = card.SaveCardFromResponse(db(ctx), response)
 	if code != 200 {
-		httputil.Error400(ctx, http.StatusBadRequest, "Card validation failed", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Card validation failed", err)
 		return
 	}
+	metrics.CardsSaved.Inc()
+	attempt.CardID = &card.ID
+	record(model.AttemptApproved, "")
 
 	ctx.JSON(200, response)
 }
@@ -73,20 +142,21 @@ func (c *Controller) SaveCard(ctx *gin.Context) {
 //
 //	@Produce		json
 //	@Success		200	{object}	model.CardResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
 //	@Router			/card/{id} [get]
 func (o *Controller) GetCard(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid card ID", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid card ID", err)
 		return
 	}
 
 	card := model.Card{ID: id}
-	code, _ := card.QGetCard(database.DB)
+	code, _ := card.QGetCard(db(ctx))
 	if code != 200 {
-		httputil.Error400(ctx, http.StatusBadRequest, "Card not found", err)
+		httputil.Problem(ctx, http.StatusNotFound, "Card not found", err)
 		return
 	}
 	ctx.JSON(200, card)
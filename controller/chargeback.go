@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"systempayment/billing"
+	"systempayment/dlocal"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewChargeback godoc
+//
+//	@Summary		New Chargeback
+//	@Description	Records a chargeback by hand (dlocal's are received by notification). The amount defaults to the payment's, the evidence deadline to 10 days
+//	@Tags			Chargeback
+//	@Accept			json
+//
+// @Param   chargeback     body     model.ChargebackRequest     true  "Chargeback example"     example(model.ChargebackRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Chargeback
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/chargeback/new [post]
+func (c *Controller) NewChargeback(ctx *gin.Context) {
+	var request model.ChargebackRequest
+	if err := ctx.BindJSON(&request); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if request.PaymentID == 0 {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: payment_id", errors.New("payment_id is required"))
+		return
+	}
+
+	var chargeback = model.Chargeback{
+		PaymentID:  request.PaymentID,
+		ReasonCode: request.ReasonCode,
+		Reason:     request.Reason,
+		Amount:     request.Amount,
+	}
+	if request.DlocalID != nil && *request.DlocalID != "" {
+		chargeback.DlocalID = request.DlocalID
+	}
+	if request.EvidenceDueAt != nil {
+		chargeback.EvidenceDueAt = *request.EvidenceDueAt
+	}
+	if code, err := billing.OpenChargeback(db(ctx), &chargeback); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Chargeback can't be opened", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not save chargeback", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, chargeback)
+}
+
+// Chargebacks godoc
+//
+//	@Summary		Select chargebacks
+//	@Description	Chargebacks (optional status and payment), soonest evidence deadline first
+//	@Tags			Chargeback
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, evidence_due_at by default"  Enums(id, -id, created_at, -created_at, evidence_due_at, -evidence_due_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   status  query  string  false  "status"  Enums(open, evidence_submitted, won, lost)
+// @Param   payment_id  query  int  false  "payment_id example"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.Chargeback}
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/chargeback/chargebacks [get]
+func (c *Controller) Chargebacks(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.Chargeback{}, "evidence_due_at", pagination.Sort{Name: "evidence_due_at", Kind: pagination.Time})
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	payment_id, _ := strconv.Atoi(ctx.Query("payment_id"))
+
+	var chargeback = model.Chargeback{}
+	chargebacks, _, err := chargeback.QGetChargebacks(db(ctx), page, ctx.Query("status"), payment_id)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching chargebacks", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&chargebacks))
+}
+
+// GetChargeback godoc
+//
+//	@Summary		Get Chargeback
+//	@Description	Get chargeback by ID with its evidence documents
+//	@Tags			Chargeback
+//
+// @Param   id  path  int  true  "Chargeback ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Chargeback
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/chargeback/{id} [get]
+func (c *Controller) GetChargeback(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	var chargeback = model.Chargeback{ID: id}
+	if code, err := chargeback.QGetChargeback(db(ctx)); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Chargeback not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching chargeback", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, chargeback)
+}
+
+// UpdateChargebackStatus godoc
+//
+//	@Summary		Update Chargeback status
+//	@Description	Moves a chargeback to evidence_submitted, won or lost. Won gives the amount back to the merchant
+//	@Tags			Chargeback
+//	@Accept			json
+//
+// @Param   id  path  int  true  "Chargeback ID"  example(1)
+// @Param   status     body     model.ChargebackStatusRequest     true  "Status example"     example(model.ChargebackStatusRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Chargeback
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/chargeback/{id}/status [put]
+func (c *Controller) UpdateChargebackStatus(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	var request model.ChargebackStatusRequest
+	if err := ctx.BindJSON(&request); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	chargeback, code, err := billing.UpdateChargeback(db(ctx), id, request.Status, request.Note)
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Chargeback status can't be changed", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not update chargeback", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, chargeback)
+}
+
+// UploadChargebackEvidence godoc
+//
+//	@Summary		Upload Chargeback evidence
+//	@Description	Attaches a document (up to 10 MB) to an open chargeback before its evidence deadline
+//	@Tags			Chargeback
+//	@Accept			multipart/form-data
+//
+// @Param   id  path  int  true  "Chargeback ID"  example(1)
+// @Param   file  formData  file  true  "Evidence document"
+// @Param   description  formData  string  false  "Description"
+//
+//	@Produce		json
+//	@Success		200	{object}	model.ChargebackEvidence
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/chargeback/{id}/evidence [post]
+func (c *Controller) UploadChargebackEvidence(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: file", err)
+		return
+	}
+	if header.Size > model.ChargebackMaxEvidenceSize {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: file", errors.New("file is larger than 10 MB"))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Could not read file", err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Could not read file", err)
+		return
+	}
+
+	var evidence = model.ChargebackEvidence{
+		FileName:    header.Filename,
+		ContentType: http.DetectContentType(data),
+		Description: ctx.PostForm("description"),
+		Data:        data,
+	}
+	var chargeback = model.Chargeback{ID: id}
+	if code, err := chargeback.QAddEvidence(db(ctx), &evidence); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Evidence can't be added", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not save evidence", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, evidence)
+}
+
+// GetChargebackEvidence godoc
+//
+//	@Summary		Download Chargeback evidence
+//	@Description	Evidence document as it was uploaded
+//	@Tags			Chargeback
+//
+// @Param   id  path  int  true  "Chargeback ID"  example(1)
+// @Param   evidence_id  path  int  true  "Evidence ID"  example(1)
+//
+//	@Produce		octet-stream
+//	@Success		200	{file}		binary
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/chargeback/{id}/evidence/{evidence_id} [get]
+func (c *Controller) GetChargebackEvidence(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	evidence_id, err := strconv.Atoi(ctx.Param("evidence_id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: evidence_id", err)
+		return
+	}
+	var evidence = model.ChargebackEvidence{ID: evidence_id, ChargebackID: id}
+	if code, err := evidence.QGetEvidence(db(ctx)); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Evidence not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching evidence", err)
+		}
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", evidence.FileName))
+	ctx.Data(200, evidence.ContentType, evidence.Data)
+}
+
+// DlocalChargebackNotification godoc
+//
+//	@Summary		dlocal chargeback notification
+//	@Description	Receives dlocal's chargeback notifications, signed with the X-Date and Authorization headers
+//	@Tags			Chargeback
+//	@Accept			json
+//
+// @Param   notification     body     dlocal.ChargebackNotification     true  "Notification example"     example(dlocal.ChargebackNotification)
+//
+//	@Produce		json
+//	@Success		200	{object}	controller.Message
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/dlocal/notifications/chargebacks [post]
+func (c *Controller) DlocalChargebackNotification(ctx *gin.Context) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Could not read body", err)
+		return
+	}
+	if !dlocal.VerifyNotification(ctx.GetHeader("X-Date"), ctx.GetHeader("Authorization"), body) {
+		httputil.Problem(ctx, http.StatusUnauthorized, "Invalid signature", errors.New("signature doesn't match or X-Date is too old"))
+		return
+	}
+	var notification dlocal.ChargebackNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	chargeback, code, err := billing.ChargebackNotified(db(ctx), notification)
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Chargeback not recorded", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not record chargeback", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, Message{Message: fmt.Sprintf("chargeback %d %s", chargeback.ID, chargeback.Status)})
+}
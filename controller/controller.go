@@ -1,15 +1,33 @@
 package controller
 
+import (
+	"systempayment/config"
+	"systempayment/database"
+	"systempayment/logging"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
 // Controller example
 type Controller struct {
+	config config.Config
 }
 
 // NewController example
-func NewController() *Controller {
-	return &Controller{}
+func NewController(c config.Config) *Controller {
+	return &Controller{config: c}
 }
 
 // Message example
 type Message struct {
 	Message string `json:"message" example:"message"`
 }
+
+// db - Database session with the request id of the request for the logs
+//
+// It doesn't take the request's cancellation: a client that disconnects
+// mustn't roll back a payment dlocal already made.
+func db(ctx *gin.Context) *gorm.DB {
+	return database.DB.WithContext(logging.Detach(ctx.Request.Context()))
+}
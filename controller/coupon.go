@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewCoupon godoc
+//
+//	@Summary		Insert Coupon
+//	@Description	save a discount Coupon. type percentage (value 0-100) or fixed (value in currency).
+//	@Tags			Coupon
+//	@Accept			json
+//
+// @Param   coupon     body     model.CouponRequest     true  "Coupon example"     example(model.CouponRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Coupon
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Router			/coupon/new [post]
+func (c *Controller) NewCoupon(ctx *gin.Context) {
+	var coupon model.Coupon
+	if err := ctx.BindJSON(&coupon); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	if _, err := coupon.QCreateCoupon(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Body validation failed", err)
+		return
+	}
+
+	ctx.JSON(200, coupon)
+}
+
+// Coupons godoc
+//
+//	@Summary		Select all Coupons
+//	@Description	Select all Coupons
+//	@Tags			Coupon
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   active  query  bool  false  "active example"  example(true)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.Coupon}
+//	@Router			/coupon/coupons [get]
+func (c *Controller) Coupons(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.Coupon{}, "id")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	active, _ := strconv.ParseBool(ctx.Query("active"))
+
+	var coupon = model.Coupon{}
+	coupons, _, err := coupon.QGetCoupons(db(ctx), page, active)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching Coupons", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&coupons))
+}
+
+// GetCoupon godoc
+//
+//	@Summary		Select Coupon
+//	@Description	Get one Coupon from ID
+//	@Tags			Coupon
+//
+// @Param   id  path  int  true  "Coupon ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Coupon
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Router			/coupon/{id} [get]
+func (c *Controller) GetCoupon(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	coupon := model.Coupon{ID: id}
+	if _, err := coupon.QGetCoupon(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusNotFound, "Coupon not found", err)
+		return
+	}
+
+	ctx.JSON(200, coupon)
+}
+
+// DeactivateCoupon godoc
+//
+//	@Summary		Deactivates Coupon
+//	@Description	Coupon can't be redeemed anymore, orders already using it keep their discount
+//	@Tags			Coupon
+//
+// @Param   id  path  int  true  "Coupon ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Coupon
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/coupon/{id}/deactivate [put]
+func (c *Controller) DeactivateCoupon(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	coupon := model.Coupon{ID: id}
+	if code, err := coupon.QDeactivateCoupon(db(ctx)); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Coupon not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not update Coupon", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, coupon)
+}
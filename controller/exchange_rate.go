@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"systempayment/dlocal"
+	"systempayment/httputil"
+	"systempayment/logging"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewExchangeRate godoc
+//
+//	@Summary		Insert Exchange Rate
+//	@Description	save a manual exchange rate (1 base = rate quote). Dates are UTC days, today by default. A rate for the same pair and date is replaced.
+//	@Tags			FX
+//	@Accept			json
+//
+// @Param   rate     body     model.ExchangeRate     true  "Exchange rate example"     example(model.ExchangeRate)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.ExchangeRate
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/fx/rates [post]
+func (c *Controller) NewExchangeRate(ctx *gin.Context) {
+	var rate model.ExchangeRate
+	if err := ctx.BindJSON(&rate); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	rate.Source = model.RateSourceManual
+
+	if code, err := rate.QCreateExchangeRate(db(ctx)); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Body validation failed", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not save exchange rate", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, rate)
+}
+
+// ExchangeRates godoc
+//
+//	@Summary		Select Exchange Rates
+//	@Description	Select exchange rates, newest first
+//	@Tags			FX
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, -date by default"  Enums(id, -id, created_at, -created_at, date, -date)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   base  query  string  false  "base example"  example(USD)
+// @Param   quote  query  string  false  "quote example"  example(UYU)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.ExchangeRate}
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/fx/rates [get]
+func (c *Controller) ExchangeRates(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.ExchangeRate{}, "-date", pagination.Sort{Name: "date", Kind: pagination.Time})
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	var rate = model.ExchangeRate{}
+	if base := ctx.Query("base"); base != "" {
+		rate.Base = &base
+	}
+	if quote := ctx.Query("quote"); quote != "" {
+		rate.Quote = &quote
+	}
+	rates, _, err := rate.QGetExchangeRates(db(ctx), page)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching exchange rates", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&rates))
+}
+
+// RefreshExchangeRate godoc
+//
+//	@Summary		Refresh Exchange Rate from dlocal
+//	@Description	Fetches the current rate for a currency pair from dlocal and stores it
+//	@Tags			FX
+//
+// @Param   base  query  string  true  "base example"  example(USD)
+// @Param   quote  query  string  true  "quote example"  example(UYU)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.ExchangeRate
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Failure		502	{object}	httputil.ProblemDetails
+//	@Failure		504	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/fx/rates/refresh [post]
+func (c *Controller) RefreshExchangeRate(ctx *gin.Context) {
+	base := strings.ToUpper(ctx.Query("base"))
+	quote := strings.ToUpper(ctx.Query("quote"))
+	if len(base) != 3 || len(quote) != 3 {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameters: base, quote",
+			errors.New("base and quote must be 3 letter currency codes"))
+		return
+	}
+
+	code, response, err := dlocal.GetExchangeRate(logging.Detach(ctx.Request.Context()), base, quote)
+	if err != nil {
+		switch code {
+		case 408:
+			httputil.Problem(ctx, http.StatusGatewayTimeout, "dlocal did not respond", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not reach dlocal", err)
+		}
+		return
+	}
+	if code != 200 {
+		message, _ := response["message"].(string)
+		httputil.Problem(ctx, http.StatusBadGateway, "dlocal did not return the rate",
+			fmt.Errorf("dlocal answered %d: %s", code, message))
+		return
+	}
+
+	var rate model.ExchangeRate
+	if code, err = rate.SaveExchangeRateFromResponse(db(ctx), response); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid dlocal exchange rate", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not save exchange rate", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, rate)
+}
+
+// ImportExchangeRates godoc
+//
+//	@Summary		Import Exchange Rates
+//	@Description	Loads rates from a CSV file with columns date,base,quote,rate (date as YYYY-MM-DD)
+//	@Tags			FX
+//	@Accept			multipart/form-data
+//
+// @Param   file  formData  file  true  "CSV file"
+//
+//	@Produce		json
+//	@Success		200	{object}	controller.Message
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/fx/rates/import [post]
+func (c *Controller) ImportExchangeRates(ctx *gin.Context) {
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: file", err)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Could not read file", err)
+		return
+	}
+	defer file.Close()
+
+	total, _, err := model.QImportExchangeRates(db(ctx), file)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid exchange rates file", err)
+		return
+	}
+
+	ctx.JSON(200, Message{Message: fmt.Sprintf("%d exchange rates imported", total)})
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"systempayment/export"
+	"systempayment/model"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// Filter and format of an export request, status must be one of statuses
+func exportParams(ctx *gin.Context, statuses ...string) (model.ExportFilter, string, error) {
+	var filter model.ExportFilter
+	format := strings.ToLower(ctx.DefaultQuery("format", export.CSV))
+	if format != export.CSV && format != export.XLSX {
+		return filter, format, errors.New("format must be csv or xlsx")
+	}
+
+	var err error
+	if filter.From, err = time.Parse("2006-01-02", ctx.Query("from")); err != nil {
+		return filter, format, errors.New("from must be a YYYY-MM-DD date")
+	}
+	if filter.To, err = time.Parse("2006-01-02", ctx.Query("to")); err != nil {
+		return filter, format, errors.New("to must be a YYYY-MM-DD date")
+	}
+	// whole last day
+	filter.To = filter.To.AddDate(0, 0, 1)
+	if !filter.To.After(filter.From) {
+		return filter, format, errors.New("to is before from")
+	}
+
+	filter.Currency = strings.ToUpper(ctx.Query("currency"))
+	filter.Country = strings.ToUpper(ctx.Query("country"))
+	if filter.Status = ctx.Query("status"); filter.Status != "" {
+		valid := false
+		for _, status := range statuses {
+			if status == filter.Status {
+				valid = true
+			}
+		}
+		if !valid {
+			return filter, format, errors.New("status must be one of " + strings.Join(statuses, ", "))
+		}
+	}
+	if product := ctx.Query("product_id"); product != "" {
+		if filter.ProductID, err = strconv.Atoi(product); err != nil {
+			return filter, format, errors.New("product_id must be a number")
+		}
+	}
+	return filter, format, nil
+}
+
+// Streams the rows written by rows as an attachment named name-from_to.format
+func streamExport(ctx *gin.Context, name string, filter model.ExportFilter, format string, rows func(export.Writer) error) {
+	w, err := export.New(format, ctx.Writer, name)
+	if err != nil {
+		log.Error("streamExport - ", err)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s-%s_%s.%s", name, filter.From.Format("2006-01-02"),
+		filter.To.AddDate(0, 0, -1).Format("2006-01-02"), format)
+	ctx.Header("Content-Type", export.ContentType(format))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	ctx.Status(200)
+
+	// the status is sent already, a failure can only cut the file short
+	if err := rows(w); err != nil {
+		log.Error("streamExport - ", name, ": ", err)
+		return
+	}
+	if err := w.Close(); err != nil {
+		log.Error("streamExport - ", name, ": ", err)
+	}
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"systempayment/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadyTimeout - how long each readiness check can take
+const ReadyTimeout = 2 * time.Second
+
+// Set once the server starts shutting down, readiness fails from then on
+var draining int32
+
+// Health - result of the readiness checks
+type Health struct {
+	Status string            `json:"status" example:"ok" enums:"ok,unavailable"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Drain - Fails readiness so no new traffic is sent while shutting down
+func (c *Controller) Drain() {
+	atomic.StoreInt32(&draining, 1)
+}
+
+// Healthz - Liveness, the process is up and answering. Outside /api/v1,
+// it's not in swagger
+func (c *Controller) Healthz(ctx *gin.Context) {
+	ctx.JSON(200, Health{Status: "ok"})
+}
+
+// Readyz - Readiness: postgres answers, and dlocal's host when
+// READY_CHECK_DLOCAL is true. Fails with 503 while shutting down
+func (c *Controller) Readyz(ctx *gin.Context) {
+	health := Health{Status: "ok", Checks: map[string]string{}}
+	check := func(name string, err error) {
+		health.Checks[name] = "ok"
+		if err != nil {
+			health.Status = "unavailable"
+			health.Checks[name] = err.Error()
+		}
+	}
+
+	if atomic.LoadInt32(&draining) == 1 {
+		check("server", errors.New("shutting down"))
+	}
+	check("database", pingDatabase(ctx.Request.Context()))
+	if c.config.ReadyCheckDlocal {
+		check("dlocal", pingDlocal(ctx.Request.Context(), c.config.Dlocal.URL))
+	}
+
+	status := http.StatusOK
+	if health.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, health)
+}
+
+func pingDatabase(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, ReadyTimeout)
+	defer cancel()
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Any answer counts, dlocal's host is reachable
+func pingDlocal(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, ReadyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, host, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LedgerTransactions godoc
+//
+//	@Summary		Select ledger transactions
+//	@Description	Ledger transactions with their entries, newest first
+//	@Tags			Ledger
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, -id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   order_id  query  int  false  "order_id example"  example(1)
+// @Param   merchant_id  query  int  false  "merchant_id example"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.LedgerTransaction}
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/ledger/transactions [get]
+func (c *Controller) LedgerTransactions(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.LedgerTransaction{}, "-id")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	order_id, _ := strconv.Atoi(ctx.Query("order_id"))
+	merchant_id, _ := strconv.Atoi(ctx.Query("merchant_id"))
+
+	var transaction = model.LedgerTransaction{}
+	transactions, _, err := transaction.QGetLedgerTransactions(db(ctx), page, order_id, merchant_id)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching ledger transactions", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&transactions))
+}
+
+// OrderBalance godoc
+//
+//	@Summary		Order balance
+//	@Description	Amount, paid, refunded and outstanding amount of the order from the ledger
+//	@Tags			Ledger
+//
+// @Param   id  path  int  true  "Order ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.OrderBalance
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/order/{id}/balance [get]
+func (c *Controller) OrderBalance(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	balance, code, err := model.QGetOrderBalance(db(ctx), id)
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Order not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching balance", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, balance)
+}
+
+// MerchantBalance godoc
+//
+//	@Summary		Merchant balance
+//	@Description	Sales, refunds, fees, chargebacks and net amount owed to the merchant per currency from the ledger
+//	@Tags			Ledger
+//
+// @Param   id  path  int  true  "Merchant ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{array}		model.MerchantBalance
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/merchant/{id}/balance [get]
+func (c *Controller) MerchantBalance(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	balances, code, err := model.QGetMerchantBalances(db(ctx), id)
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Merchant not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching balance", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, balances)
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewMerchant godoc
+//
+//	@Summary		Insert Merchant
+//	@Description	save a Merchant, invoices of its products' payments are numbered from 1
+//	@Tags			Merchant
+//	@Accept			json
+//
+// @Param   merchant     body     model.MerchantRequest     true  "Merchant example"     example(model.MerchantRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Merchant
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Router			/merchant/new [post]
+func (c *Controller) NewMerchant(ctx *gin.Context) {
+	var merchant model.Merchant
+	if err := ctx.BindJSON(&merchant); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	if _, err := merchant.QCreateMerchant(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Body validation failed", err)
+		return
+	}
+
+	ctx.JSON(200, merchant)
+}
+
+// Merchants godoc
+//
+//	@Summary		Select all Merchants
+//	@Description	Select all Merchants
+//	@Tags			Merchant
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.Merchant}
+//	@Router			/merchant/merchants [get]
+func (c *Controller) Merchants(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.Merchant{}, "id")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	var merchant = model.Merchant{}
+	merchants, _, err := merchant.QGetMerchants(db(ctx), page)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching Merchants", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&merchants))
+}
+
+// GetMerchant godoc
+//
+//	@Summary		Select Merchant
+//	@Description	Get one Merchant from ID
+//	@Tags			Merchant
+//
+// @Param   id  path  int  true  "Merchant ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Merchant
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/merchant/{id} [get]
+func (c *Controller) GetMerchant(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	merchant := model.Merchant{ID: id}
+	if code, err := merchant.QGetMerchant(db(ctx)); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Merchant not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching Merchant", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, merchant)
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Notifications godoc
+//
+//	@Summary		Select all Notifications
+//	@Description	Emails sent or waiting to be sent to payers, newest first
+//	@Tags			Notification
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, -id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   payer_id  query  int  false  "payer_id example"  example(1)
+// @Param   status  query  string  false  "status example"  Enums(pending, sent, failed, canceled)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.Notification}
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/notification/notifications [get]
+func (c *Controller) Notifications(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.Notification{}, "-id")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	payer_id, _ := strconv.Atoi(ctx.Query("payer_id"))
+
+	var notification = model.Notification{}
+	notifications, _, err := notification.QGetNotifications(db(ctx), page, payer_id, ctx.Query("status"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching Notifications", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&notifications))
+}
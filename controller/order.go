@@ -3,9 +3,10 @@ package controller
 import (
 	"net/http"
 	"strconv"
-	"systempayment/database"
+	"systempayment/export"
 	"systempayment/httputil"
 	"systempayment/model"
+	"systempayment/pagination"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,32 +24,82 @@ import (
 //
 //	@Produce		json
 //	@Success		200	{object}	model.OrderResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
 //	@Router			/order/new [post]
 func (o *Controller) NewOrder(ctx *gin.Context) {
 	payer_id, err := strconv.Atoi(ctx.Query("payer_id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: payer_id", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: payer_id", err)
 		return
 	}
 	auto, _ := strconv.ParseBool(ctx.Query("auto"))
-	var order model.Order
-	order.Auto = auto
-	if err := ctx.BindJSON(&order); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload", err)
+	var request model.OrderRequest
+	if err := ctx.BindJSON(&request); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
 		return
 	}
-	order.PayerID = payer_id
+	var order = model.Order{
+		PayerID:    payer_id,
+		ProductID:  request.ProductID,
+		Currency:   request.Currency,
+		TotalFees:  request.TotalFees,
+		CouponCode: request.CouponCode,
+		Auto:       auto,
+	}
 
-	if code, _ := order.QCreateOrder(database.DB); code != 200 {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload or query params", err)
+	if code, err := order.QCreateOrder(db(ctx)); code != 200 {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload or query params", err)
 		return
 	}
 
 	ctx.JSON(200, order)
 }
 
+// ExportOrders godoc
+//
+//	@Summary		Export Orders
+//	@Description	Streams the orders created between from and to (both included) as CSV or XLSX. Country is the tax country
+//	@Tags			Order
+//
+// @Param   from  query  string  true  "From (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  true  "To (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   format  query  string  false  "format"  Enums(csv, xlsx)
+// @Param   currency  query  string  false  "currency example"  example(USD)
+// @Param   country  query  string  false  "country example"  example(UY)
+// @Param   status  query  string  false  "status"  Enums(active, finished)
+// @Param   product_id  query  int  false  "product_id example"  example(1)
+//
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Success		200	{file}		binary
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/order/export [get]
+func (o *Controller) ExportOrders(ctx *gin.Context) {
+	filter, format, err := exportParams(ctx, model.OrderActive, model.OrderFinished)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid export parameters", err)
+		return
+	}
+
+	streamExport(ctx, "orders", filter, format, func(w export.Writer) error {
+		if err := w.Write("id", "created_at", "order_id", "payer_id", "product_id", "subscription_id",
+			"subtotal", "discount", "coupon_code", "amount", "currency", "net_amount", "tax_amount",
+			"tax_country", "total_fees", "current_fee", "next_payment", "finished"); err != nil {
+			return err
+		}
+		return model.QExportOrders(db(ctx), filter, func(order model.Order) error {
+			return w.Write(order.ID, order.CreatedAt, order.OrderId, order.PayerID, order.ProductID,
+				order.SubscriptionID, order.Subtotal, order.Discount, order.CouponCode, order.Amount,
+				order.Currency, order.NetAmount, order.TaxAmount, order.TaxCountry, order.TotalFees,
+				order.CurrentFee, order.NextPayment, order.Finished)
+		})
+	})
+}
+
 // Orders godoc
 //
 //	@Summary		Select all Orders
@@ -56,41 +107,45 @@ func (o *Controller) NewOrder(ctx *gin.Context) {
 //	@Tags			Order
 //	@Accept			json
 //
-// @Param   start  query  int  true  "start example"  example(0)
-// @Param   count  query  int  true  "count example"  example(10)
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, id by default"  Enums(id, -id, created_at, -created_at, next_payment, -next_payment, amount, -amount)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
 // @Param   payer_id  query  int  false  "payer_id example"  example(1)
+// @Param   product_id  query  int  false  "product_id example"  example(1)
+// @Param   currency  query  string  false  "currency example"  example(USD)
+// @Param   finished  query  bool  false  "finished example"  example(false)
 //
 //	@Produce		json
-//	@Success		200	{array}		model.OrderResponse
+//	@Success		200	{object}	pagination.Page{data=[]model.OrderResponse}
 //	@Router			/order/orders [get]
 func (o *Controller) Orders(ctx *gin.Context) {
-	start, err := strconv.Atoi(ctx.Query("start"))
+	page, err := pagination.Parse(ctx, model.Order{}, "id", pagination.Sort{Name: "next_payment", Kind: pagination.Time}, pagination.Sort{Name: "amount", Kind: pagination.Number})
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: start", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
 		return
 	}
-	count, err := strconv.Atoi(ctx.Query("count"))
-	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: count", err)
-		return
+	payer_id, _ := strconv.Atoi(ctx.Query("payer_id"))
+	product_id, _ := strconv.Atoi(ctx.Query("product_id"))
+	var finished *bool
+	if ctx.Query("finished") != "" {
+		value, err := strconv.ParseBool(ctx.Query("finished"))
+		if err != nil {
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: finished", err)
+			return
+		}
+		finished = &value
 	}
-	payer_id := 0
-	payer_id, _ = strconv.Atoi(ctx.Query("payer_id"))
 
-	if count > 30 || count < 1 {
-		count = 30
-	}
-	if start < 0 {
-		start = 0
-	}
 	var order = model.Order{}
-	orders, _, err := order.QGetOrders(database.DB, start, count, payer_id)
+	orders, _, err := order.QGetOrders(db(ctx), page, payer_id, product_id, ctx.Query("currency"), finished)
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Query returned 0 records", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Query returned 0 records", err)
 		return
 	}
 
-	ctx.JSON(200, orders)
+	ctx.JSON(200, page.Page(&orders))
 }
 
 // GetOrder godoc
@@ -104,22 +159,22 @@ func (o *Controller) Orders(ctx *gin.Context) {
 //
 //	@Produce		json
 //	@Success		200	{object}	model.OrderResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
 //	@Router			/order/{id} [get]
 func (o *Controller) GetOrder(ctx *gin.Context) {
 	// var out model.OrderResponse
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
 		return
 	}
 
 	order := model.Order{ID: id}
-	_, err = order.QGetOrder(database.DB)
+	_, err = order.QGetOrder(db(ctx))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Order not found", err)
+		httputil.Problem(ctx, http.StatusNotFound, "Order not found", err)
 		return
 	}
 
@@ -1,11 +1,12 @@
 package controller
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
-	"systempayment/database"
 	"systempayment/httputil"
 	"systempayment/model"
+	"systempayment/pagination"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,18 +20,18 @@ import (
 //	 @Param   payer     body     model.Payer     true  "Payer example"     example(model.Payer)
 //		@Produce		json
 //		@Success		200	{object}	model.PayerResponse
-//		@Failure		400	{object}	httputil.HTTPError400
-//		@Failure		500	{object}	httputil.HTTPError500
+//		@Failure		400	{object}	httputil.ProblemDetails
+//		@Failure		500	{object}	httputil.ProblemDetails
 //		@Router			/payer/new [post]
 func (c *Controller) NewPayer(ctx *gin.Context) {
 	var payer model.Payer
 	if err := ctx.BindJSON(&payer); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
 		return
 	}
 
-	if _, err := payer.QCreatePayer(database.DB); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Body validation failed", err)
+	if _, err := payer.QCreatePayer(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Body validation failed", err)
 		return
 	}
 
@@ -40,46 +41,46 @@ func (c *Controller) NewPayer(ctx *gin.Context) {
 // Payers godoc
 //
 //	@Summary		Select all Payers
-//	@Description	Select all Payers
+//	@Description	Select all Payers, or look them up by email or document (case and surrounding spaces ignored)
 //	@Tags			Payer
 //
-// @Param   start  query  int  true  "start example"  example(0)
-// @Param   count  query  int  true  "count example"  example(10)
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   email  query  string  false  "email example"  example(jhondoe@mail.com)
+// @Param   document  query  string  false  "document example"  example(23415162)
 //
 //	@Produce		json
-//	@Success		200	{array}		model.PayerResponse
+//	@Success		200	{object}	pagination.Page{data=[]model.PayerResponse}
 //	@Router			/payer/payers [get]
 func (c *Controller) Payers(ctx *gin.Context) {
-	start, err := strconv.Atoi(ctx.Query("start"))
+	page, err := pagination.Parse(ctx, model.Payer{}, "id")
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: start", err)
-		return
-	}
-	count, err := strconv.Atoi(ctx.Query("count"))
-	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: count", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
 		return
 	}
 
-	if count > 30 || count < 1 {
-		count = 30
+	var payer = model.Payer{}
+	if email, ok := ctx.GetQuery("email"); ok {
+		payer.Email = &email
 	}
-	if start < 0 {
-		start = 0
+	if document, ok := ctx.GetQuery("document"); ok {
+		payer.Document = &document
 	}
-	var payer = model.Payer{}
-	payers, code, err := payer.QGetPayers(database.DB, start, count)
+	payers, code, err := payer.QGetPayers(db(ctx), page)
 	if err != nil {
 		switch code {
 		case 400:
-			httputil.Error400(ctx, http.StatusBadRequest, "Query returned 0 records", err)
+			httputil.Problem(ctx, http.StatusBadRequest, "Query returned 0 records", err)
 		default:
-			httputil.Error500(ctx, http.StatusInternalServerError, "Error fetching Payers", err)
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching Payers", err)
 		}
 		return
 	}
 
-	ctx.JSON(200, payers)
+	ctx.JSON(200, page.Page(&payers))
 }
 
 // GetPayer godoc
@@ -92,21 +93,22 @@ func (c *Controller) Payers(ctx *gin.Context) {
 //
 //	@Produce		json
 //	@Success		200	{object}	model.PayerResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
 //	@Router			/payer/{id} [get]
 func (c *Controller) GetPayer(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
 		return
 	}
 
 	payer := model.Payer{ID: id}
 	// var payer_out model.PayerResponse
-	_, err = payer.QGetPayer(database.DB)
+	_, err = payer.QGetPayer(db(ctx))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Payer not found", err)
+		httputil.Problem(ctx, http.StatusNotFound, "Payer not found", err)
 		return
 	}
 
@@ -125,24 +127,26 @@ func (c *Controller) GetPayer(ctx *gin.Context) {
 //
 //	@Produce		json
 //	@Success		200	{object}	model.PayerResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
 //	@Router			/payer/update/{id} [put]
 func (c *Controller) UpdatePayer(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
 		return
 	}
 
 	payer := model.Payer{ID: id}
 	if err := ctx.BindJSON(&payer); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
 		return
 	}
 
-	if _, err := payer.QUpdatePayer(database.DB); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload or query params", err)
+	if _, err := payer.QUpdatePayer(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload or query params", err)
 		return
 	}
 
@@ -161,28 +165,29 @@ func (c *Controller) UpdatePayer(ctx *gin.Context) {
 //
 //	@Produce		json
 //	@Success		200	{object}	model.PayerResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
 //	@Router			/payer/primary-card [put]
 func (c *Controller) PrimaryCard(ctx *gin.Context) {
 	payer_id, err := strconv.Atoi(ctx.Query("payer_id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: payer_id", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: payer_id", err)
 		return
 	}
 	card_id, err := strconv.Atoi(ctx.Query("card_id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: card_id", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: card_id", err)
 		return
 	}
 
 	payer := model.Payer{ID: payer_id}
-	if _, err := payer.QGetPayer(database.DB); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Payer not found", err)
+	if _, err := payer.QGetPayer(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusNotFound, "Payer not found", err)
 	}
 
-	if _, err := payer.QPrimaryCard(database.DB, card_id); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload or query params", err)
+	if _, err := payer.QPrimaryCard(db(ctx), card_id); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload or query params", err)
 		return
 	}
 
@@ -203,21 +208,122 @@ func (c *Controller) PrimaryCard(ctx *gin.Context) {
 func (c *Controller) PayerCards(ctx *gin.Context) {
 	payer_id, err := strconv.Atoi(ctx.Query("payer_id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: start", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: start", err)
 		return
 	}
 
 	card := model.Card{PayerID: payer_id}
-	cards, code, err := card.QGetCards(database.DB, payer_id)
+	cards, code, err := card.QGetCards(db(ctx), payer_id)
 	if err != nil {
 		switch code {
 		case 400:
-			httputil.Error400(ctx, http.StatusBadRequest, "Query returned 0 records", err)
+			httputil.Problem(ctx, http.StatusBadRequest, "Query returned 0 records", err)
 		default:
-			httputil.Error500(ctx, http.StatusInternalServerError, "Error fetching cards", err)
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching cards", err)
 		}
 		return
 	}
 
 	ctx.JSON(200, cards)
 }
+
+// ExportPayer godoc
+//
+//	@Summary		Export Payer data
+//	@Description	Returns all data tied to a payer (address, cards, orders, payments) as a JSON archive
+//	@Tags			Payer
+//
+// @Param   id  path  int  true  "Payer ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.PayerExport
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/payer/{id}/export [get]
+func (c *Controller) ExportPayer(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	payer := model.Payer{ID: id}
+	export, code, err := payer.QExportPayer(db(ctx))
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Payer not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error exporting Payer", err)
+		}
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=payer-%d-export.json", id))
+	ctx.JSON(200, export)
+}
+
+// ErasePayer godoc
+//
+//	@Summary		Erase Payer personal data
+//	@Description	Pseudonymizes personal fields on Payer and Address, removes saved cards, cancels subscriptions, and erases the payer's notifications, webhook event data and payment attempt IPs. Orders and payments are kept.
+//	@Tags			Payer
+//
+// @Param   id  path  int  true  "Payer ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.PayerResponse
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/payer/{id}/erase [post]
+func (c *Controller) ErasePayer(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	payer := model.Payer{ID: id}
+	code, err := payer.QErasePayer(db(ctx))
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Payer not found", err)
+		case 409:
+			httputil.Problem(ctx, http.StatusConflict, "", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error erasing Payer", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, payer)
+}
+
+// ReencryptPayers godoc
+//
+//	@Summary		Re-encrypt Payers
+//	@Description	Re-encrypts payer and address personal data with the active key (run after key rotation)
+//	@Tags			Payer
+//
+//	@Produce		json
+//	@Success		200	{object}	controller.Message
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/payer/reencrypt [post]
+func (c *Controller) ReencryptPayers(ctx *gin.Context) {
+	total, _, err := model.QReencryptPayers(db(ctx))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error re-encrypting Payers", err)
+		return
+	}
+
+	ctx.JSON(200, Message{Message: fmt.Sprintf("%d payers re-encrypted", total)})
+}
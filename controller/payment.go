@@ -1,12 +1,17 @@
 package controller
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
-	"systempayment/database"
-	"systempayment/dlocal"
+	"systempayment/billing"
+	"systempayment/export"
 	"systempayment/httputil"
 	"systempayment/model"
+	"systempayment/pagination"
+	"systempayment/receipt"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +19,7 @@ import (
 // NewPayment godoc
 //
 //	@Summary		New Payment
-//	@Description	Creates a new payment with dlocal
+//	@Description	Creates a new payment with dlocal, checked by the risk rules first: denied with 402, or held for review with 202 and the attempt
 //	@Tags			Payment
 //	@Accept			json
 //
@@ -23,72 +28,124 @@ import (
 //
 //	@Produce		json
 //	@Success		200	{object}	model.PaymentResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Success		202	{object}	model.PaymentAttempt
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		402	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Failure		502	{object}	httputil.ProblemDetails
+//	@Failure		504	{object}	httputil.ProblemDetails
 //	@Router			/payment/new [post]
 func (c *Controller) NewPayment(ctx *gin.Context) {
 	order_id, err := strconv.Atoi(ctx.Query("order_id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: order_id", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: order_id", err)
 		return
 	}
 	var order = model.Order{ID: order_id}
-	if _, err := order.GetOrderForPayment(database.DB); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Order not found or already finished", err)
+	if _, err := order.GetOrderForPayment(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Order not found or already finished", err)
 		return
 	}
 	auto, _ := strconv.ParseBool(ctx.Query("auto"))
 	if !order.Auto && auto {
+		disputed, err := model.QHasOpenChargeback(db(ctx), order.PayerID)
+		if err != nil {
+			httputil.Problem(ctx, http.StatusInternalServerError, "An error occurred while checking chargebacks", err)
+			return
+		}
+		if disputed {
+			httputil.Problem(ctx, http.StatusBadRequest, "Automatic charging is suspended", errors.New("payer has an open chargeback"))
+			return
+		}
 		order.Auto = auto
 	}
 
 	var payer = model.Payer{ID: order.PayerID}
-	if code, err := payer.QGetPayer(database.DB); err != nil {
+	if code, err := payer.QGetPayer(db(ctx)); err != nil {
 		switch code {
 		case 400:
-			httputil.Error400(ctx, http.StatusBadRequest, "Payer not found", err)
+			httputil.Problem(ctx, http.StatusNotFound, "Payer not found", err)
 		default:
-			httputil.Error500(ctx, http.StatusInternalServerError, "An error occurred while fetching the payer", err)
+			httputil.Problem(ctx, http.StatusInternalServerError, "An error occurred while fetching the payer", err)
 		}
 		return
 	}
 
 	var card = model.Card{ID: payer.CardID}
-	if _, err := card.QGetCard(database.DB); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Card not found", err)
+	if _, err := card.QGetCard(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusNotFound, "Card not found", err)
 		return
 	}
 
-	code, response, err := dlocal.MakePayment(order, payer, card)
-	if err != nil {
-		ctx.JSON(code, err)
-	}
-	if code != 200 {
-		ctx.JSON(code, response)
-	}
-
-	code, err = order.PaymentSuccessful(database.DB)
+	payment, code, err := billing.ScreenAndCharge(db(ctx), &order, payer, card, ctx.ClientIP())
 	if err != nil {
-		switch code {
-		case 400:
-			httputil.Error400(ctx, http.StatusBadRequest, "Order validation failed", err)
+		var dlocalErr *billing.DlocalError
+		var held *billing.HeldError
+		switch {
+		case errors.As(err, &held):
+			ctx.JSON(http.StatusAccepted, held.Attempt)
+		case errors.As(err, &dlocalErr):
+			httputil.Problem(ctx, http.StatusBadGateway, "dlocal did not approve the payment", err)
+		case code == http.StatusPaymentRequired || code == http.StatusConflict:
+			// denied by the risk rules or waiting for review
+			httputil.Problem(ctx, code, "", err)
+		case code == 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Payment validation failed", err)
+		case code == 408:
+			httputil.Problem(ctx, http.StatusGatewayTimeout, "dlocal did not respond", err)
 		default:
-			httputil.Error500(ctx, http.StatusInternalServerError, "Could not update Order", err)
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not complete payment", err)
 		}
 		return
 	}
 
-	var payment = model.Payment{
-		OrderID: order.ID,
-		CardID:  card.ID,
-	}
-	_, err = payment.SavePaymentFromResponse(database.DB, response)
+	ctx.JSON(200, payment)
+}
+
+// ExportPayments godoc
+//
+//	@Summary		Export Payments
+//	@Description	Streams the payments made between from and to (both included) as CSV or XLSX
+//	@Tags			Payment
+//
+// @Param   from  query  string  true  "From (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  true  "To (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   format  query  string  false  "format"  Enums(csv, xlsx)
+// @Param   currency  query  string  false  "currency example"  example(USD)
+// @Param   country  query  string  false  "country example"  example(UY)
+// @Param   status  query  string  false  "status"  Enums(paid, partially_refunded, refunded, charged_back)
+// @Param   product_id  query  int  false  "product_id example"  example(1)
+//
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Success		200	{file}		binary
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/payment/export [get]
+func (c *Controller) ExportPayments(ctx *gin.Context) {
+	filter, format, err := exportParams(ctx, model.PaymentPaid, model.PaymentPartiallyRefunded,
+		model.PaymentRefunded, model.PaymentChargedBack)
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Payment validation failed", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid export parameters", err)
 		return
 	}
 
-	ctx.JSON(200, payment)
+	streamExport(ctx, "payments", filter, format, func(w export.Writer) error {
+		if err := w.Write("id", "created_at", "order_id", "order_number", "installment", "product_id",
+			"amount", "currency", "country", "net_amount", "tax_amount", "fx_rate", "status",
+			"refunded_amount", "dlocal_id", "settled_at"); err != nil {
+			return err
+		}
+		return model.QExportPayments(db(ctx), filter, func(p model.PaymentExport) error {
+			return w.Write(p.ID, p.CreatedAt, p.OrderID, p.OrderNumber, p.Installment, p.ProductID,
+				p.Amount, p.Currency, p.Country, p.NetAmount, p.TaxAmount, p.FxRate, p.Status,
+				p.RefundedAmount, p.DlocalID, p.SettledAt)
+		})
+	})
 }
 
 // GetPayments godoc
@@ -97,44 +154,186 @@ func (c *Controller) NewPayment(ctx *gin.Context) {
 //	@Description	Select all Payments
 //	@Tags			Payment
 //
-// @Param   start  query  int  true  "start example"  example(0)
-// @Param   count  query  int  true  "count example"  example(10)
-// @Param   orderId  query  int  false  "orderId example"  example(1)
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, -created_at by default"  Enums(id, -id, created_at, -created_at, amount, -amount)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   order_id  query  int  false  "order_id example"  example(1)
+// @Param   status  query  string  false  "status"  Enums(paid, partially_refunded, refunded, charged_back)
+// @Param   currency  query  string  false  "currency example"  example(USD)
 //
 //	@Produce		json
-//	@Success		200	{array}		model.PaymentResponse
+//	@Success		200	{object}	pagination.Page{data=[]model.PaymentResponse}
 //	@Router			/payment/payments [get]
 func (c *Controller) GetPayments(ctx *gin.Context) {
-	start, err := strconv.Atoi(ctx.Query("start"))
+	page, err := pagination.Parse(ctx, model.Payment{}, "-created_at", pagination.Sort{Name: "amount", Kind: pagination.Number})
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: start", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
 		return
 	}
-	count, err := strconv.Atoi(ctx.Query("count"))
+	// orderId is the parameter's previous name
+	order_id, _ := strconv.Atoi(ctx.DefaultQuery("order_id", ctx.Query("orderId")))
+
+	var payment = model.Payment{}
+	payments, code, err := payment.QGetAllPayments(db(ctx), page, order_id, ctx.Query("status"), ctx.Query("currency"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: count", err)
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Query returned 0 records", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching Payments", err)
+		}
 		return
 	}
-	order_id := 0
-	order_id, _ = strconv.Atoi(ctx.Query("orderId"))
 
-	if count > 30 || count < 1 {
-		count = 30
+	ctx.JSON(200, page.Page(&payments))
+}
+
+// PaymentReceipt godoc
+//
+//	@Summary		Payment receipt
+//	@Description	Receipt of a payment as HTML (default) or PDF. Payments made before invoices existed get their invoice number now
+//	@Tags			Payment
+//
+// @Param   id  path  int  true  "Payment ID"  example(1)
+// @Param   format  query  string  false  "html or pdf"  Enums(html, pdf)
+//
+//	@Produce		html
+//	@Produce		application/pdf
+//	@Success		200
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/payment/{id}/receipt [get]
+func (c *Controller) PaymentReceipt(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
 	}
-	if start < 0 {
-		start = 0
+	format := ctx.DefaultQuery("format", "html")
+	if format != "html" && format != "pdf" {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: format", errors.New("format must be html or pdf"))
+		return
 	}
-	var payment = model.Payment{}
-	payments, code, err := payment.QGetAllPayments(database.DB, start, count, order_id)
+
+	r, code, err := model.QGetReceipt(db(ctx), id)
 	if err != nil {
 		switch code {
 		case 400:
-			httputil.Error400(ctx, http.StatusBadRequest, "Query returned 0 records", err)
+			httputil.Problem(ctx, http.StatusNotFound, "Payment not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not load receipt", err)
+		}
+		return
+	}
+
+	// render first, a failure halfway can't be sent as an error anymore
+	var buf bytes.Buffer
+	contentType := "text/html; charset=utf-8"
+	if format == "pdf" {
+		contentType = "application/pdf"
+		err = receipt.PDF(&buf, r)
+	} else {
+		err = receipt.HTML(&buf, r)
+	}
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Could not render receipt", err)
+		return
+	}
+
+	if format == "pdf" {
+		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=receipt-%s.pdf", r.Invoice.Code()))
+	}
+	ctx.Data(200, contentType, buf.Bytes())
+}
+
+// RefundPayment godoc
+//
+//	@Summary		Refund Payment
+//	@Description	Refunds an amount of the payment with dlocal, everything not refunded yet when amount is 0 or missing. Refunds dlocal hasn't made yet answer 202 as pending
+//	@Tags			Payment
+//	@Accept			json
+//
+// @Param   id  path  int  true  "Payment ID"  example(1)
+// @Param   refund     body     model.RefundRequest     false  "Refund example"     example(model.RefundRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Refund
+//	@Success		202	{object}	model.Refund
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		402	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Failure		502	{object}	httputil.ProblemDetails
+//	@Failure		504	{object}	httputil.ProblemDetails
+//	@Router			/payment/{id}/refund [post]
+func (c *Controller) RefundPayment(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	var request model.RefundRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.BindJSON(&request); err != nil {
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+			return
+		}
+	}
+
+	refund, code, err := billing.RefundPayment(db(ctx), id, request.Amount, request.Reason)
+	if err != nil {
+		var dlocalErr *billing.DlocalError
+		switch {
+		case errors.As(err, &dlocalErr):
+			httputil.Problem(ctx, http.StatusBadGateway, "dlocal did not approve the refund", err)
+		case code == 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Payment can't be refunded", err)
+		case code == 408:
+			httputil.Problem(ctx, http.StatusGatewayTimeout, "dlocal did not respond", err)
 		default:
-			httputil.Error500(ctx, http.StatusInternalServerError, "Error fetching Payments", err)
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not complete refund", err)
 		}
 		return
 	}
 
-	ctx.JSON(200, payments)
+	if refund.Status == model.RefundPending {
+		ctx.JSON(http.StatusAccepted, refund)
+		return
+	}
+	ctx.JSON(200, refund)
+}
+
+// PaymentRefunds godoc
+//
+//	@Summary		Payment refunds
+//	@Description	Refunds of a payment
+//	@Tags			Payment
+//
+// @Param   id  path  int  true  "Payment ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{array}		model.Refund
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/payment/{id}/refunds [get]
+func (c *Controller) PaymentRefunds(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	refund := model.Refund{PaymentID: id}
+	refunds, _, err := refund.QGetRefunds(db(ctx))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching refunds", err)
+		return
+	}
+
+	ctx.JSON(200, refunds)
 }
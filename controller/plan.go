@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewPlan godoc
+//
+//	@Summary		Insert Plan
+//	@Description	save a subscription Plan for a Product. Charged every interval_count interval (day, week, month, year).
+//	@Tags			Plan
+//	@Accept			json
+//
+// @Param   plan     body     model.PlanRequest     true  "Plan example"     example(model.PlanRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Plan
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Router			/plan/new [post]
+func (c *Controller) NewPlan(ctx *gin.Context) {
+	var plan model.Plan
+	if err := ctx.BindJSON(&plan); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	if _, err := plan.QCreatePlan(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Body validation failed", err)
+		return
+	}
+
+	ctx.JSON(200, plan)
+}
+
+// Plans godoc
+//
+//	@Summary		Select all Plans
+//	@Description	Select all Plans
+//	@Tags			Plan
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   active  query  bool  false  "active example"  example(true)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.Plan}
+//	@Router			/plan/plans [get]
+func (c *Controller) Plans(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.Plan{}, "id")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	active, _ := strconv.ParseBool(ctx.Query("active"))
+
+	var plan = model.Plan{}
+	plans, _, err := plan.QGetPlans(db(ctx), page, active)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching Plans", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&plans))
+}
+
+// GetPlan godoc
+//
+//	@Summary		Select Plan
+//	@Description	Get one Plan from ID
+//	@Tags			Plan
+//
+// @Param   id  path  int  true  "Plan ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Plan
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Router			/plan/{id} [get]
+func (c *Controller) GetPlan(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	plan := model.Plan{ID: id}
+	if _, err := plan.QGetPlan(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusNotFound, "Plan not found", err)
+		return
+	}
+
+	ctx.JSON(200, plan)
+}
+
+// DeactivatePlan godoc
+//
+//	@Summary		Deactivates Plan
+//	@Description	No new subscriptions to the Plan, existing ones keep renewing
+//	@Tags			Plan
+//
+// @Param   id  path  int  true  "Plan ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Plan
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/plan/{id}/deactivate [put]
+func (c *Controller) DeactivatePlan(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	plan := model.Plan{ID: id}
+	if code, err := plan.QDeactivatePlan(db(ctx)); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Plan not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not update Plan", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, plan)
+}
@@ -3,9 +3,9 @@ package controller
 import (
 	"net/http"
 	"strconv"
-	"systempayment/database"
 	"systempayment/httputil"
 	"systempayment/model"
+	"systempayment/pagination"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,18 +20,18 @@ import (
 //		@in body
 //		@Produce		json
 //		@Success		200	{object}	model.ProductResponse
-//		@Failure		400	{object}	httputil.HTTPError400
-//		@Failure		500	{object}	httputil.HTTPError500
+//		@Failure		400	{object}	httputil.ProblemDetails
+//		@Failure		500	{object}	httputil.ProblemDetails
 //		@Router			/product/new [post]
 func (c *Controller) NewProduct(ctx *gin.Context) {
 	var product model.Product
 	if err := ctx.BindJSON(&product); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
 		return
 	}
 
-	if _, err := product.QCreateProduct(database.DB); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Body validation failed", err)
+	if _, err := product.QCreateProduct(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Body validation failed", err)
 		return
 	}
 
@@ -44,38 +44,32 @@ func (c *Controller) NewProduct(ctx *gin.Context) {
 //	@Description	Select all Products
 //	@Tags			Product
 //
-// @Param   start  query  int  true  "start example"  example(0)
-// @Param   count  query  int  true  "count example"  example(10)
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   status  query  string  false  "status example"  example(active)
 //
 //	@Produce		json
-//	@Success		200	{array}		model.ProductResponse
+//	@Success		200	{object}	pagination.Page{data=[]model.ProductResponse}
 //	@Router			/product/products [get]
 func (c *Controller) Products(ctx *gin.Context) {
-	start, err := strconv.Atoi(ctx.Query("start"))
+	page, err := pagination.Parse(ctx, model.Product{}, "id")
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: start", err)
-		return
-	}
-	count, err := strconv.Atoi(ctx.Query("count"))
-	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: count", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
 		return
 	}
 
-	if count > 30 || count < 1 {
-		count = 30
-	}
-	if start < 0 {
-		start = 0
-	}
 	var product = model.Product{}
-	products, _, err := product.QGetProducts(database.DB, start, count)
+	status := ctx.Query("status")
+	products, _, err := product.QGetProducts(db(ctx), page, status)
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Query returned 0 records", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Query returned 0 records", err)
 		return
 	}
 
-	ctx.JSON(200, products)
+	ctx.JSON(200, page.Page(&products))
 }
 
 // GetProduct godoc
@@ -88,19 +82,20 @@ func (c *Controller) Products(ctx *gin.Context) {
 //
 //	@Produce		json
 //	@Success		200	{object}	model.ProductResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
 //	@Router			/product/{id} [get]
 func (c *Controller) GetProduct(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
 		return
 	}
 
 	product := model.Product{ID: id}
-	if _, err := product.QGetProduct(database.DB); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Product not found", err)
+	if _, err := product.QGetProduct(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusNotFound, "Product not found", err)
 		return
 	}
 
@@ -120,24 +115,190 @@ func (c *Controller) GetProduct(ctx *gin.Context) {
 //
 //	@Produce		json
 //	@Success		200	{object}	model.ProductResponse
-//	@Failure		400	{object}	httputil.HTTPError400
-//	@Failure		500	{object}	httputil.HTTPError500
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
 //	@Router			/product/update/{id} [put]
 func (c *Controller) UpdateProduct(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
 		return
 	}
 
 	product := model.Product{ID: id}
 	if err := ctx.BindJSON(&product); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	if _, err := product.QUpdateProduct(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload or query params", err)
+		return
+	}
+
+	ctx.JSON(200, product)
+}
+
+// ActivateProduct godoc
+//
+//	@Summary		Activates Product
+//	@Description	Moves a draft or archived Product to active, only active products can be ordered
+//	@Tags			Product
+//
+// @Param   id  path  int  true  "Product ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.ProductResponse
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/product/{id}/activate [put]
+func (c *Controller) ActivateProduct(ctx *gin.Context) {
+	c.setProductStatus(ctx, model.ProductActive)
+}
+
+// ArchiveProduct godoc
+//
+//	@Summary		Archives Product
+//	@Description	Archived products are kept for existing orders but can't be ordered
+//	@Tags			Product
+//
+// @Param   id  path  int  true  "Product ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.ProductResponse
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/product/{id}/archive [put]
+func (c *Controller) ArchiveProduct(ctx *gin.Context) {
+	c.setProductStatus(ctx, model.ProductArchived)
+}
+
+func (c *Controller) setProductStatus(ctx *gin.Context, status string) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	product := model.Product{ID: id}
+	code, err := product.QSetStatus(db(ctx), status)
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Product not found or invalid status change", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not update Product", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, product)
+}
+
+// ProductPrices godoc
+//
+//	@Summary		Product price history
+//	@Description	All prices of a Product, newest first. Current prices have no effective_to.
+//	@Tags			Product
+//
+// @Param   id  path  int  true  "Product ID"  example(1)
+// @Param   currency  query  string  false  "currency example"  example(USD)
+//
+//	@Produce		json
+//	@Success		200	{array}		model.ProductPrice
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/product/{id}/prices [get]
+func (c *Controller) ProductPrices(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	if exists, err := model.ProductExists(db(ctx), id); !exists {
+		httputil.Problem(ctx, http.StatusNotFound, "Product not found", err)
 		return
 	}
 
-	if _, err := product.QUpdateProduct(database.DB); err != nil {
-		httputil.Error400(ctx, http.StatusBadRequest, "Invalid request payload or query params", err)
+	price := model.ProductPrice{ProductID: id}
+	if currency := ctx.Query("currency"); currency != "" {
+		price.Currency = &currency
+	}
+	prices, _, err := price.QGetPriceHistory(db(ctx))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching prices", err)
+		return
+	}
+
+	ctx.JSON(200, prices)
+}
+
+// SetProductPrice godoc
+//
+//	@Summary		Sets Product price in a currency
+//	@Description	Adds or replaces the Product's price in the given currency, the previous one is kept in the history
+//	@Tags			Product
+//	@Accept			json
+//
+// @Param   id  path  int  true  "Product ID"  example(1)
+// @Param   price     body     model.PriceRequest     true  "Price example"     example(model.PriceRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.ProductResponse
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Router			/product/{id}/prices [put]
+func (c *Controller) SetProductPrice(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	var price model.ProductPrice
+	if err := ctx.BindJSON(&price); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	product := model.Product{ID: id}
+	if _, err := product.QSetPrice(db(ctx), &price); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Product not found or invalid price", err)
+		return
+	}
+
+	ctx.JSON(200, product)
+}
+
+// RemoveProductPrice godoc
+//
+//	@Summary		Removes Product price in a currency
+//	@Description	The Product can no longer be ordered in that currency. The default currency can't be removed.
+//	@Tags			Product
+//
+// @Param   id  path  int  true  "Product ID"  example(1)
+// @Param   currency  path  string  true  "Currency"  example(UYU)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.ProductResponse
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Router			/product/{id}/prices/{currency} [delete]
+func (c *Controller) RemoveProductPrice(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	product := model.Product{ID: id}
+	if _, err := product.QRemovePrice(db(ctx), ctx.Param("currency")); err != nil {
+		httputil.Problem(ctx, http.StatusNotFound, "Product or price not found", err)
 		return
 	}
 
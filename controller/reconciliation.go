@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+	"systempayment/reconciliation"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportSettlementReport godoc
+//
+//	@Summary		Import settlement report
+//	@Description	Reconciles a dlocal settlement CSV against the recorded payments. The period defaults to the dates in the file
+//	@Tags			Reconciliation
+//	@Accept			multipart/form-data
+//
+// @Param   file  formData  file  true  "Settlement CSV file"
+// @Param   from  query  string  false  "Period start (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Period end (YYYY-MM-DD)"  example(2023-02-28)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.SettlementReport
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reconciliation/reports [post]
+func (c *Controller) ImportSettlementReport(ctx *gin.Context) {
+	var from, to *time.Time
+	if value := ctx.Query("from"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: from", err)
+			return
+		}
+		from = &date
+	}
+	if value := ctx.Query("to"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: to", err)
+			return
+		}
+		// whole last day
+		date = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		to = &date
+	}
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: file", err)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Could not read file", err)
+		return
+	}
+	defer file.Close()
+
+	report, code, err := reconciliation.Import(db(ctx), header.Filename, file, from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, reconciliation.ErrAlreadyImported):
+			httputil.Problem(ctx, http.StatusBadRequest, "Settlement report already imported", err)
+		case code == 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid settlement report", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not reconcile settlement report", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, report)
+}
+
+// SettlementReports godoc
+//
+//	@Summary		Select settlement reports
+//	@Description	Imported settlement reports, newest first
+//	@Tags			Reconciliation
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, -id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.SettlementReport}
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reconciliation/reports [get]
+func (c *Controller) SettlementReports(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.SettlementReport{}, "-id")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	var report = model.SettlementReport{}
+	reports, _, err := report.QGetSettlementReports(db(ctx), page)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching settlement reports", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&reports))
+}
+
+// GetSettlementReport godoc
+//
+//	@Summary		Get settlement report
+//	@Description	Get settlement report by ID
+//	@Tags			Reconciliation
+//
+// @Param   id  path  int  true  "Report ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.SettlementReport
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reconciliation/reports/{id} [get]
+func (c *Controller) GetSettlementReport(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	var report = model.SettlementReport{ID: id}
+	code, err := report.QGetSettlementReport(db(ctx))
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Settlement report not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching settlement report", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, report)
+}
+
+// SettlementDiscrepancies godoc
+//
+//	@Summary		Select settlement discrepancies
+//	@Description	Discrepancies found by reconciliation, filtered by report, kind and resolved
+//	@Tags			Reconciliation
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   report_id  query  int  false  "report_id example"  example(1)
+// @Param   kind  query  string  false  "kind"  Enums(missing, extra, amount_mismatch)
+// @Param   resolved  query  bool  false  "resolved"
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.SettlementDiscrepancy}
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reconciliation/discrepancies [get]
+func (c *Controller) SettlementDiscrepancies(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.SettlementDiscrepancy{}, "id")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	report_id, _ := strconv.Atoi(ctx.Query("report_id"))
+	var resolved *bool
+	if value := ctx.Query("resolved"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: resolved", err)
+			return
+		}
+		resolved = &b
+	}
+
+	var discrepancy = model.SettlementDiscrepancy{}
+	discrepancies, _, err := discrepancy.QGetDiscrepancies(db(ctx), page, report_id, ctx.Query("kind"), resolved)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching discrepancies", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&discrepancies))
+}
+
+// ResolveDiscrepancy godoc
+//
+//	@Summary		Resolve discrepancy
+//	@Description	Marks a settlement discrepancy as resolved
+//	@Tags			Reconciliation
+//	@Accept			json
+//
+// @Param   id  path  int  true  "Discrepancy ID"  example(1)
+// @Param   resolve     body     model.ResolveRequest     false  "Resolve example"     example(model.ResolveRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.SettlementDiscrepancy
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reconciliation/discrepancies/{id}/resolve [put]
+func (c *Controller) ResolveDiscrepancy(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	var request model.ResolveRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.BindJSON(&request); err != nil {
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+			return
+		}
+	}
+
+	var discrepancy = model.SettlementDiscrepancy{ID: id}
+	code, err := discrepancy.QResolve(db(ctx), request.Note)
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Discrepancy can't be resolved", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not resolve discrepancy", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, discrepancy)
+}
@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"systempayment/httputil"
+	"systempayment/model"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Period and currency of a report request, both dates included.
+// Without from the report starts at defaultFrom before to.
+func reportFilter(ctx *gin.Context, defaultFrom func(to time.Time) time.Time) (model.ReportFilter, error) {
+	var filter model.ReportFilter
+	var err error
+	today := time.Now().Truncate(24 * time.Hour)
+
+	filter.To = today
+	if to := ctx.Query("to"); to != "" {
+		if filter.To, err = time.Parse("2006-01-02", to); err != nil {
+			return filter, errors.New("to must be a YYYY-MM-DD date")
+		}
+	}
+	// whole last day
+	filter.To = filter.To.AddDate(0, 0, 1)
+
+	filter.From = defaultFrom(filter.To)
+	if from := ctx.Query("from"); from != "" {
+		if filter.From, err = time.Parse("2006-01-02", from); err != nil {
+			return filter, errors.New("from must be a YYYY-MM-DD date")
+		}
+	}
+	if !filter.To.After(filter.From) {
+		return filter, errors.New("to is before from")
+	}
+
+	filter.Currency = strings.ToUpper(ctx.Query("currency"))
+	return filter, nil
+}
+
+// A year before to
+func lastYear(to time.Time) time.Time {
+	return to.AddDate(-1, 0, 0)
+}
+
+// RevenueReport godoc
+//
+//	@Summary		Revenue report
+//	@Description	Payments, refunds and chargebacks by day, week or month and currency. Defaults to the last year by month
+//	@Tags			Reports
+//
+// @Param   from  query  string  false  "From (YYYY-MM-DD)"  example(2023-01-01)
+// @Param   to  query  string  false  "To (YYYY-MM-DD)"  example(2023-12-31)
+// @Param   currency  query  string  false  "currency example"  example(USD)
+// @Param   interval  query  string  false  "interval"  Enums(day, week, month)
+//
+//	@Produce		json
+//	@Success		200	{array}		model.Revenue
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/revenue [get]
+func (c *Controller) RevenueReport(ctx *gin.Context) {
+	filter, err := reportFilter(ctx, lastYear)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid report parameters", err)
+		return
+	}
+
+	report, code, err := model.QRevenueReport(db(ctx), filter, ctx.DefaultQuery("interval", model.ReportMonth))
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: interval", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error computing revenue", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, report)
+}
+
+// MRRReport godoc
+//
+//	@Summary		MRR report
+//	@Description	Current monthly recurring revenue of auto orders and subscriptions, and what they collected each month of the period (defaults to the last year)
+//	@Tags			Reports
+//
+// @Param   from  query  string  false  "From (YYYY-MM-DD)"  example(2023-01-01)
+// @Param   to  query  string  false  "To (YYYY-MM-DD)"  example(2023-12-31)
+// @Param   currency  query  string  false  "currency example"  example(USD)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.MRRReport
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/mrr [get]
+func (c *Controller) MRRReport(ctx *gin.Context) {
+	filter, err := reportFilter(ctx, lastYear)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid report parameters", err)
+		return
+	}
+
+	report, _, err := model.QMRRReport(db(ctx), filter)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error computing MRR", err)
+		return
+	}
+
+	ctx.JSON(200, report)
+}
+
+// OrdersReport godoc
+//
+//	@Summary		Orders report
+//	@Description	Finished, active and abandoned (installment overdue more than abandoned_days, 30 by default) orders created in the period, by currency
+//	@Tags			Reports
+//
+// @Param   from  query  string  false  "From (YYYY-MM-DD)"  example(2023-01-01)
+// @Param   to  query  string  false  "To (YYYY-MM-DD)"  example(2023-12-31)
+// @Param   currency  query  string  false  "currency example"  example(USD)
+// @Param   abandoned_days  query  int  false  "abandoned_days example"  example(30)
+//
+//	@Produce		json
+//	@Success		200	{array}		model.OrdersReport
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/orders [get]
+func (c *Controller) OrdersReport(ctx *gin.Context) {
+	filter, err := reportFilter(ctx, lastYear)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid report parameters", err)
+		return
+	}
+	abandoned_days := model.ReportAbandonedDays
+	if days := ctx.Query("abandoned_days"); days != "" {
+		if abandoned_days, err = strconv.Atoi(days); err != nil || abandoned_days < 1 {
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: abandoned_days", errors.New("abandoned_days must be a positive number"))
+			return
+		}
+	}
+
+	report, _, err := model.QOrdersReport(db(ctx), filter, abandoned_days)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error computing orders report", err)
+		return
+	}
+
+	ctx.JSON(200, report)
+}
+
+// AgingReport godoc
+//
+//	@Summary		Installment aging report
+//	@Description	Unfinished orders with an overdue installment by days late (1-30, 31-60, 61-90, 90+) and currency. The period filters the due date, every overdue installment by default
+//	@Tags			Reports
+//
+// @Param   from  query  string  false  "From (YYYY-MM-DD)"  example(2023-01-01)
+// @Param   to  query  string  false  "To (YYYY-MM-DD)"  example(2023-12-31)
+// @Param   currency  query  string  false  "currency example"  example(USD)
+//
+//	@Produce		json
+//	@Success		200	{array}		model.AgingBucket
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/aging [get]
+func (c *Controller) AgingReport(ctx *gin.Context) {
+	filter, err := reportFilter(ctx, func(time.Time) time.Time { return time.Time{} })
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid report parameters", err)
+		return
+	}
+
+	report, _, err := model.QAgingReport(db(ctx), filter)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error computing aging report", err)
+		return
+	}
+
+	ctx.JSON(200, report)
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"systempayment/billing"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reviews godoc
+//
+//	@Summary		Select reviews
+//	@Description	Payments held by the risk rules, filtered by review status
+//	@Tags			Risk
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   status  query  string  false  "status"  Enums(pending, approved, denied)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.PaymentAttempt}
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/risk/reviews [get]
+func (c *Controller) Reviews(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.PaymentAttempt{}, "id")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	var attempt = model.PaymentAttempt{}
+	reviews, _, err := attempt.QGetReviews(db(ctx), page, ctx.Query("status"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching reviews", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&reviews))
+}
+
+// GetReview godoc
+//
+//	@Summary		Get review
+//	@Description	Get a payment held by the risk rules by ID
+//	@Tags			Risk
+//
+// @Param   id  path  int  true  "Review ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.PaymentAttempt
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/risk/reviews/{id} [get]
+func (c *Controller) GetReview(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	var attempt = model.PaymentAttempt{ID: id}
+	code, err := attempt.QGetPaymentAttempt(db(ctx))
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Review not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching review", err)
+		}
+		return
+	}
+	if attempt.ReviewStatus == "" {
+		httputil.Problem(ctx, http.StatusNotFound, "Review not found", nil)
+		return
+	}
+
+	ctx.JSON(200, attempt)
+}
+
+// ApproveReview godoc
+//
+//	@Summary		Approve review
+//	@Description	Approves a payment held by the risk rules and charges it with dlocal. The order isn't checked by the rules again
+//	@Tags			Risk
+//	@Accept			json
+//
+// @Param   id  path  int  true  "Review ID"  example(1)
+// @Param   review     body     model.ReviewRequest     false  "Review example"     example(model.ReviewRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.PaymentResponse
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		402	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Failure		502	{object}	httputil.ProblemDetails
+//	@Failure		504	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/risk/reviews/{id}/approve [put]
+func (c *Controller) ApproveReview(ctx *gin.Context) {
+	id, request, ok := reviewParams(ctx)
+	if !ok {
+		return
+	}
+
+	payment, code, err := billing.ApproveReview(db(ctx), id, request.Note)
+	if err != nil {
+		var dlocalErr *billing.DlocalError
+		switch {
+		case errors.As(err, &dlocalErr):
+			httputil.Problem(ctx, http.StatusBadGateway, "dlocal did not approve the payment", err)
+		case code == 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Review not found", err)
+		case code == 408:
+			httputil.Problem(ctx, http.StatusGatewayTimeout, "dlocal did not respond", err)
+		case code == http.StatusConflict:
+			httputil.Problem(ctx, code, "", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not complete payment", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, payment)
+}
+
+// DenyReview godoc
+//
+//	@Summary		Deny review
+//	@Description	Denies a payment held by the risk rules, the order won't be charged anymore
+//	@Tags			Risk
+//	@Accept			json
+//
+// @Param   id  path  int  true  "Review ID"  example(1)
+// @Param   review     body     model.ReviewRequest     false  "Review example"     example(model.ReviewRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.PaymentAttempt
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/risk/reviews/{id}/deny [put]
+func (c *Controller) DenyReview(ctx *gin.Context) {
+	id, request, ok := reviewParams(ctx)
+	if !ok {
+		return
+	}
+
+	attempt, code, err := billing.DenyReview(db(ctx), id, request.Note)
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Review not found", err)
+		case http.StatusConflict:
+			httputil.Problem(ctx, code, "", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not deny review", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, attempt)
+}
+
+// Review id and the optional note, answers 400 when they're invalid
+func reviewParams(ctx *gin.Context) (int, model.ReviewRequest, bool) {
+	var request model.ReviewRequest
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return id, request, false
+	}
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.BindJSON(&request); err != nil {
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+			return id, request, false
+		}
+	}
+	return id, request, true
+}
@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"systempayment/billing"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewSubscription godoc
+//
+//	@Summary		Subscribe Payer to a Plan
+//	@Description	Starts the plan's trial, or charges the first period right away with the card (payer's primary card by default). A first payment held by the risk rules answers 202 with the subscription in review
+//	@Tags			Subscription
+//	@Accept			json
+//
+// @Param   payer_id  query  int  true  "payer_id example"  example(1)
+// @Param   subscription     body     model.SubscriptionRequest     true  "Subscription example"     example(model.SubscriptionRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Subscription
+//	@Success		202	{object}	model.Subscription
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		402	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Router			/subscription/new [post]
+func (c *Controller) NewSubscription(ctx *gin.Context) {
+	payer_id, err := strconv.Atoi(ctx.Query("payer_id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: payer_id", err)
+		return
+	}
+	var request model.SubscriptionRequest
+	if err := ctx.BindJSON(&request); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	var subscription = model.Subscription{PayerID: payer_id, PlanID: request.PlanID, CardID: request.CardID}
+	if subscription.CardID == 0 {
+		payer, err := model.PreloadPayer(db(ctx), payer_id)
+		if err != nil {
+			httputil.Problem(ctx, http.StatusNotFound, "Payer not found", err)
+			return
+		}
+		subscription.CardID = payer.CardID
+	}
+
+	if _, err := subscription.QCreateSubscription(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload or query params", err)
+		return
+	}
+
+	status := 200
+	if subscription.Status == model.SubscriptionActive {
+		// no trial, first period is due now
+		var held *billing.HeldError
+		if _, err := billing.RenewSubscription(db(ctx), subscription.ID); errors.As(err, &held) {
+			// renewed, or failed like a declined charge, once the review is decided
+			status = http.StatusAccepted
+		} else if err != nil {
+			if _, qerr := subscription.QGetSubscription(db(ctx)); qerr != nil ||
+				subscription.Status == model.SubscriptionAttention || subscription.Status == model.SubscriptionRenewing {
+				// maybe charged, the subscription needs attention rather than a cancel
+				httputil.Problem(ctx, http.StatusInternalServerError, "First payment not confirmed, subscription needs attention", err)
+				return
+			}
+			_, _ = subscription.QCancel(db(ctx), false)
+			httputil.Problem(ctx, http.StatusPaymentRequired, "First payment failed, subscription canceled", err)
+			return
+		}
+	}
+
+	if code, err := subscription.QGetSubscription(db(ctx)); err != nil {
+		httputil.Problem(ctx, code, "Error fetching Subscription", err)
+		return
+	}
+	ctx.JSON(status, subscription)
+}
+
+// Subscriptions godoc
+//
+//	@Summary		Select all Subscriptions
+//	@Description	Select all Subscriptions
+//	@Tags			Subscription
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, id by default"  Enums(id, -id, created_at, -created_at, current_period_end, -current_period_end)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   payer_id  query  int  false  "payer_id example"  example(1)
+// @Param   status  query  string  false  "status example"  example(active)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.Subscription}
+//	@Router			/subscription/subscriptions [get]
+func (c *Controller) Subscriptions(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.Subscription{}, "id", pagination.Sort{Name: "current_period_end", Kind: pagination.Time})
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	payer_id, _ := strconv.Atoi(ctx.Query("payer_id"))
+
+	var subscription = model.Subscription{PayerID: payer_id, Status: ctx.Query("status")}
+	subscriptions, _, err := subscription.QGetSubscriptions(db(ctx), page)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching Subscriptions", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&subscriptions))
+}
+
+// GetSubscription godoc
+//
+//	@Summary		Select Subscription
+//	@Description	Get one Subscription from ID with its renewal orders
+//	@Tags			Subscription
+//
+// @Param   id  path  int  true  "Subscription ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Subscription
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Router			/subscription/{id} [get]
+func (c *Controller) GetSubscription(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	subscription := model.Subscription{ID: id}
+	if _, err := subscription.QGetSubscription(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusNotFound, "Subscription not found", err)
+		return
+	}
+
+	ctx.JSON(200, subscription)
+}
+
+// PauseSubscription godoc
+//
+//	@Summary		Pauses Subscription
+//	@Description	No renewals are charged while paused
+//	@Tags			Subscription
+//
+// @Param   id  path  int  true  "Subscription ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Subscription
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Router			/subscription/{id}/pause [put]
+func (c *Controller) PauseSubscription(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	subscription := model.Subscription{ID: id}
+	if _, err := subscription.QPause(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Subscription not found or can't be paused", err)
+		return
+	}
+
+	ctx.JSON(200, subscription)
+}
+
+// ResumeSubscription godoc
+//
+//	@Summary		Resumes Subscription
+//	@Description	If the period ended while paused a new one starts now and is charged on the next renewal run
+//	@Tags			Subscription
+//
+// @Param   id  path  int  true  "Subscription ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Subscription
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Router			/subscription/{id}/resume [put]
+func (c *Controller) ResumeSubscription(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	subscription := model.Subscription{ID: id}
+	if _, err := subscription.QResume(db(ctx)); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Subscription not found or not paused", err)
+		return
+	}
+
+	ctx.JSON(200, subscription)
+}
+
+// CancelSubscription godoc
+//
+//	@Summary		Cancels Subscription
+//	@Description	Cancels at the end of the current period, or right away with at_period_end=false
+//	@Tags			Subscription
+//
+// @Param   id  path  int  true  "Subscription ID"  example(1)
+// @Param   at_period_end  query  bool  false  "at_period_end example"  example(true)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.Subscription
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		409	{object}	httputil.ProblemDetails
+//	@Router			/subscription/{id}/cancel [put]
+func (c *Controller) CancelSubscription(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	atPeriodEnd := true
+	if value := ctx.Query("at_period_end"); value != "" {
+		if atPeriodEnd, err = strconv.ParseBool(value); err != nil {
+			httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: at_period_end", err)
+			return
+		}
+	}
+
+	subscription := model.Subscription{ID: id}
+	if _, err := subscription.QCancel(db(ctx), atPeriodEnd); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Subscription not found or already canceled", err)
+		return
+	}
+
+	ctx.JSON(200, subscription)
+}
@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"systempayment/httputil"
+	"systempayment/model"
+	"systempayment/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewWebhookEndpoint godoc
+//
+//	@Summary		Register webhook endpoint
+//	@Description	Events of the merchant's orders are POSTed to the URL, signed with the returned secret (only shown here). Without merchant_id it receives every event, without events every type.
+//	@Tags			Webhook
+//	@Accept			json
+//
+// @Param   endpoint     body     model.WebhookEndpointRequest     true  "Endpoint example"     example(model.WebhookEndpointRequest)
+//
+//	@Produce		json
+//	@Success		200	{object}	model.WebhookEndpoint
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/webhook/endpoints [post]
+func (c *Controller) NewWebhookEndpoint(ctx *gin.Context) {
+	var endpoint model.WebhookEndpoint
+	if err := ctx.BindJSON(&endpoint); err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	if code, err := endpoint.QCreateWebhookEndpoint(db(ctx)); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Body validation failed", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not save endpoint", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, endpoint)
+}
+
+// WebhookEndpoints godoc
+//
+//	@Summary		Select webhook endpoints
+//	@Description	Registered endpoints, secrets aren't returned
+//	@Tags			Webhook
+//
+// @Param   merchant_id  query  int  false  "merchant_id example"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{array}		model.WebhookEndpoint
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/webhook/endpoints [get]
+func (c *Controller) WebhookEndpoints(ctx *gin.Context) {
+	merchant_id, _ := strconv.Atoi(ctx.Query("merchant_id"))
+
+	var endpoint = model.WebhookEndpoint{}
+	endpoints, _, err := endpoint.QGetWebhookEndpoints(db(ctx), merchant_id)
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching endpoints", err)
+		return
+	}
+
+	ctx.JSON(200, endpoints)
+}
+
+// DeleteWebhookEndpoint godoc
+//
+//	@Summary		Delete webhook endpoint
+//	@Description	No more events are sent to the endpoint, its pending deliveries are dropped
+//	@Tags			Webhook
+//
+// @Param   id  path  int  true  "Endpoint ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{object}	controller.Message
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/webhook/endpoints/{id} [delete]
+func (c *Controller) DeleteWebhookEndpoint(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	endpoint := model.WebhookEndpoint{ID: id}
+	if code, err := endpoint.QDeleteWebhookEndpoint(db(ctx)); err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusNotFound, "Endpoint not found", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not delete endpoint", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, Message{Message: "Endpoint deleted"})
+}
+
+// WebhookEvents godoc
+//
+//	@Summary		Select webhook events
+//	@Description	Emitted events, newest first
+//	@Tags			Webhook
+//
+// @Param   limit  query  int  false  "Page size, up to 100"  example(30)
+// @Param   cursor  query  string  false  "next_cursor of the previous page"
+// @Param   sort  query  string  false  "Sort, descending with a leading -, -id by default"  Enums(id, -id, created_at, -created_at)
+// @Param   from  query  string  false  "Created from (YYYY-MM-DD)"  example(2023-02-01)
+// @Param   to  query  string  false  "Created to (YYYY-MM-DD)"  example(2023-02-28)
+// @Param   merchant_id  query  int  false  "merchant_id example"  example(1)
+// @Param   type  query  string  false  "type example"  Enums(payment.succeeded, payment.failed, order.finished, card.saved, refund.created)
+//
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page{data=[]model.WebhookEvent}
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/webhook/events [get]
+func (c *Controller) WebhookEvents(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx, model.WebhookEvent{}, "-id")
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	merchant_id, _ := strconv.Atoi(ctx.Query("merchant_id"))
+
+	var event = model.WebhookEvent{}
+	events, _, err := event.QGetWebhookEvents(db(ctx), page, merchant_id, ctx.Query("type"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching events", err)
+		return
+	}
+
+	ctx.JSON(200, page.Page(&events))
+}
+
+// WebhookDeliveries godoc
+//
+//	@Summary		Webhook delivery log
+//	@Description	Deliveries of an event with every request made
+//	@Tags			Webhook
+//
+// @Param   id  path  int  true  "Event ID"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{array}		model.WebhookDelivery
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/webhook/events/{id}/deliveries [get]
+func (c *Controller) WebhookDeliveries(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+
+	event := model.WebhookEvent{ID: id}
+	deliveries, _, err := event.QGetDeliveries(db(ctx))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusInternalServerError, "Error fetching deliveries", err)
+		return
+	}
+
+	ctx.JSON(200, deliveries)
+}
+
+// ReplayWebhookEvent godoc
+//
+//	@Summary		Replay webhook event
+//	@Description	Sends the event again, to one endpoint or to all the endpoints it was sent to
+//	@Tags			Webhook
+//
+// @Param   id  path  int  true  "Event ID"  example(1)
+// @Param   endpoint_id  query  int  false  "endpoint_id example"  example(1)
+//
+//	@Produce		json
+//	@Success		200	{array}		model.WebhookDelivery
+//	@Failure		400	{object}	httputil.ProblemDetails
+//	@Failure		401	{object}	httputil.ProblemDetails
+//	@Failure		404	{object}	httputil.ProblemDetails
+//	@Failure		422	{object}	httputil.ProblemDetails
+//	@Failure		500	{object}	httputil.ProblemDetails
+//	@Security		ApiKeyAuth
+//	@Router			/admin/webhook/events/{id}/replay [post]
+func (c *Controller) ReplayWebhookEvent(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		httputil.Problem(ctx, http.StatusBadRequest, "Invalid parameter: id", err)
+		return
+	}
+	endpoint_id, _ := strconv.Atoi(ctx.Query("endpoint_id"))
+
+	event := model.WebhookEvent{ID: id}
+	deliveries, code, err := event.QReplay(db(ctx), endpoint_id)
+	if err != nil {
+		switch code {
+		case 400:
+			httputil.Problem(ctx, http.StatusBadRequest, "Event can't be replayed", err)
+		default:
+			httputil.Problem(ctx, http.StatusInternalServerError, "Could not replay event", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, deliveries)
+}
@@ -2,7 +2,11 @@ package database
 
 import (
 	"fmt"
+	"systempayment/config"
+	"systempayment/logging"
+	"systempayment/metrics"
 	"systempayment/model"
+	"systempayment/tracing"
 
 	log "github.com/sirupsen/logrus"
 
@@ -14,27 +18,49 @@ import (
 
 var DB *gorm.DB
 
-func DBInit(user string, password string,
-	dbhost string, dbname string) {
+func DBInit(c config.Database) {
 	connectionString :=
 		fmt.Sprintf("postgres://%v:%v@%v/%v?sslmode=disable",
-			user,
-			password,
-			dbhost,
-			dbname)
-
-	fmt.Println(connectionString)
+			c.User,
+			c.Password,
+			c.Host,
+			c.Name)
 
 	log.Info("Connecting to database...")
 
 	var err error
-	DB, err = gorm.Open(postgres.Open(connectionString), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(connectionString), &gorm.Config{Logger: logging.GormLogger{}})
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err = DB.Use(metrics.GormPlugin{}); err != nil {
+		log.Fatal(err)
+	}
+	if err = DB.Use(tracing.GormPlugin{}); err != nil {
+		log.Fatal(err)
+	}
+
+	DB.AutoMigrate(&model.Product{}, &model.ProductPrice{}, &model.Payer{}, &model.Address{},
+		&model.Order{}, &model.Card{}, &model.Payment{}, &model.ExchangeRate{},
+		&model.Plan{}, &model.Subscription{}, &model.Coupon{}, &model.CouponRedemption{},
+		&model.Merchant{}, &model.Invoice{}, &model.Notification{},
+		&model.Refund{}, &model.WebhookEndpoint{}, &model.WebhookEvent{}, &model.WebhookDelivery{},
+		&model.WebhookAttempt{}, &model.LedgerTransaction{}, &model.LedgerEntry{},
+		&model.SettlementReport{}, &model.SettlementDiscrepancy{}, &model.Chargeback{},
+		&model.ChargebackEvidence{}, &model.RateLimit{}, &model.PaymentAttempt{})
 
-	DB.AutoMigrate(&model.Product{}, &model.Payer{}, &model.Address{},
-		&model.Order{}, &model.Card{}, &model.Payment{})
+	if err = model.QBackfillProductPrices(DB); err != nil {
+		log.Fatal(err)
+	}
+	if err = model.QBackfillMerchants(DB); err != nil {
+		log.Fatal(err)
+	}
+	if err = model.QProtectLedger(DB); err != nil {
+		log.Fatal(err)
+	}
+	if err = model.QBackfillLedger(DB); err != nil {
+		log.Fatal(err)
+	}
 
 	log.Info("Database connected")
 }
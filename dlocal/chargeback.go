@@ -0,0 +1,23 @@
+package dlocal
+
+// Chargeback status
+const (
+	ChargebackPending   = "PENDING"
+	ChargebackCompleted = "COMPLETED"
+	ChargebackReversed  = "REVERSED"
+	ChargebackCancelled = "CANCELLED"
+)
+
+// Chargeback notification body
+type ChargebackNotification struct {
+	ID             string  `json:"id" example:"CHAR42-2023"`
+	PaymentID      string  `json:"payment_id" example:"D-4-cf2d3e7a"`
+	Amount         float64 `json:"amount" example:"100"`
+	Currency       string  `json:"currency" example:"USD"`
+	Status         string  `json:"status" example:"COMPLETED"`
+	StatusCode     string  `json:"status_code" example:"200"`
+	StatusDetail   string  `json:"status_detail" example:"The chargeback was executed."`
+	ReasonCode     string  `json:"reason_code" example:"4837"`
+	CreatedDate    string  `json:"created_date" example:"2023-02-20T15:04:05.000+0000"`
+	DisputeDueDate string  `json:"dispute_due_date" example:"2023-03-02T00:00:00.000+0000"`
+}
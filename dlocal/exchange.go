@@ -0,0 +1,55 @@
+package dlocal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Exchange rate Response
+type ExchangeRateResponseBody struct {
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
+
+// Gets dlocal's current exchange rate from one currency to another
+func GetExchangeRate(ctx context.Context, from string, to string) (int, map[string]interface{}, error) {
+	var req *http.Request
+	var err error
+
+	query := url.Values{}
+	query.Set("from", from)
+	query.Set("to", to)
+	if req, err = DlocalGetRequest(ctx, "/currency-exchanges", query); err != nil {
+		return 501, nil, err
+	}
+
+	client := http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+	}
+
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		observe("/currency-exchanges", start, 0, nil)
+		log.WithContext(ctx).Error("GetExchangeRate - ", err)
+		return 408, nil, err
+	}
+	defer res.Body.Close()
+
+	var res_body map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&res_body)
+	observe("/currency-exchanges", start, res.StatusCode, res_body)
+	if err != nil {
+		log.WithContext(ctx).Error("GetExchangeRate - ", err)
+		return 502, nil, err
+	}
+
+	return res.StatusCode, res_body, nil
+}
@@ -1,6 +1,7 @@
 package dlocal
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -11,6 +12,12 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// Payment status
+const (
+	StatusPaid     = "PAID"
+	StatusRejected = "REJECTED"
+)
+
 // Payment
 type PaymentRequestBody struct {
 	Amount            float64 `json:"amount"`
@@ -57,7 +64,7 @@ type PaymentResponseBody struct {
 }
 
 // Creates a new payment with Dlocal
-func MakePayment(order model.Order, payer model.Payer, card model.Card) (int, map[string]interface{}, error) {
+func MakePayment(ctx context.Context, order model.Order, payer model.Payer, card model.Card) (int, map[string]interface{}, error) {
 	var req *http.Request
 	var err error
 	var dlocalCard = Card{CardId: card.CardId}
@@ -86,7 +93,7 @@ func MakePayment(order model.Order, payer model.Payer, card model.Card) (int, ma
 	}
 	// Payment request body
 	Body := PaymentRequestBody{
-		Amount:            order.Amount / float64(order.TotalFees),
+		Amount:            order.InstallmentAmount(),
 		Currency:          *order.Currency,
 		Country:           *payer.Country,
 		PaymentMethodID:   "CARD",
@@ -98,36 +105,37 @@ func MakePayment(order model.Order, payer model.Payer, card model.Card) (int, ma
 
 	body_json, err := json.Marshal(Body)
 	if err != nil {
-		log.Error("MakePayment - ", err)
+		log.WithContext(ctx).Error("MakePayment - ", err)
 		return 501, nil, err
 	}
 	// prepare dlocal POST request
-	if req, err = DlocalPostRequest(body_json, "/payments"); err != nil {
+	if req, err = DlocalPostRequest(ctx, body_json, "/payments"); err != nil {
 		return 501, nil, err
 	}
 
 	client := http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:   30 * time.Second,
+		Transport: transport,
 	}
 
+	start := time.Now()
 	res, err := client.Do(req)
-	var res_body map[string]interface{}
-	_ = json.NewDecoder(res.Body).Decode(&res_body)
 	if err != nil {
-		log.Error("MakePayment - ", err)
-		if res != nil {
-			return res.StatusCode, res_body, err
-		}
+		observe("/payments", start, 0, nil)
+		log.WithContext(ctx).Error("MakePayment - ", err)
 		return 408, nil, err
 	}
 	defer res.Body.Close()
+	var res_body map[string]interface{}
+	_ = json.NewDecoder(res.Body).Decode(&res_body)
+	observe("/payments", start, res.StatusCode, res_body)
 
 	return res.StatusCode, res_body, nil
 }
 
 // Creates a payment with amount 1USD and card's token, saves new card ID
 // to reuse for future payments
-func PaymentWithToken(payer model.Payer, token string) (int, map[string]interface{}, error) {
+func PaymentWithToken(ctx context.Context, payer model.Payer, token string) (int, map[string]interface{}, error) {
 	var req *http.Request
 	var err error
 	var dlocalCard = CardWithToken{Token: token, Save: true}
@@ -164,29 +172,30 @@ func PaymentWithToken(payer model.Payer, token string) (int, map[string]interfac
 
 	body_json, err := json.Marshal(Body)
 	if err != nil {
-		log.Error("PaymentWithToken - ", err)
+		log.WithContext(ctx).Error("PaymentWithToken - ", err)
 		return 501, nil, err
 	}
 	// prepare dlocal POST request
-	if req, err = DlocalPostRequest(body_json, "/payments"); err != nil {
+	if req, err = DlocalPostRequest(ctx, body_json, "/payments"); err != nil {
 		return 501, nil, err
 	}
 
 	client := http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:   30 * time.Second,
+		Transport: transport,
 	}
 
+	start := time.Now()
 	res, err := client.Do(req)
-	var res_body map[string]interface{}
-	_ = json.NewDecoder(res.Body).Decode(&res_body)
 	if err != nil {
-		log.Error("PaymentWithToken - ", err)
-		if res != nil {
-			return res.StatusCode, res_body, err
-		}
+		observe("/payments", start, 0, nil)
+		log.WithContext(ctx).Error("PaymentWithToken - ", err)
 		return 408, nil, err
 	}
 	defer res.Body.Close()
+	var res_body map[string]interface{}
+	_ = json.NewDecoder(res.Body).Decode(&res_body)
+	observe("/payments", start, res.StatusCode, res_body)
 
 	return res.StatusCode, res_body, nil
 }
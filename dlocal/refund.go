@@ -0,0 +1,104 @@
+package dlocal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Refund status
+const (
+	RefundSuccess  = "SUCCESS"
+	RefundPending  = "PENDING"
+	RefundRejected = "REJECTED"
+)
+
+// Refund request body
+type RefundRequestBody struct {
+	PaymentID string  `json:"payment_id"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+}
+
+// Refund Response
+type RefundResponseBody struct {
+	ID           string  `json:"id"`
+	PaymentID    string  `json:"payment_id"`
+	Status       string  `json:"status"`
+	StatusCode   int     `json:"status_code"`
+	StatusDetail string  `json:"status_detail"`
+	Amount       float64 `json:"amount"`
+	Currency     string  `json:"currency"`
+}
+
+// Refunds an amount of a dlocal payment
+func MakeRefund(ctx context.Context, paymentID string, amount float64, currency string) (int, map[string]interface{}, error) {
+	var req *http.Request
+	var err error
+
+	body_json, err := json.Marshal(RefundRequestBody{
+		PaymentID: paymentID,
+		Amount:    amount,
+		Currency:  currency,
+	})
+	if err != nil {
+		log.WithContext(ctx).Error("MakeRefund - ", err)
+		return 501, nil, err
+	}
+	if req, err = DlocalPostRequest(ctx, body_json, "/refunds"); err != nil {
+		return 501, nil, err
+	}
+
+	client := http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+	}
+
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		observe("/refunds", start, 0, nil)
+		log.WithContext(ctx).Error("MakeRefund - ", err)
+		return 408, nil, err
+	}
+	defer res.Body.Close()
+	var res_body map[string]interface{}
+	_ = json.NewDecoder(res.Body).Decode(&res_body)
+	observe("/refunds", start, res.StatusCode, res_body)
+
+	return res.StatusCode, res_body, nil
+}
+
+// Gets the status of a dlocal refund
+func GetRefundStatus(ctx context.Context, refundID string) (int, map[string]interface{}, error) {
+	var req *http.Request
+	var err error
+
+	endpoint := "/refunds/" + url.PathEscape(refundID) + "/status"
+	if req, err = DlocalGetRequest(ctx, endpoint, nil); err != nil {
+		return 501, nil, err
+	}
+
+	client := http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+	}
+
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		observe("/refunds/status", start, 0, nil)
+		log.WithContext(ctx).Error("GetRefundStatus - ", err)
+		return 408, nil, err
+	}
+	defer res.Body.Close()
+	var res_body map[string]interface{}
+	_ = json.NewDecoder(res.Body).Decode(&res_body)
+	observe("/refunds/status", start, res.StatusCode, res_body)
+
+	return res.StatusCode, res_body, nil
+}
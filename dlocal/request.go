@@ -1,25 +1,42 @@
 package dlocal
 
 import (
+	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
+	"systempayment/config"
+	"systempayment/metrics"
+	"systempayment/tracing"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func DlocalPostRequest(body []byte, endpoint string) (*http.Request, error) {
-	host := os.Getenv("DLOCAL_URL")
-	x_login := os.Getenv("DLOCAL_X_LOGIN")
-	x_trans_key := os.Getenv("DLOCAL_X_TRANS_KEY")
+// transport - traces the requests to dlocal
+var transport = tracing.Transport("dlocal")
+
+// settings - API url and credentials, set on startup with Configure
+var settings config.Dlocal
+
+// Configure sets the API url and credentials of the requests
+func Configure(c config.Dlocal) {
+	settings = c
+}
+
+func DlocalPostRequest(ctx context.Context, body []byte, endpoint string) (*http.Request, error) {
+	host := settings.URL
+	x_login := settings.Login
+	x_trans_key := settings.TransKey
 	x_date := time.Now().Format(time.RFC3339)
 
-	req, err := http.NewRequest(http.MethodPost, host+endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, host+endpoint, bytes.NewReader(body))
 	if err != nil {
-		log.Error("DlocalPostRequest - ", err)
+		log.WithContext(ctx).Error("DlocalPostRequest - ", err)
 		return nil, err
 	}
 
@@ -29,7 +46,7 @@ func DlocalPostRequest(body []byte, endpoint string) (*http.Request, error) {
 	req.Header.Set("X-Trans-Key", x_trans_key)
 
 	// Authorization Header
-	secret := os.Getenv("DLOCAL_SECRET")
+	secret := settings.Secret
 
 	// Create a new HMAC by defining the hash type and the key (as byte array)
 	h := hmac.New(sha256.New, []byte(secret))
@@ -45,3 +62,67 @@ func DlocalPostRequest(body []byte, endpoint string) (*http.Request, error) {
 
 	return req, nil
 }
+
+func DlocalGetRequest(ctx context.Context, endpoint string, query url.Values) (*http.Request, error) {
+	host := settings.URL
+	x_login := settings.Login
+	x_trans_key := settings.TransKey
+	x_date := time.Now().Format(time.RFC3339)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host+endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		log.WithContext(ctx).Error("DlocalGetRequest - ", err)
+		return nil, err
+	}
+
+	req.Header.Set("X-Date", x_date)
+	req.Header.Set("X-Login", x_login)
+	req.Header.Set("X-Trans-Key", x_trans_key)
+
+	// Authorization Header, GET requests sign an empty body
+	secret := settings.Secret
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(x_login + x_date))
+	sha := hex.EncodeToString(h.Sum(nil))
+	req.Header.Set("Authorization", "V2-HMAC-SHA256, Signature: "+sha)
+
+	return req, nil
+}
+
+// observe - Records a call in the metrics with dlocal's status_code, status
+// 0 when dlocal didn't answer
+func observe(endpoint string, start time.Time, status int, body map[string]interface{}) {
+	var statusCode string
+	switch code := body["status_code"].(type) {
+	case string:
+		statusCode = code
+	case float64:
+		statusCode = strconv.FormatFloat(code, 'f', -1, 64)
+	}
+	metrics.DlocalCall(endpoint, status, statusCode, time.Since(start))
+}
+
+// NotificationMaxAge - how far X-Date of a notification can be from now,
+// older ones are taken as replays
+const NotificationMaxAge = 5 * time.Minute
+
+// VerifyNotification - checks the signature dlocal sends with its
+// notifications, the same scheme as the requests, and that it's recent
+func VerifyNotification(x_date string, authorization string, body []byte) bool {
+	x_login := settings.Login
+	secret := settings.Secret
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(append([]byte(x_login+x_date), body...))
+	expected := "V2-HMAC-SHA256, Signature: " + hex.EncodeToString(h.Sum(nil))
+	if !hmac.Equal([]byte(authorization), []byte(expected)) {
+		return false
+	}
+
+	date, err := time.Parse(time.RFC3339, x_date)
+	if err != nil {
+		return false
+	}
+	age := time.Since(date)
+	return age <= NotificationMaxAge && age >= -NotificationMaxAge
+}
@@ -24,35 +24,133 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/card/save-card": {
-            "post": {
-                "description": "Creates a new payment of 1USD with a CC token, saves card returned by dlocal.",
-                "consumes": [
-                    "application/json"
+        "/admin/fx/rates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Select exchange rates, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Card"
+                    "FX"
                 ],
-                "summary": "Saves a new Card",
+                "summary": "Select Exchange Rates",
                 "parameters": [
                     {
                         "type": "integer",
-                        "example": 1,
-                        "description": "payer_id example",
-                        "name": "payer_id",
-                        "in": "query",
-                        "required": true
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "description": "Card's token example",
-                        "name": "token",
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at",
+                            "date",
+                            "-date"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, -date by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "base example",
+                        "name": "base",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "UYU",
+                        "description": "quote example",
+                        "name": "quote",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.ExchangeRate"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "save a manual exchange rate (1 base = rate quote). Dates are UTC days, today by default. A rate for the same pair and date is replaced.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "FX"
+                ],
+                "summary": "Insert Exchange Rate",
+                "parameters": [
+                    {
+                        "description": "Exchange rate example",
+                        "name": "rate",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Token"
+                            "$ref": "#/definitions/model.ExchangeRate"
                         }
                     }
                 ],
@@ -60,43 +158,60 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.PaymentResponse"
+                            "$ref": "#/definitions/model.ExchangeRate"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/card/{id}": {
-            "get": {
-                "description": "Get one Card from ID",
+        "/admin/fx/rates/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Loads rates from a CSV file with columns date,base,quote,rate (date as YYYY-MM-DD)",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Card"
+                    "FX"
                 ],
-                "summary": "Select Card",
+                "summary": "Import Exchange Rates",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "example: 1",
-                        "name": "int",
-                        "in": "query",
+                        "type": "file",
+                        "description": "CSV file",
+                        "name": "file",
+                        "in": "formData",
                         "required": true
                     }
                 ],
@@ -104,120 +219,164 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.CardResponse"
+                            "$ref": "#/definitions/controller.Message"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/order/new": {
+        "/admin/fx/rates/refresh": {
             "post": {
-                "description": "save Order in database",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Fetches the current rate for a currency pair from dlocal and stores it",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Order"
+                    "FX"
                 ],
-                "summary": "Insert Order",
+                "summary": "Refresh Exchange Rate from dlocal",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "example": 1,
-                        "description": "payer_id example",
-                        "name": "payer_id",
+                        "type": "string",
+                        "example": "USD",
+                        "description": "base example",
+                        "name": "base",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "boolean",
-                        "example": true,
-                        "description": "auto example",
-                        "name": "auto",
-                        "in": "query"
-                    },
-                    {
-                        "description": "Order example",
-                        "name": "order",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.OrderRequest"
-                        }
+                        "type": "string",
+                        "example": "UYU",
+                        "description": "quote example",
+                        "name": "quote",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.OrderResponse"
+                            "$ref": "#/definitions/model.ExchangeRate"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/order/orders": {
+        "/admin/ledger/transactions": {
             "get": {
-                "description": "Select all Orders",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Ledger transactions with their entries, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Order"
+                    "Ledger"
                 ],
-                "summary": "Select all Orders",
+                "summary": "Select ledger transactions",
                 "parameters": [
                     {
                         "type": "integer",
-                        "example": 0,
-                        "description": "start example",
-                        "name": "start",
-                        "in": "query",
-                        "required": true
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, -id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "example": 10,
-                        "description": "count example",
-                        "name": "count",
-                        "in": "query",
-                        "required": true
+                        "example": 1,
+                        "description": "order_id example",
+                        "name": "order_id",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
                         "example": 1,
-                        "description": "payer_id example",
-                        "name": "payer_id",
+                        "description": "merchant_id example",
+                        "name": "merchant_id",
                         "in": "query"
                     }
                 ],
@@ -225,114 +384,202 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.OrderResponse"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.LedgerTransaction"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/order/{id}": {
+        "/admin/order/export": {
             "get": {
-                "description": "Get one Order from ID",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Streams the orders created between from and to (both included) as CSV or XLSX. Country is the tax country",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
                     "Order"
                 ],
-                "summary": "Select Order",
+                "summary": "Export Orders",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "example: 1",
-                        "name": "int",
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "currency example",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "UY",
+                        "description": "country example",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "finished"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "product_id example",
+                        "name": "product_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.OrderResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/payer/cards": {
-            "get": {
-                "description": "?payer_id=1",
+        "/admin/payer/reencrypt": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Re-encrypts payer and address personal data with the active key (run after key rotation)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Payer"
                 ],
-                "summary": "Get all cards from payer id",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "example": 1,
-                        "description": "payer_id example",
-                        "name": "payer_id",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
+                "summary": "Re-encrypt Payers",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.CardResponse"
-                            }
+                            "$ref": "#/definitions/controller.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/payer/new": {
+        "/admin/payer/{id}/erase": {
             "post": {
-                "description": "save payer in database",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Pseudonymizes personal fields on Payer and Address, removes saved cards, cancels subscriptions, and erases the payer's notifications, webhook event data and payment attempt IPs. Orders and payments are kept.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Payer"
                 ],
-                "summary": "Insert Payer",
+                "summary": "Erase Payer personal data",
                 "parameters": [
                     {
-                        "description": "Payer example",
-                        "name": "payer",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.Payer"
-                        }
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Payer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -345,85 +592,52 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
-                    }
-                }
-            }
-        },
-        "/payer/payers": {
-            "get": {
-                "description": "Select all Payers",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Payer"
-                ],
-                "summary": "Select all Payers",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "example": 0,
-                        "description": "start example",
-                        "name": "start",
-                        "in": "query",
-                        "required": true
                     },
-                    {
-                        "type": "integer",
-                        "example": 10,
-                        "description": "count example",
-                        "name": "count",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.PayerResponse"
-                            }
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/payer/primary-card": {
-            "put": {
-                "description": "?payer_id=1\u0026card_id=1",
-                "consumes": [
-                    "application/json"
+        "/admin/payer/{id}/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Returns all data tied to a payer (address, cards, orders, payments) as a JSON archive",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Payer"
                 ],
-                "summary": "Sets Payers primary card",
+                "summary": "Export Payer data",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "example: 1",
-                        "name": "payer_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "example: 1",
-                        "name": "card_id",
-                        "in": "query",
+                        "example": 1,
+                        "description": "Payer ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -431,121 +645,266 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.PayerResponse"
+                            "$ref": "#/definitions/model.PayerExport"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/payer/update/{id}": {
-            "put": {
-                "description": "Updates a payer in database (id req)",
-                "consumes": [
-                    "application/json"
+        "/admin/payment/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Streams the payments made between from and to (both included) as CSV or XLSX",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
-                    "Payer"
+                    "Payment"
                 ],
-                "summary": "Updates Payer",
+                "summary": "Export Payments",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "example: 1",
-                        "name": "id",
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Payer example",
-                        "name": "payer",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.Payer"
-                        }
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "currency example",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "UY",
+                        "description": "country example",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "paid",
+                            "partially_refunded",
+                            "refunded",
+                            "charged_back"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "product_id example",
+                        "name": "product_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.PayerResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/payer/{id}": {
+        "/admin/reconciliation/discrepancies": {
             "get": {
-                "description": "Get one Payer from ID",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Discrepancies found by reconciliation, filtered by report, kind and resolved",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Payer"
+                    "Reconciliation"
                 ],
-                "summary": "Select Payer",
+                "summary": "Select settlement discrepancies",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "example: 1",
-                        "name": "int",
-                        "in": "query",
-                        "required": true
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "report_id example",
+                        "name": "report_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "missing",
+                            "extra",
+                            "amount_mismatch"
+                        ],
+                        "type": "string",
+                        "description": "kind",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "resolved",
+                        "name": "resolved",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.PayerResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.SettlementDiscrepancy"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/payment/new": {
-            "post": {
-                "description": "Creates a new payment with dlocal",
+        "/admin/reconciliation/discrepancies/{id}/resolve": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Marks a settlement discrepancy as resolved",
                 "consumes": [
                     "application/json"
                 ],
@@ -553,80 +912,120 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Payment"
+                    "Reconciliation"
                 ],
-                "summary": "New Payment",
+                "summary": "Resolve discrepancy",
                 "parameters": [
                     {
                         "type": "integer",
                         "example": 1,
-                        "description": "order_id example",
-                        "name": "order_id",
-                        "in": "query",
+                        "description": "Discrepancy ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "boolean",
-                        "example": true,
-                        "description": "auto example",
-                        "name": "auto",
-                        "in": "query"
+                        "description": "Resolve example",
+                        "name": "resolve",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/model.ResolveRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.PaymentResponse"
+                            "$ref": "#/definitions/model.SettlementDiscrepancy"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/payment/payments": {
+        "/admin/reconciliation/reports": {
             "get": {
-                "description": "Select all Payments",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Imported settlement reports, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Payment"
+                    "Reconciliation"
                 ],
-                "summary": "Select all Payments",
+                "summary": "Select settlement reports",
                 "parameters": [
                     {
                         "type": "integer",
-                        "example": 0,
-                        "description": "start example",
-                        "name": "start",
-                        "in": "query",
-                        "required": true
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "example": 10,
-                        "description": "count example",
-                        "name": "count",
-                        "in": "query",
-                        "required": true
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "example": 1,
-                        "description": "orderId example",
-                        "name": "orderId",
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, -id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
@@ -634,591 +1033,6702 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.PaymentResponse"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.SettlementReport"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
-            }
-        },
-        "/product/new": {
+            },
             "post": {
-                "description": "save Product in database",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reconciles a dlocal settlement CSV against the recorded payments. The period defaults to the dates in the file",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Product"
+                    "Reconciliation"
                 ],
-                "summary": "Insert Product",
+                "summary": "Import settlement report",
                 "parameters": [
                     {
-                        "description": "Product example",
-                        "name": "product",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.ProductRequest"
-                        }
+                        "type": "file",
+                        "description": "Settlement CSV file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Period start (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Period end (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.ProductResponse"
+                            "$ref": "#/definitions/model.SettlementReport"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/product/products": {
+        "/admin/reconciliation/reports/{id}": {
             "get": {
-                "description": "Select all Products",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get settlement report by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Product"
+                    "Reconciliation"
                 ],
-                "summary": "Select all Products",
+                "summary": "Get settlement report",
                 "parameters": [
                     {
                         "type": "integer",
-                        "example": 0,
-                        "description": "start example",
-                        "name": "start",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "example": 10,
-                        "description": "count example",
-                        "name": "count",
-                        "in": "query",
+                        "example": 1,
+                        "description": "Report ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SettlementReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/aging": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unfinished orders with an overdue installment by days late (1-30, 31-60, 61-90, 90+) and currency. The period filters the due date, every overdue installment by default",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Installment aging report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2023-01-01",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-12-31",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "currency example",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.ProductResponse"
+                                "$ref": "#/definitions/model.AgingBucket"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
                     }
                 }
             }
         },
-        "/product/update/{id}": {
-            "put": {
-                "description": "Updates a Product in database (id req)",
-                "consumes": [
-                    "application/json"
+        "/admin/reports/mrr": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Current monthly recurring revenue of auto orders and subscriptions, and what they collected each month of the period (defaults to the last year)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Product"
+                    "Reports"
                 ],
-                "summary": "Updates Product",
+                "summary": "MRR report",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "example: 1",
-                        "name": "int",
-                        "in": "query",
-                        "required": true
+                        "type": "string",
+                        "example": "2023-01-01",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
                     },
                     {
-                        "description": "Product example",
-                        "name": "product",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.ProductRequest"
-                        }
+                        "type": "string",
+                        "example": "2023-12-31",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "currency example",
+                        "name": "currency",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.ProductResponse"
+                            "$ref": "#/definitions/model.MRRReport"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/product/{id}": {
+        "/admin/reports/orders": {
             "get": {
-                "description": "Get one Product from ID",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Finished, active and abandoned (installment overdue more than abandoned_days, 30 by default) orders created in the period, by currency",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Product"
+                    "Reports"
                 ],
-                "summary": "Select Product",
+                "summary": "Orders report",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2023-01-01",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-12-31",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "currency example",
+                        "name": "currency",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "example: 1",
-                        "name": "int",
-                        "in": "query",
-                        "required": true
+                        "example": 30,
+                        "description": "abandoned_days example",
+                        "name": "abandoned_days",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.ProductResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.OrdersReport"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError400"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError500"
+                            "$ref": "#/definitions/httputil.ProblemDetails"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "httputil.HTTPError400": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 400
-                },
-                "error": {
-                    "type": "string",
-                    "example": "Invalid request payload or query params"
-                },
-                "message": {
-                    "type": "string"
+        },
+        "/admin/reports/revenue": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Payments, refunds and chargebacks by day, week or month and currency. Defaults to the last year by month",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Revenue report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2023-01-01",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-12-31",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "currency example",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "interval",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Revenue"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
                 }
             }
         },
-        "httputil.HTTPError500": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 500
-                },
-                "error": {
-                    "type": "string",
-                    "example": "Internal Server Error"
-                },
-                "message": {
-                    "type": "string"
+        "/admin/risk/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Payments held by the risk rules, filtered by review status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Risk"
+                ],
+                "summary": "Select reviews",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "denied"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.PaymentAttempt"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
                 }
             }
         },
-        "model.Address": {
-            "type": "object",
-            "properties": {
-                "city": {
-                    "type": "string",
-                    "example": "Volta Redonda"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "number": {
-                    "type": "string",
-                    "example": "1106"
-                },
-                "state": {
-                    "type": "string",
-                    "example": "Rio de Janeiro"
+        "/admin/risk/reviews/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a payment held by the risk rules by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Risk"
+                ],
+                "summary": "Get review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Review ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaymentAttempt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/risk/reviews/{id}/approve": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Approves a payment held by the risk rules and charges it with dlocal. The order isn't checked by the rules again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Risk"
+                ],
+                "summary": "Approve review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Review ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Review example",
+                        "name": "review",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaymentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/risk/reviews/{id}/deny": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Denies a payment held by the risk rules, the order won't be charged anymore",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Risk"
+                ],
+                "summary": "Deny review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Review ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Review example",
+                        "name": "review",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaymentAttempt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhook/endpoints": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Registered endpoints, secrets aren't returned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Select webhook endpoints",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "merchant_id example",
+                        "name": "merchant_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookEndpoint"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Events of the merchant's orders are POSTed to the URL, signed with the returned secret (only shown here). Without merchant_id it receives every event, without events every type.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Register webhook endpoint",
+                "parameters": [
+                    {
+                        "description": "Endpoint example",
+                        "name": "endpoint",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookEndpointRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookEndpoint"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhook/endpoints/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "No more events are sent to the endpoint, its pending deliveries are dropped",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Delete webhook endpoint",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Endpoint ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhook/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Emitted events, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Select webhook events",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, -id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "merchant_id example",
+                        "name": "merchant_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "payment.succeeded",
+                            "payment.failed",
+                            "order.finished",
+                            "card.saved",
+                            "refund.created"
+                        ],
+                        "type": "string",
+                        "description": "type example",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.WebhookEvent"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhook/events/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deliveries of an event with every request made",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Webhook delivery log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhook/events/{id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sends the event again, to one endpoint or to all the endpoints it was sent to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Replay webhook event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "endpoint_id example",
+                        "name": "endpoint_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/card/save-card": {
+            "post": {
+                "description": "Creates a new payment of 1USD with a CC token, saves card returned by dlocal. Rate limited by IP, API key and payer, and blocked for a while after too many rejected cards of the payer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Card"
+                ],
+                "summary": "Saves a new Card",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "payer_id example",
+                        "name": "payer_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Card's token example",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Token"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaymentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/card/{id}": {
+            "get": {
+                "description": "Get one Card from ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Card"
+                ],
+                "summary": "Select Card",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "example: 1",
+                        "name": "int",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CardResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/chargeback/chargebacks": {
+            "get": {
+                "description": "Chargebacks (optional status and payment), soonest evidence deadline first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chargeback"
+                ],
+                "summary": "Select chargebacks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at",
+                            "evidence_due_at",
+                            "-evidence_due_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, evidence_due_at by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "open",
+                            "evidence_submitted",
+                            "won",
+                            "lost"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "payment_id example",
+                        "name": "payment_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Chargeback"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/chargeback/new": {
+            "post": {
+                "description": "Records a chargeback by hand (dlocal's are received by notification). The amount defaults to the payment's, the evidence deadline to 10 days",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chargeback"
+                ],
+                "summary": "New Chargeback",
+                "parameters": [
+                    {
+                        "description": "Chargeback example",
+                        "name": "chargeback",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ChargebackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Chargeback"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/chargeback/{id}": {
+            "get": {
+                "description": "Get chargeback by ID with its evidence documents",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chargeback"
+                ],
+                "summary": "Get Chargeback",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Chargeback ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Chargeback"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/chargeback/{id}/evidence": {
+            "post": {
+                "description": "Attaches a document (up to 10 MB) to an open chargeback before its evidence deadline",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chargeback"
+                ],
+                "summary": "Upload Chargeback evidence",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Chargeback ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Evidence document",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Description",
+                        "name": "description",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ChargebackEvidence"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/chargeback/{id}/evidence/{evidence_id}": {
+            "get": {
+                "description": "Evidence document as it was uploaded",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Chargeback"
+                ],
+                "summary": "Download Chargeback evidence",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Chargeback ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Evidence ID",
+                        "name": "evidence_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/chargeback/{id}/status": {
+            "put": {
+                "description": "Moves a chargeback to evidence_submitted, won or lost. Won gives the amount back to the merchant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chargeback"
+                ],
+                "summary": "Update Chargeback status",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Chargeback ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Status example",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ChargebackStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Chargeback"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupon/coupons": {
+            "get": {
+                "description": "Select all Coupons",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coupon"
+                ],
+                "summary": "Select all Coupons",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "active example",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Coupon"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/coupon/new": {
+            "post": {
+                "description": "save a discount Coupon. type percentage (value 0-100) or fixed (value in currency).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coupon"
+                ],
+                "summary": "Insert Coupon",
+                "parameters": [
+                    {
+                        "description": "Coupon example",
+                        "name": "coupon",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CouponRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Coupon"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupon/{id}": {
+            "get": {
+                "description": "Get one Coupon from ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coupon"
+                ],
+                "summary": "Select Coupon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Coupon"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupon/{id}/deactivate": {
+            "put": {
+                "description": "Coupon can't be redeemed anymore, orders already using it keep their discount",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coupon"
+                ],
+                "summary": "Deactivates Coupon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Coupon"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/dlocal/notifications/chargebacks": {
+            "post": {
+                "description": "Receives dlocal's chargeback notifications, signed with the X-Date and Authorization headers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chargeback"
+                ],
+                "summary": "dlocal chargeback notification",
+                "parameters": [
+                    {
+                        "description": "Notification example",
+                        "name": "notification",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dlocal.ChargebackNotification"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/merchant/merchants": {
+            "get": {
+                "description": "Select all Merchants",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Merchant"
+                ],
+                "summary": "Select all Merchants",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Merchant"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/merchant/new": {
+            "post": {
+                "description": "save a Merchant, invoices of its products' payments are numbered from 1",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Merchant"
+                ],
+                "summary": "Insert Merchant",
+                "parameters": [
+                    {
+                        "description": "Merchant example",
+                        "name": "merchant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MerchantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Merchant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/merchant/{id}": {
+            "get": {
+                "description": "Get one Merchant from ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Merchant"
+                ],
+                "summary": "Select Merchant",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Merchant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Merchant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/merchant/{id}/balance": {
+            "get": {
+                "description": "Sales, refunds, fees, chargebacks and net amount owed to the merchant per currency from the ledger",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ledger"
+                ],
+                "summary": "Merchant balance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Merchant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.MerchantBalance"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/notification/notifications": {
+            "get": {
+                "description": "Emails sent or waiting to be sent to payers, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "Select all Notifications",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, -id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "payer_id example",
+                        "name": "payer_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "sent",
+                            "failed",
+                            "canceled"
+                        ],
+                        "type": "string",
+                        "description": "status example",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Notification"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/new": {
+            "post": {
+                "description": "save Order in database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Insert Order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "payer_id example",
+                        "name": "payer_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "auto example",
+                        "name": "auto",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Order example",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.OrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/orders": {
+            "get": {
+                "description": "Select all Orders",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Select all Orders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at",
+                            "next_payment",
+                            "-next_payment",
+                            "amount",
+                            "-amount"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "payer_id example",
+                        "name": "payer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "product_id example",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "currency example",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": false,
+                        "description": "finished example",
+                        "name": "finished",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.OrderResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{id}": {
+            "get": {
+                "description": "Get one Order from ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Select Order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "example: 1",
+                        "name": "int",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{id}/balance": {
+            "get": {
+                "description": "Amount, paid, refunded and outstanding amount of the order from the ledger",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ledger"
+                ],
+                "summary": "Order balance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.OrderBalance"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/payer/cards": {
+            "get": {
+                "description": "?payer_id=1",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payer"
+                ],
+                "summary": "Get all cards from payer id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "payer_id example",
+                        "name": "payer_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.CardResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/payer/new": {
+            "post": {
+                "description": "save payer in database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payer"
+                ],
+                "summary": "Insert Payer",
+                "parameters": [
+                    {
+                        "description": "Payer example",
+                        "name": "payer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Payer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PayerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/payer/payers": {
+            "get": {
+                "description": "Select all Payers, or look them up by email or document (case and surrounding spaces ignored)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payer"
+                ],
+                "summary": "Select all Payers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "jhondoe@mail.com",
+                        "description": "email example",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "23415162",
+                        "description": "document example",
+                        "name": "document",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.PayerResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/payer/primary-card": {
+            "put": {
+                "description": "?payer_id=1\u0026card_id=1",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payer"
+                ],
+                "summary": "Sets Payers primary card",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "example: 1",
+                        "name": "payer_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "example: 1",
+                        "name": "card_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PayerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/payer/update/{id}": {
+            "put": {
+                "description": "Updates a payer in database (id req)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payer"
+                ],
+                "summary": "Updates Payer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "example: 1",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Payer example",
+                        "name": "payer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Payer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PayerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/payer/{id}": {
+            "get": {
+                "description": "Get one Payer from ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payer"
+                ],
+                "summary": "Select Payer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "example: 1",
+                        "name": "int",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PayerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/new": {
+            "post": {
+                "description": "Creates a new payment with dlocal, checked by the risk rules first: denied with 402, or held for review with 202 and the attempt",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payment"
+                ],
+                "summary": "New Payment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "order_id example",
+                        "name": "order_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "auto example",
+                        "name": "auto",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaymentResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaymentAttempt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/payments": {
+            "get": {
+                "description": "Select all Payments",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payment"
+                ],
+                "summary": "Select all Payments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at",
+                            "amount",
+                            "-amount"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, -created_at by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "order_id example",
+                        "name": "order_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "paid",
+                            "partially_refunded",
+                            "refunded",
+                            "charged_back"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "currency example",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.PaymentResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/{id}/receipt": {
+            "get": {
+                "description": "Receipt of a payment as HTML (default) or PDF. Payments made before invoices existed get their invoice number now",
+                "produces": [
+                    "text/html",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Payment"
+                ],
+                "summary": "Payment receipt",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "html",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "description": "html or pdf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/{id}/refund": {
+            "post": {
+                "description": "Refunds an amount of the payment with dlocal, everything not refunded yet when amount is 0 or missing. Refunds dlocal hasn't made yet answer 202 as pending",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payment"
+                ],
+                "summary": "Refund Payment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Refund example",
+                        "name": "refund",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/model.RefundRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Refund"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.Refund"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/{id}/refunds": {
+            "get": {
+                "description": "Refunds of a payment",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payment"
+                ],
+                "summary": "Payment refunds",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Refund"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/new": {
+            "post": {
+                "description": "save a subscription Plan for a Product. Charged every interval_count interval (day, week, month, year).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plan"
+                ],
+                "summary": "Insert Plan",
+                "parameters": [
+                    {
+                        "description": "Plan example",
+                        "name": "plan",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PlanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Plan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/plans": {
+            "get": {
+                "description": "Select all Plans",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plan"
+                ],
+                "summary": "Select all Plans",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "active example",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Plan"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/{id}": {
+            "get": {
+                "description": "Get one Plan from ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plan"
+                ],
+                "summary": "Select Plan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Plan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/{id}/deactivate": {
+            "put": {
+                "description": "No new subscriptions to the Plan, existing ones keep renewing",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plan"
+                ],
+                "summary": "Deactivates Plan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Plan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/product/new": {
+            "post": {
+                "description": "save Product in database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Insert Product",
+                "parameters": [
+                    {
+                        "description": "Product example",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/product/products": {
+            "get": {
+                "description": "Select all Products",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Select all Products",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "active",
+                        "description": "status example",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.ProductResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/product/update/{id}": {
+            "put": {
+                "description": "Updates a Product in database (id req)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Updates Product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "example: 1",
+                        "name": "int",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Product example",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/product/{id}": {
+            "get": {
+                "description": "Get one Product from ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Select Product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "example: 1",
+                        "name": "int",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/product/{id}/activate": {
+            "put": {
+                "description": "Moves a draft or archived Product to active, only active products can be ordered",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Activates Product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/product/{id}/archive": {
+            "put": {
+                "description": "Archived products are kept for existing orders but can't be ordered",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Archives Product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/product/{id}/prices": {
+            "get": {
+                "description": "All prices of a Product, newest first. Current prices have no effective_to.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Product price history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "currency example",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ProductPrice"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Adds or replaces the Product's price in the given currency, the previous one is kept in the history",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Sets Product price in a currency",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Price example",
+                        "name": "price",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PriceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/product/{id}/prices/{currency}": {
+            "delete": {
+                "description": "The Product can no longer be ordered in that currency. The default currency can't be removed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Removes Product price in a currency",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "UYU",
+                        "description": "Currency",
+                        "name": "currency",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/new": {
+            "post": {
+                "description": "Starts the plan's trial, or charges the first period right away with the card (payer's primary card by default). A first payment held by the risk rules answers 202 with the subscription in review",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Subscribe Payer to a Plan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "payer_id example",
+                        "name": "payer_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Subscription example",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/subscriptions": {
+            "get": {
+                "description": "Select all Subscriptions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Select all Subscriptions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at",
+                            "current_period_end",
+                            "-current_period_end"
+                        ],
+                        "type": "string",
+                        "description": "Sort, descending with a leading -, id by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-01",
+                        "description": "Created from (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-02-28",
+                        "description": "Created to (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "payer_id example",
+                        "name": "payer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "active",
+                        "description": "status example",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pagination.Page"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Subscription"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}": {
+            "get": {
+                "description": "Get one Subscription from ID with its renewal orders",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Select Subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/cancel": {
+            "put": {
+                "description": "Cancels at the end of the current period, or right away with at_period_end=false",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Cancels Subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "at_period_end example",
+                        "name": "at_period_end",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/pause": {
+            "put": {
+                "description": "No renewals are charged while paused",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Pauses Subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/resume": {
+            "put": {
+                "description": "If the period ended while paused a new one starts now and is charged on the next renewal run",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Resumes Subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apperror.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "currency"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "zero value"
+                }
+            }
+        },
+        "controller.Message": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "message"
+                }
+            }
+        },
+        "dlocal.ChargebackNotification": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "created_date": {
+                    "type": "string",
+                    "example": "2023-02-20T15:04:05.000+0000"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "dispute_due_date": {
+                    "type": "string",
+                    "example": "2023-03-02T00:00:00.000+0000"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "CHAR42-2023"
+                },
+                "payment_id": {
+                    "type": "string",
+                    "example": "D-4-cf2d3e7a"
+                },
+                "reason_code": {
+                    "type": "string",
+                    "example": "4837"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "COMPLETED"
+                },
+                "status_code": {
+                    "type": "string",
+                    "example": "200"
+                },
+                "status_detail": {
+                    "type": "string",
+                    "example": "The chargeback was executed."
+                }
+            }
+        },
+        "httputil.ProblemDetails": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "Order not found"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "record not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apperror.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/order/1"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:problem:not_found"
+                }
+            }
+        },
+        "model.AccountBalance": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "string",
+                    "example": "payer_receivable"
+                },
+                "balance": {
+                    "type": "number",
+                    "example": 200
+                },
+                "credit": {
+                    "type": "number",
+                    "example": 100
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "debit": {
+                    "type": "number",
+                    "example": 300
+                }
+            }
+        },
+        "model.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "example": "Volta Redonda"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string",
+                    "example": "1106"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "Rio de Janeiro"
+                },
+                "street": {
+                    "type": "string",
+                    "example": "Servidão B-1"
+                },
+                "zip_code": {
+                    "type": "string",
+                    "example": "27275-595"
+                }
+            }
+        },
+        "model.AgingBucket": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string",
+                    "enum": [
+                        "1-30",
+                        "31-60",
+                        "61-90",
+                        "90+"
+                    ],
+                    "example": "1-30"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "orders": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "outstanding": {
+                    "type": "number",
+                    "example": 1200
+                },
+                "overdue": {
+                    "type": "number",
+                    "example": 250
+                }
+            }
+        },
+        "model.Card": {
+            "type": "object",
+            "properties": {
+                "brand": {
+                    "type": "string",
+                    "example": "Visa"
+                },
+                "card_id": {
+                    "type": "string"
+                },
+                "country": {
+                    "description": "Issuing country, when dlocal reports it",
+                    "type": "string",
+                    "example": "UY"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_4": {
+                    "type": "string",
+                    "maxLength": 4,
+                    "minLength": 4,
+                    "example": "1234"
+                },
+                "payer_id": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                }
+            }
+        },
+        "model.CardResponse": {
+            "type": "object",
+            "properties": {
+                "brand": {
+                    "type": "string",
+                    "example": "Visa"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_4": {
+                    "type": "string",
+                    "example": "1234"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Chargeback": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "dlocal_id": {
+                    "type": "string",
+                    "example": "CHAR42-2023"
+                },
+                "evidence": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ChargebackEvidence"
+                    }
+                },
+                "evidence_due_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "note": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "payer_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "payment_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "No cardholder authorization"
+                },
+                "reason_code": {
+                    "type": "string",
+                    "example": "4837"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "evidence_submitted",
+                        "won",
+                        "lost"
+                    ],
+                    "example": "open"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ChargebackEvidence": {
+            "type": "object",
+            "properties": {
+                "chargeback_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Signed delivery receipt"
+                },
+                "file_name": {
+                    "type": "string",
+                    "example": "delivery-proof.pdf"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 48213
+                }
+            }
+        },
+        "model.ChargebackRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "dlocal_id": {
+                    "type": "string",
+                    "example": "CHAR42-2023"
+                },
+                "evidence_due_at": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "No cardholder authorization"
+                },
+                "reason_code": {
+                    "type": "string",
+                    "example": "4837"
+                }
+            }
+        },
+        "model.ChargebackStatusRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "example": "Issuer accepted the delivery proof"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "evidence_submitted",
+                        "won",
+                        "lost"
+                    ],
+                    "example": "won"
+                }
+            }
+        },
+        "model.Coupon": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string",
+                    "maxLength": 40,
+                    "minLength": 3,
+                    "example": "VERANO10"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "max_redemptions": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 100
+                },
+                "per_payer_limit": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1
+                },
+                "product_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1
+                    ]
+                },
+                "redemptions": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "percentage",
+                        "fixed"
+                    ],
+                    "example": "percentage"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number",
+                    "example": 10
+                }
+            }
+        },
+        "model.CouponRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "VERANO10"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "max_redemptions": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "per_payer_limit": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "product_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "percentage",
+                        "fixed"
+                    ],
+                    "example": "percentage"
+                },
+                "value": {
+                    "type": "number",
+                    "example": 10
+                }
+            }
+        },
+        "model.ExchangeRate": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string",
+                    "maxLength": 3,
+                    "minLength": 3,
+                    "example": "USD"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "quote": {
+                    "type": "string",
+                    "maxLength": 3,
+                    "minLength": 3,
+                    "example": "UYU"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 39.25
+                },
+                "source": {
+                    "type": "string",
+                    "example": "manual"
+                }
+            }
+        },
+        "model.LedgerEntry": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "string",
+                    "example": "dlocal_clearing"
+                },
+                "credit": {
+                    "type": "number",
+                    "example": 0
+                },
+                "debit": {
+                    "type": "number",
+                    "example": 100
+                }
+            }
+        },
+        "model.LedgerTransaction": {
+            "type": "object",
+            "properties": {
+                "chargeback_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.LedgerEntry"
+                    }
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "merchant_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "order_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "payment_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "payment:1"
+                },
+                "refund_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "type": {
+                    "type": "string",
+                    "example": "payment"
+                }
+            }
+        },
+        "model.MRR": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "orders": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "orders_amount": {
+                    "type": "number",
+                    "example": 400
+                },
+                "subscription_amount": {
+                    "type": "number",
+                    "example": 450
+                },
+                "subscriptions": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "total": {
+                    "type": "number",
+                    "example": 850
+                }
+            }
+        },
+        "model.MRRReport": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MRR"
+                    }
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RecurringMonth"
+                    }
+                }
+            }
+        },
+        "model.Merchant": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "Av. 18 de Julio 1234, Montevideo"
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 2,
+                    "minLength": 2,
+                    "example": "UY"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "facturacion@academia.com"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "invoice_prefix": {
+                    "type": "string",
+                    "maxLength": 10,
+                    "example": "A"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3,
+                    "example": "Academia Online"
+                },
+                "next_invoice_number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "tax_id": {
+                    "type": "string",
+                    "example": "214563780018"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MerchantBalance": {
+            "type": "object",
+            "properties": {
+                "chargebacks": {
+                    "type": "number",
+                    "example": 0
+                },
+                "clearing": {
+                    "description": "collected by dlocal and not paid back",
+                    "type": "number",
+                    "example": 720
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "fees": {
+                    "type": "number",
+                    "example": 30
+                },
+                "merchant_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "net": {
+                    "type": "number",
+                    "example": 720
+                },
+                "receivable": {
+                    "type": "number",
+                    "example": 200
+                },
+                "refunds": {
+                    "type": "number",
+                    "example": 50
+                },
+                "sales": {
+                    "type": "number",
+                    "example": 1000
+                }
+            }
+        },
+        "model.MerchantRequest": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "Av. 18 de Julio 1234, Montevideo"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "UY"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "facturacion@academia.com"
+                },
+                "invoice_prefix": {
+                    "type": "string",
+                    "example": "A"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Academia Online"
+                },
+                "tax_id": {
+                    "type": "string",
+                    "example": "214563780018"
+                }
+            }
+        },
+        "model.Notification": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "event": {
+                    "type": "string",
+                    "example": "payment_succeeded"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payer_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                }
+            }
+        },
+        "model.Order": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "auto_suspended": {
+                    "description": "automatic charging paused while the payer has an open chargeback",
+                    "type": "boolean"
+                },
+                "coupon_code": {
+                    "description": "Amount = Subtotal - Discount when a coupon was applied",
+                    "type": "string",
+                    "example": "VERANO10"
+                },
+                "coupon_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "current_fee": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "discount": {
+                    "type": "number",
+                    "example": 10
+                },
+                "finished": {
+                    "type": "boolean"
+                },
+                "fx_rate": {
+                    "type": "number",
+                    "example": 39.25
+                },
+                "fx_rate_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "net_amount": {
+                    "type": "number",
+                    "example": 73.77
+                },
+                "next_payment": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "original_amount": {
+                    "description": "Set when the price was converted from the product's default currency",
+                    "type": "number",
+                    "example": 100
+                },
+                "original_currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "payer_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Payment"
+                    }
+                },
+                "price_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "product": {
+                    "$ref": "#/definitions/model.Product"
+                },
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "subscription_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "subtotal": {
+                    "type": "number",
+                    "example": 100
+                },
+                "tax_amount": {
+                    "type": "number",
+                    "example": 16.23
+                },
+                "tax_category": {
+                    "type": "string",
+                    "example": "standard"
+                },
+                "tax_country": {
+                    "description": "Amount is the gross amount, NetAmount + TaxAmount",
+                    "type": "string",
+                    "example": "UY"
+                },
+                "tax_lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tax.Line"
+                    }
+                },
+                "total_fees": {
+                    "type": "integer",
+                    "maximum": 24,
+                    "minimum": 1,
+                    "example": 3
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.OrderBalance": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AccountBalance"
+                    }
+                },
+                "amount": {
+                    "type": "number",
+                    "example": 300
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "order_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "outstanding": {
+                    "type": "number",
+                    "example": 200
+                },
+                "paid": {
+                    "type": "number",
+                    "example": 100
+                },
+                "refunded": {
+                    "type": "number",
+                    "example": 0
+                }
+            }
+        },
+        "model.OrderRequest": {
+            "type": "object",
+            "properties": {
+                "coupon_code": {
+                    "type": "string",
+                    "example": "VERANO10"
+                },
+                "currency": {
+                    "type": "string",
+                    "maxLength": 3,
+                    "minLength": 3
+                },
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_fees": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "finished": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "net_amount": {
+                    "type": "number"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PaymentResponse"
+                    }
+                },
+                "product": {
+                    "$ref": "#/definitions/model.Product"
+                },
+                "subtotal": {
+                    "type": "number"
+                },
+                "tax_amount": {
+                    "type": "number"
+                },
+                "tax_lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tax.Line"
+                    }
+                },
+                "total_fees": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.OrdersReport": {
+            "type": "object",
+            "properties": {
+                "abandoned": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "active": {
+                    "type": "integer",
+                    "example": 32
+                },
+                "churn_rate": {
+                    "type": "number",
+                    "example": 0.08
+                },
+                "created": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "finished": {
+                    "type": "integer",
+                    "example": 60
+                }
+            }
+        },
+        "model.Payer": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "$ref": "#/definitions/model.Address"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "24/07/1992"
+                },
+                "card_id": {
+                    "type": "integer"
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 2,
+                    "minLength": 2,
+                    "example": "UY"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "document": {
+                    "type": "string",
+                    "example": "23415162"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 8,
+                    "example": "jhondoe@mail.com"
+                },
+                "erased_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3,
+                    "example": "Jhon Doe"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+123456789"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_reference": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PayerExport": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Card"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Order"
+                    }
+                },
+                "payer": {
+                    "$ref": "#/definitions/model.Payer"
+                }
+            }
+        },
+        "model.PayerResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "$ref": "#/definitions/model.Address"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "24/07/1992"
+                },
+                "card_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "document": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "jhondoe@mail.com"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Jhon Doe"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+123456789"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_reference": {
+                    "type": "string",
+                    "example": "12345"
+                }
+            }
+        },
+        "model.Payment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 5000
+                },
+                "card_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 2,
+                    "minLength": 2,
+                    "example": "UY"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "maxLength": 3,
+                    "minLength": 3,
+                    "example": "USD"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dlocal_id": {
+                    "type": "string",
+                    "example": "D-4-cf2d3e7a"
+                },
+                "fx_rate": {
+                    "type": "number",
+                    "example": 39.25
+                },
+                "fx_rate_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "installment": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "net_amount": {
+                    "type": "number",
+                    "example": 4098.36
+                },
+                "order_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "order_number": {
+                    "type": "string"
+                },
+                "payment_method_flow": {
+                    "type": "string",
+                    "maxLength": 10,
+                    "minLength": 2,
+                    "example": "DIRECT"
+                },
+                "payment_method_id": {
+                    "type": "string",
+                    "maxLength": 4,
+                    "minLength": 2,
+                    "example": "CARD"
+                },
+                "refunded_amount": {
+                    "type": "number",
+                    "example": 0
+                },
+                "settled_at": {
+                    "type": "string"
+                },
+                "settlement_report_id": {
+                    "description": "set when a dlocal settlement report includes the payment",
+                    "type": "integer",
+                    "example": 1
+                },
+                "status": {
+                    "type": "string",
+                    "example": "paid"
+                },
+                "tax_amount": {
+                    "type": "number",
+                    "example": 901.64
+                },
+                "tax_lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tax.Line"
+                    }
+                }
+            }
+        },
+        "model.PaymentAttempt": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "card_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "installment": {
+                    "description": "Installment of the order charged",
+                    "type": "integer",
+                    "example": 1
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "card_save",
+                        "payment"
+                    ],
+                    "example": "payment"
+                },
+                "order_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "payer_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "payment_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "insufficient funds"
+                },
+                "result": {
+                    "type": "string",
+                    "enum": [
+                        "approved",
+                        "rejected",
+                        "failed",
+                        "blocked",
+                        "held"
+                    ],
+                    "example": "held"
+                },
+                "review_note": {
+                    "type": "string",
+                    "example": "payer confirmed by phone"
+                },
+                "review_status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "approved",
+                        "denied"
+                    ],
+                    "example": "pending"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "risk_outcome": {
+                    "description": "Risk rules' outcome and the rules that matched, comma separated",
+                    "type": "string",
+                    "enum": [
+                        "allow",
+                        "review",
+                        "deny"
+                    ],
+                    "example": "review"
+                },
+                "risk_reasons": {
+                    "type": "string",
+                    "example": "amount_over_review_limit,card_country_mismatch"
+                }
+            }
+        },
+        "model.PaymentResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 125
+                },
+                "card": {
+                    "$ref": "#/definitions/model.Card"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "UY"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "PAY2323243343543"
+                },
+                "installment": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "net_amount": {
+                    "type": "number",
+                    "example": 102.46
+                },
+                "order_number": {
+                    "type": "string"
+                },
+                "payment_method_flow": {
+                    "type": "string"
+                },
+                "payment_method_id": {
+                    "type": "string",
+                    "example": "CARD"
+                },
+                "tax_amount": {
+                    "type": "number",
+                    "example": 22.54
+                },
+                "tax_lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tax.Line"
+                    }
+                }
+            }
+        },
+        "model.Plan": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "amount": {
+                    "type": "number",
+                    "example": 15
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "maxLength": 3,
+                    "minLength": 3,
+                    "example": "USD"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "interval": {
+                    "type": "string",
+                    "enum": [
+                        "day",
+                        "week",
+                        "month",
+                        "year"
+                    ],
+                    "example": "month"
+                },
+                "interval_count": {
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 0,
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3,
+                    "example": "Membresia mensual"
+                },
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "trial_days": {
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 0,
+                    "example": 7
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PlanRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 15
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "interval": {
+                    "type": "string",
+                    "enum": [
+                        "day",
+                        "week",
+                        "month",
+                        "year"
+                    ],
+                    "example": "month"
+                },
+                "interval_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Membresia mensual"
+                },
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "trial_days": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "model.PriceRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 25000
+                },
+                "currency": {
+                    "type": "string",
+                    "maxLength": 3,
+                    "minLength": 3,
+                    "example": "UYU"
+                }
+            }
+        },
+        "model.Product": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 5000
+                },
+                "currency": {
+                    "type": "string",
+                    "maxLength": 3,
+                    "minLength": 3,
+                    "example": "USD"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 6,
+                    "example": "Curso de Programacion"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "merchant_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 6,
+                    "example": "programacion en C"
+                },
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ProductPrice"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "tax_category": {
+                    "type": "string",
+                    "example": "standard"
+                }
+            }
+        },
+        "model.ProductPrice": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 5000
+                },
+                "currency": {
+                    "type": "string",
+                    "maxLength": 3,
+                    "minLength": 3,
+                    "example": "USD"
+                },
+                "effective_from": {
+                    "type": "string"
+                },
+                "effective_to": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "model.ProductRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 5000
                 },
-                "street": {
+                "currency": {
                     "type": "string",
-                    "example": "Servidão B-1"
+                    "maxLength": 3,
+                    "minLength": 3,
+                    "example": "USD"
                 },
-                "zip_code": {
+                "description": {
                     "type": "string",
-                    "example": "27275-595"
+                    "maxLength": 100,
+                    "minLength": 6,
+                    "example": "Curso de Programacion"
+                },
+                "merchant_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 6,
+                    "example": "programacion en C"
+                },
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PriceRequest"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "active"
+                    ],
+                    "example": "active"
+                },
+                "tax_category": {
+                    "type": "string",
+                    "enum": [
+                        "standard",
+                        "reduced",
+                        "service",
+                        "exempt"
+                    ],
+                    "example": "standard"
                 }
             }
         },
-        "model.Card": {
+        "model.ProductResponse": {
             "type": "object",
             "properties": {
-                "brand": {
+                "amount": {
+                    "type": "number",
+                    "example": 5000
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
                     "type": "string",
-                    "example": "Visa"
+                    "maxLength": 3,
+                    "minLength": 3,
+                    "example": "USD"
                 },
-                "card_id": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 6,
+                    "example": "Curso de Programacion"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "merchant_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 6,
+                    "example": "programacion en C"
+                },
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ProductPrice"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "tax_category": {
+                    "type": "string",
+                    "example": "standard"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.RecurringMonth": {
+            "type": "object",
+            "properties": {
+                "collected": {
+                    "type": "number",
+                    "example": 820
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "month": {
                     "type": "string"
                 },
+                "payments": {
+                    "type": "integer",
+                    "example": 40
+                }
+            }
+        },
+        "model.Refund": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "dlocal_id": {
+                    "type": "string",
+                    "example": "REF-15-1a2b3c"
+                },
+                "error": {
+                    "description": "dlocal's answer when the refund failed",
+                    "type": "string",
+                    "example": "dlocal payment not approved (402 REJECTED): Insufficient funds"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
-                "last_4": {
+                "payment_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
                     "type": "string",
-                    "maxLength": 4,
-                    "minLength": 4,
-                    "example": "1234"
+                    "example": "customer request"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "requested",
+                        "success",
+                        "pending",
+                        "failed"
+                    ],
+                    "example": "success"
+                }
+            }
+        },
+        "model.RefundRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 50
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "customer request"
+                }
+            }
+        },
+        "model.ResolveRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "example": "fee adjustment confirmed by dlocal"
+                }
+            }
+        },
+        "model.Revenue": {
+            "type": "object",
+            "properties": {
+                "chargebacks": {
+                    "type": "number",
+                    "example": 0
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "gross": {
+                    "type": "number",
+                    "example": 4200
+                },
+                "net": {
+                    "type": "number",
+                    "example": 4100
+                },
+                "payments": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "period": {
+                    "type": "string"
+                },
+                "refunds": {
+                    "type": "number",
+                    "example": 100
+                },
+                "tax": {
+                    "type": "number",
+                    "example": 757.38
+                }
+            }
+        },
+        "model.ReviewRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "example": "payer confirmed by phone"
+                }
+            }
+        },
+        "model.SettlementDiscrepancy": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "dlocal_id": {
+                    "type": "string",
+                    "example": "D-4-cf2d3e7a"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "missing",
+                        "extra",
+                        "amount_mismatch"
+                    ],
+                    "example": "amount_mismatch"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "order_number": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "recorded": {
+                    "type": "number",
+                    "example": 100
+                },
+                "report_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "resolved": {
+                    "type": "boolean"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "settled": {
+                    "type": "number",
+                    "example": 99.5
+                }
+            }
+        },
+        "model.SettlementReport": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "discrepancies": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "file_name": {
+                    "type": "string",
+                    "example": "settlement_2023-02-20.csv"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "matched": {
+                    "type": "integer",
+                    "example": 115
+                },
+                "period_from": {
+                    "type": "string"
+                },
+                "period_to": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "skipped": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.Subscription": {
+            "type": "object",
+            "properties": {
+                "cancel_at_period_end": {
+                    "type": "boolean"
+                },
+                "canceled_at": {
+                    "type": "string"
+                },
+                "card_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "current_period_end": {
+                    "type": "string"
+                },
+                "current_period_start": {
+                    "type": "string"
+                },
+                "failed_attempts": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Order"
+                    }
+                },
+                "paused_at": {
+                    "type": "string"
                 },
                 "payer_id": {
                     "type": "integer",
-                    "minimum": 1,
                     "example": 1
-                }
-            }
-        },
-        "model.CardResponse": {
-            "type": "object",
-            "properties": {
-                "brand": {
-                    "type": "string",
-                    "example": "Visa"
                 },
-                "created_at": {
-                    "type": "string"
+                "plan": {
+                    "$ref": "#/definitions/model.Plan"
                 },
-                "id": {
+                "plan_id": {
                     "type": "integer",
                     "example": 1
                 },
-                "last_4": {
+                "retry_at": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string",
-                    "example": "1234"
+                    "example": "active"
                 },
-                "token": {
+                "trial_end": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "model.OrderRequest": {
+        "model.SubscriptionRequest": {
             "type": "object",
             "properties": {
-                "currency": {
-                    "type": "string",
-                    "maxLength": 3,
-                    "minLength": 3
-                },
-                "product_id": {
+                "card_id": {
                     "type": "integer",
                     "example": 1
                 },
-                "total_fees": {
+                "plan_id": {
                     "type": "integer",
-                    "example": 3
+                    "example": 1
                 }
             }
         },
-        "model.OrderResponse": {
+        "model.Token": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "token": {
                     "type": "string"
-                },
-                "finished": {
-                    "type": "boolean"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "payments": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/model.PaymentResponse"
-                    }
-                },
-                "product": {
-                    "$ref": "#/definitions/model.Product"
-                },
-                "total_fees": {
-                    "type": "integer"
                 }
             }
         },
-        "model.Payer": {
+        "model.WebhookAttempt": {
             "type": "object",
             "properties": {
-                "address": {
-                    "$ref": "#/definitions/model.Address"
-                },
-                "birth_date": {
-                    "type": "string",
-                    "example": "24/07/1992"
-                },
-                "card_id": {
-                    "type": "integer"
-                },
-                "country": {
-                    "type": "string",
-                    "maxLength": 2,
-                    "minLength": 2,
-                    "example": "UY"
-                },
                 "created_at": {
                     "type": "string"
                 },
-                "document": {
-                    "type": "string",
-                    "example": "23415162"
+                "delivery_id": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "email": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 8,
-                    "example": "jhondoe@mail.com"
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 85
+                },
+                "error": {
+                    "type": "string"
                 },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
-                "name": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 3,
-                    "example": "Jhon Doe"
-                },
-                "phone": {
-                    "type": "string",
-                    "example": "+123456789"
-                },
-                "updated_at": {
+                "response": {
                     "type": "string"
                 },
-                "user_reference": {
-                    "type": "string"
+                "status_code": {
+                    "type": "integer",
+                    "example": 200
                 }
             }
         },
-        "model.PayerResponse": {
+        "model.WebhookDelivery": {
             "type": "object",
             "properties": {
-                "address": {
-                    "$ref": "#/definitions/model.Address"
-                },
-                "birth_date": {
-                    "type": "string",
-                    "example": "24/07/1992"
-                },
-                "card_id": {
+                "attempts": {
                     "type": "integer",
                     "example": 1
                 },
                 "created_at": {
                     "type": "string"
                 },
-                "document": {
+                "delivered_at": {
                     "type": "string"
                 },
-                "email": {
-                    "type": "string",
-                    "example": "jhondoe@mail.com"
+                "endpoint_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "event_id": {
+                    "type": "integer",
+                    "example": 1
                 },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
-                "name": {
-                    "type": "string",
-                    "example": "Jhon Doe"
+                "last_error": {
+                    "type": "string"
                 },
-                "phone": {
-                    "type": "string",
-                    "example": "+123456789"
+                "last_status_code": {
+                    "type": "integer",
+                    "example": 200
                 },
-                "updated_at": {
+                "log": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WebhookAttempt"
+                    }
+                },
+                "next_attempt_at": {
                     "type": "string"
                 },
-                "user_reference": {
+                "status": {
                     "type": "string",
-                    "example": "12345"
+                    "example": "succeeded"
                 }
             }
         },
-        "model.PaymentResponse": {
+        "model.WebhookEndpoint": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "number",
-                    "example": 125
-                },
-                "card": {
-                    "$ref": "#/definitions/model.Card"
-                },
-                "country": {
-                    "type": "string",
-                    "example": "UY"
-                },
                 "created_at": {
                     "type": "string"
                 },
-                "currency": {
-                    "type": "string",
-                    "example": "USD"
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "payment.succeeded",
+                        "order.finished"
+                    ]
                 },
                 "id": {
-                    "type": "string",
-                    "example": "PAY2323243343543"
+                    "type": "integer",
+                    "example": 1
                 },
-                "order_number": {
-                    "type": "string"
+                "merchant_id": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "payment_method_flow": {
-                    "type": "string"
+                "secret": {
+                    "type": "string",
+                    "example": "whsec_2f6c..."
                 },
-                "payment_method_id": {
+                "url": {
                     "type": "string",
-                    "example": "CARD"
+                    "maxLength": 500,
+                    "example": "https://shop.example.com/webhooks/payments"
                 }
             }
         },
-        "model.Product": {
+        "model.WebhookEndpointRequest": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "number",
-                    "example": 5000
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "payment.succeeded",
+                        "order.finished"
+                    ]
                 },
-                "currency": {
-                    "type": "string",
-                    "maxLength": 3,
-                    "minLength": 3,
-                    "example": "USD"
+                "merchant_id": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "description": {
+                "url": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 6,
-                    "example": "Curso de Programacion"
+                    "example": "https://shop.example.com/webhooks/payments"
+                }
+            }
+        },
+        "model.WebhookEvent": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": true
                 },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
-                "name": {
+                "merchant_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "type": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 6,
-                    "example": "programacion en C"
+                    "example": "payment.succeeded"
                 }
             }
         },
-        "model.ProductRequest": {
+        "pagination.Page": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "number",
-                    "example": 5000
-                },
-                "currency": {
-                    "type": "string",
-                    "maxLength": 3,
-                    "minLength": 3,
-                    "example": "USD"
-                },
-                "description": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 6,
-                    "example": "Curso de Programacion"
+                "data": {},
+                "has_more": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "name": {
+                "next_cursor": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 6,
-                    "example": "programacion en C"
+                    "example": "eyJzIjoiaWQiLCJ2IjoiMzAiLCJpZCI6MzB9"
                 }
             }
         },
-        "model.ProductResponse": {
+        "tax.Line": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "number",
-                    "example": 5000
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "currency": {
-                    "type": "string",
-                    "maxLength": 3,
-                    "minLength": 3,
-                    "example": "USD"
+                    "example": 18.03
                 },
-                "description": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 6,
-                    "example": "Curso de Programacion"
-                },
-                "id": {
-                    "type": "integer",
-                    "example": 1
+                "base": {
+                    "type": "number",
+                    "example": 81.97
                 },
                 "name": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 6,
-                    "example": "programacion en C"
+                    "example": "IVA"
                 },
-                "updated_at": {
-                    "type": "string"
-                }
-            }
-        },
-        "model.Token": {
-            "type": "object",
-            "properties": {
-                "token": {
-                    "type": "string"
+                "rate": {
+                    "type": "number",
+                    "example": 0.22
                 }
             }
         }
@@ -0,0 +1,102 @@
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Prefix of encrypted values: enc:v1:<key id>:<wrapped data key>:<ciphertext>
+const prefix = "enc:v1:"
+
+var provider KeyProvider
+var indexKey []byte
+
+// Configure sets the key provider used for PII columns and the blind index key.
+// Until it is called values are stored in plaintext.
+func Configure(p KeyProvider, index []byte) {
+	provider = p
+	indexKey = index
+}
+
+// Enabled reports whether a key provider is configured
+func Enabled() bool {
+	return provider != nil
+}
+
+// Encrypt seals plaintext with a fresh data key wrapped by the active key
+func Encrypt(plaintext string) (string, error) {
+	if provider == nil {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	keyID := provider.ActiveKeyID()
+	wrapped, err := provider.WrapKey(keyID, dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + keyID + ":" + base64.StdEncoding.EncodeToString(wrapped) + ":" +
+		base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Values without the prefix are
+// returned as they are (rows written before encryption was enabled).
+func Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	if provider == nil {
+		return "", errors.New("encrypted value found but no key provider configured")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed encrypted value")
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := provider.UnwrapKey(parts[0], wrapped)
+	if err != nil {
+		return "", fmt.Errorf("unwrap data key: %w", err)
+	}
+	plaintext, err := open(dataKey, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a deterministic HMAC of the normalized value, used to
+// look up encrypted columns by equality. Empty without an index key: values
+// are stored in plaintext then, and an index made with no key would be of
+// no use once one is configured.
+func BlindIndex(value string) string {
+	if len(indexKey) == 0 {
+		return ""
+	}
+	h := hmac.New(sha256.New, indexKey)
+	h.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(h.Sum(nil))
+}
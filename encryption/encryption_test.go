@@ -0,0 +1,166 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+func writeKeyFile(t *testing.T, kf KeyFile) string {
+	t.Helper()
+	raw, err := json.Marshal(kf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err = os.WriteFile(path, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func load(t *testing.T, kf KeyFile) *KeyFile {
+	t.Helper()
+	loaded, err := LoadKeyFile(writeKeyFile(t, kf))
+	if err != nil {
+		t.Fatalf("LoadKeyFile() = %v", err)
+	}
+	return loaded
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	tests := []struct {
+		name string
+		kf   KeyFile
+		err  string
+	}{
+		{"valid", KeyFile{ActiveKey: "2023-01", Keys: map[string]string{"2023-01": key('a')}, IndexKey: key('i')}, ""},
+		{"active key missing", KeyFile{ActiveKey: "2023-02", Keys: map[string]string{"2023-01": key('a')}, IndexKey: key('i')}, `active key "2023-02" not found in key file`},
+		{"short key", KeyFile{ActiveKey: "2023-01", Keys: map[string]string{"2023-01": "c2hvcnQ="}, IndexKey: key('i')}, "key 2023-01: key must be 32 bytes"},
+		{"key id with a colon", KeyFile{ActiveKey: "a:b", Keys: map[string]string{"a:b": key('a')}, IndexKey: key('i')}, `invalid key id "a:b"`},
+		{"index key missing", KeyFile{ActiveKey: "2023-01", Keys: map[string]string{"2023-01": key('a')}}, "index key: key must be 32 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadKeyFile(writeKeyFile(t, tt.kf))
+			if tt.err == "" && err != nil {
+				t.Fatalf("LoadKeyFile() = %v", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Fatalf("LoadKeyFile() = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	kf := load(t, KeyFile{ActiveKey: "2023-01", Keys: map[string]string{"2023-01": key('a')}, IndexKey: key('i')})
+	Configure(kf, kf.IndexKeyBytes())
+	defer Configure(nil, nil)
+
+	for _, plaintext := range []string{"jhondoe@mail.com", "", "Av. 18 de Julio 1234, Montevideo"} {
+		sealed, err := Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q) = %v", plaintext, err)
+		}
+		if !strings.HasPrefix(sealed, prefix+"2023-01:") {
+			t.Errorf("Encrypt(%q) = %q, want it sealed with the active key", plaintext, sealed)
+		}
+		again, _ := Encrypt(plaintext)
+		if again == sealed {
+			t.Errorf("Encrypt(%q) twice gave the same value", plaintext)
+		}
+		if got, err := Decrypt(sealed); err != nil || got != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q, %v", plaintext, got, err)
+		}
+	}
+
+	// rows written before encryption was enabled
+	if got, err := Decrypt("jhondoe@mail.com"); err != nil || got != "jhondoe@mail.com" {
+		t.Errorf("Decrypt(plaintext) = %q, %v", got, err)
+	}
+	for _, value := range []string{prefix + "2023-01:abc", prefix + "2023-01:!!:!!", prefix + "2023-09:" + key('x') + ":" + key('y')} {
+		if _, err := Decrypt(value); err == nil {
+			t.Errorf("Decrypt(%q), want an error", value)
+		}
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := load(t, KeyFile{ActiveKey: "2023-01", Keys: map[string]string{"2023-01": key('a')}, IndexKey: key('i')})
+	Configure(old, old.IndexKeyBytes())
+	defer Configure(nil, nil)
+	sealed, err := Encrypt("23415162")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := BlindIndex("23415162")
+
+	rotated := load(t, KeyFile{ActiveKey: "2023-02", Keys: map[string]string{"2023-01": key('a'), "2023-02": key('b')}, IndexKey: key('i')})
+	Configure(rotated, rotated.IndexKeyBytes())
+	if got, err := Decrypt(sealed); err != nil || got != "23415162" {
+		t.Errorf("Decrypt() with the old key still in the file = %q, %v", got, err)
+	}
+	resealed, err := Encrypt("23415162")
+	if err != nil || !strings.HasPrefix(resealed, prefix+"2023-02:") {
+		t.Errorf("Encrypt() = %q, %v, want it sealed with the new key", resealed, err)
+	}
+	if BlindIndex("23415162") != index {
+		t.Error("BlindIndex() changed with the rotation")
+	}
+
+	removed := load(t, KeyFile{ActiveKey: "2023-02", Keys: map[string]string{"2023-02": key('b')}, IndexKey: key('i')})
+	Configure(removed, removed.IndexKeyBytes())
+	if _, err := Decrypt(sealed); err == nil {
+		t.Error("Decrypt() with the key removed, want an error")
+	}
+}
+
+func TestPlaintextWithoutProvider(t *testing.T) {
+	Configure(nil, nil)
+	if got, err := Encrypt("jhondoe@mail.com"); err != nil || got != "jhondoe@mail.com" {
+		t.Errorf("Encrypt() = %q, %v, want the plaintext", got, err)
+	}
+	if _, err := Decrypt(prefix + "2023-01:" + key('a') + ":" + key('b')); err == nil {
+		t.Error("Decrypt() of an encrypted value without a provider, want an error")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	Configure(nil, nil)
+	if got := BlindIndex("jhondoe@mail.com"); got != "" {
+		t.Errorf("BlindIndex() without an index key = %q, want empty", got)
+	}
+
+	kf := load(t, KeyFile{ActiveKey: "2023-01", Keys: map[string]string{"2023-01": key('a')}, IndexKey: key('i')})
+	Configure(kf, kf.IndexKeyBytes())
+	defer Configure(nil, nil)
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"jhondoe@mail.com", " JhonDoe@Mail.com ", true},
+		{"jhondoe@mail.com", "janedoe@mail.com", false},
+		{"23415162", "2341516", false},
+	}
+	for _, tt := range tests {
+		a, b := BlindIndex(tt.a), BlindIndex(tt.b)
+		if len(a) != 64 || (a == b) != tt.same {
+			t.Errorf("BlindIndex(%q) = %s, BlindIndex(%q) = %s, same %v", tt.a, a, tt.b, b, tt.same)
+		}
+	}
+
+	other := load(t, KeyFile{ActiveKey: "2023-01", Keys: map[string]string{"2023-01": key('a')}, IndexKey: key('j')})
+	index := BlindIndex("jhondoe@mail.com")
+	Configure(other, other.IndexKeyBytes())
+	if BlindIndex("jhondoe@mail.com") == index {
+		t.Error("BlindIndex() is the same with another index key")
+	}
+}
@@ -0,0 +1,141 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeyProvider wraps and unwraps data keys with a key encryption key.
+//
+// Local key files implement it, and so can a KMS client (Encrypt/Decrypt by key id).
+type KeyProvider interface {
+	// ActiveKeyID is the key used to wrap new data keys
+	ActiveKeyID() string
+	WrapKey(keyID string, dataKey []byte) ([]byte, error)
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Local key file
+//
+//	{
+//	  "active_key": "2023-02",
+//	  "keys": {"2023-01": "<base64 32 bytes>", "2023-02": "<base64 32 bytes>"},
+//	  "index_key": "<base64 32 bytes>"
+//	}
+//
+// To rotate, add a new key, point active_key to it and re-encrypt the payers.
+// The index key must not change, blind indexes depend on it.
+type KeyFile struct {
+	ActiveKey string            `json:"active_key"`
+	Keys      map[string]string `json:"keys"`
+	IndexKey  string            `json:"index_key"`
+
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+// LoadKeyFile reads and validates a local key file
+func LoadKeyFile(path string) (*KeyFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var kf KeyFile
+	if err = json.Unmarshal(raw, &kf); err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+	}
+
+	kf.keys = make(map[string][]byte, len(kf.Keys))
+	for id, encoded := range kf.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		if kf.keys[id], err = decodeKey(encoded); err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+	}
+	if _, ok := kf.keys[kf.ActiveKey]; !ok {
+		return nil, fmt.Errorf("active key %q not found in key file", kf.ActiveKey)
+	}
+	if kf.indexKey, err = decodeKey(kf.IndexKey); err != nil {
+		return nil, fmt.Errorf("index key: %w", err)
+	}
+	return &kf, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, errors.New("key must be 32 bytes")
+	}
+	return key, nil
+}
+
+func (kf *KeyFile) ActiveKeyID() string {
+	return kf.ActiveKey
+}
+
+// IndexKeyBytes returns the key used for blind indexes
+func (kf *KeyFile) IndexKeyBytes() []byte {
+	return kf.indexKey
+}
+
+func (kf *KeyFile) WrapKey(keyID string, dataKey []byte) ([]byte, error) {
+	kek, ok := kf.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	return seal(kek, dataKey)
+}
+
+func (kf *KeyFile) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := kf.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	return open(kek, wrapped)
+}
+
+// AES-256-GCM, nonce is prepended to the ciphertext
+func seal(key []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key []byte, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
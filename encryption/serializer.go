@@ -0,0 +1,62 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// Serializer encrypts *string and string fields tagged `gorm:"serializer:encrypted"`
+type Serializer struct{}
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
+
+// Scan implements serializer interface
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType).Elem()
+	if dbValue == nil {
+		field.ReflectValueOf(ctx, dst).Set(fieldValue)
+		return nil
+	}
+
+	var value string
+	switch v := dbValue.(type) {
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("failed to decrypt value: %#v", dbValue)
+	}
+
+	plaintext, err := Decrypt(value)
+	if err != nil {
+		return err
+	}
+	if field.FieldType.Kind() == reflect.Ptr {
+		fieldValue.Set(reflect.ValueOf(&plaintext))
+	} else {
+		fieldValue.SetString(plaintext)
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+// Value implements serializer interface
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	switch v := fieldValue.(type) {
+	case *string:
+		if v == nil {
+			return nil, nil
+		}
+		return Encrypt(*v)
+	case string:
+		return Encrypt(v)
+	default:
+		return nil, fmt.Errorf("invalid field type %#v for encrypted serializer", fieldValue)
+	}
+}
@@ -0,0 +1,38 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSV(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(cells ...interface{}) error {
+	record := make([]string, len(cells))
+	for i, value := range cells {
+		text, number := cell(value)
+		// spreadsheets would run text starting like a formula
+		if !number && text != "" && strings.ContainsAny(text[:1], "=+-@") {
+			text = "'" + text
+		}
+		record[i] = text
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	// keep the response streaming instead of buffering the whole file
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Export formats
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// Writer - writes a table one row at a time, nothing is kept in memory
+//
+// Cells can be strings, numbers, bools and times (or pointers to them,
+// nil is an empty cell).
+type Writer interface {
+	Write(cells ...interface{}) error
+	Close() error
+}
+
+// New - Writer of the format, sheet names the XLSX worksheet
+func New(format string, w io.Writer, sheet string) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSV(w), nil
+	case XLSX:
+		return newXLSX(w, sheet), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q, use csv or xlsx", format)
+}
+
+// ContentType - MIME type of the format
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Text of a cell and whether it's a number
+func cell(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, false
+	case *string:
+		if v == nil {
+			return "", false
+		}
+		return *v, false
+	case int:
+		return strconv.Itoa(v), true
+	case *int:
+		if v == nil {
+			return "", false
+		}
+		return strconv.Itoa(*v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), false
+	case time.Time:
+		if v.IsZero() {
+			return "", false
+		}
+		return v.UTC().Format("2006-01-02 15:04:05"), false
+	case *time.Time:
+		if v == nil {
+			return "", false
+		}
+		return cell(*v)
+	}
+	return fmt.Sprint(value), false
+}
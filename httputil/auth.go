@@ -0,0 +1,23 @@
+package httputil
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireToken - Answers 401 to requests without Authorization: Bearer
+// token. An empty token refuses every request
+func RequireToken(token string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		given := strings.TrimPrefix(header, "Bearer ")
+		if given == header || token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			Problem(ctx, http.StatusUnauthorized, "Missing or invalid admin token", nil)
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -1,47 +1,96 @@
 package httputil
 
-import "github.com/gin-gonic/gin"
-
-// Error400 example
-func Error400(ctx *gin.Context, status int, message string, err error) {
-	er := HTTPError400{
-		Code:    status,
-		Message: message,
-		Error:   err.Error(),
-	}
-	ctx.JSON(status, er)
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"systempayment/apperror"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// ProblemContentType - RFC 7807 media type of error responses
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails - RFC 7807 error response
+type ProblemDetails struct {
+	Type     string                `json:"type" example:"urn:problem:not_found"`
+	Title    string                `json:"title" example:"Not Found"`
+	Status   int                   `json:"status" example:"404"`
+	Detail   string                `json:"detail" example:"Order not found"`
+	Instance string                `json:"instance" example:"/api/v1/order/1"`
+	Code     string                `json:"code" example:"not_found"`
+	Error    string                `json:"error,omitempty" example:"record not found"`
+	Errors   []apperror.FieldError `json:"errors,omitempty"`
 }
 
-// Error408 example
-func Error408(ctx *gin.Context, status int, message string, err error) {
-	er := HTTPError400{
-		Code:    status,
-		Message: message,
-		Error:   err.Error(),
+// Problem - Answers with the error as application/problem+json
+//
+// Errors the apperror package knows (typed, records not found, validation,
+// timeouts) are answered with their own status and code, any other with
+// status and the code of that status. The detail is message, or the typed
+// error's message when it's empty.
+func Problem(ctx *gin.Context, status int, message string, err error) {
+	problem := ProblemDetails{
+		Status:   status,
+		Code:     statusCode(status),
+		Detail:   message,
+		Instance: ctx.Request.URL.Path,
+	}
+	if err != nil {
+		problem.Error = err.Error()
 	}
-	ctx.JSON(status, er)
+	if appErr := apperror.From(err); appErr != nil {
+		problem.Status = appErr.Status
+		problem.Code = appErr.Code
+		problem.Errors = appErr.Fields
+		if message == "" {
+			problem.Detail = appErr.Message
+		}
+	}
+	if problem.Detail == "" {
+		problem.Detail = http.StatusText(problem.Status)
+	}
+	problem.Title = http.StatusText(problem.Status)
+	problem.Type = "urn:problem:" + problem.Code
+
+	ctx.Header("Content-Type", ProblemContentType)
+	ctx.AbortWithStatusJSON(problem.Status, problem)
 }
 
-// Error500 example
-func Error500(ctx *gin.Context, status int, message string, err error) {
-	er := HTTPError500{
-		Code:    status,
-		Message: message,
-		Error:   err.Error(),
+// Code of the errors that aren't typed, by status
+func statusCode(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return apperror.CodeUnauthorized
+	case http.StatusNotFound:
+		return apperror.CodeNotFound
+	case http.StatusConflict:
+		return apperror.CodeConflict
+	case http.StatusUnprocessableEntity:
+		return apperror.CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return apperror.CodeRateLimited
+	case http.StatusBadGateway:
+		return apperror.CodeDlocalError
+	case http.StatusGatewayTimeout:
+		return apperror.CodeDlocalTimeout
+	}
+	if status >= 500 {
+		return apperror.CodeInternal
 	}
-	ctx.JSON(status, er)
+	return apperror.CodeInvalidRequest
 }
 
-// HTTPError400 example Bad Request
-type HTTPError400 struct {
-	Code    int    `json:"code" example:"400"`
-	Message string `json:"message"`
-	Error   string `json:"error" example:"Invalid request payload or query params"`
+// NoRoute - Answers requests to unknown routes
+func NoRoute(ctx *gin.Context) {
+	Problem(ctx, http.StatusNotFound, "Route not found", fmt.Errorf("%s %s doesn't exist", ctx.Request.Method, ctx.Request.URL.Path))
 }
 
-// HTTPError500 example Server Error
-type HTTPError500 struct {
-	Code    int    `json:"code" example:"500"`
-	Message string `json:"message"`
-	Error   string `json:"error" example:"Internal Server Error"`
+// Recovered - Logs the panic of a handler and answers with a 500, for
+// gin.CustomRecovery. The panic isn't sent to the client.
+func Recovered(ctx *gin.Context, recovered interface{}) {
+	log.WithContext(ctx.Request.Context()).Error("Panic: ", recovered, "\n", string(debug.Stack()))
+	Problem(ctx, http.StatusInternalServerError, "", nil)
 }
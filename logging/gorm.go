@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SlowQuery - queries that take longer are logged as warnings
+const SlowQuery = 500 * time.Millisecond
+
+// GormLogger - gorm logger writing to logrus, with the request id of the
+// query's context
+type GormLogger struct{}
+
+func (l GormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	log.WithContext(ctx).Infof(msg, args...)
+}
+
+func (GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	log.WithContext(ctx).Warnf(msg, args...)
+}
+
+func (GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	log.WithContext(ctx).Errorf(msg, args...)
+}
+
+// Trace - Logs failed queries (but not records not found) and slow ones
+func (GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	if !failed && elapsed < SlowQuery {
+		return
+	}
+	sql, rows := fc()
+	entry := log.WithContext(ctx).WithFields(log.Fields{
+		"sql":        sql,
+		"rows":       rows,
+		"elapsed_ms": elapsed.Milliseconds(),
+	})
+	if failed {
+		entry.WithError(err).Error("Query failed")
+		return
+	}
+	entry.Warn("Slow query")
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"context"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// Setup - Logs as JSON with the request id of the entry's context, redacted
+//
+// level is a logrus level name, info when empty or unknown.
+func Setup(level string) {
+	log.SetFormatter(&formatter{json: log.JSONFormatter{TimestampFormat: "2006-01-02T15:04:05.000Z07:00"}})
+	parsed, err := log.ParseLevel(strings.TrimSpace(level))
+	if err != nil {
+		parsed = log.InfoLevel
+	}
+	log.SetLevel(parsed)
+}
+
+// WithRequestID - Context carrying the request id for the logs
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// RequestID - Request id of the context, empty if it has none
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Detach - Context with the request id and trace span of ctx, but not its
+// deadline or cancellation
+func Detach(ctx context.Context) context.Context {
+	detached := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+	return WithRequestID(detached, RequestID(ctx))
+}
+
+// formatter - JSON formatter that adds the request id and redacts the entry
+type formatter struct {
+	json log.JSONFormatter
+}
+
+func (f *formatter) Format(entry *log.Entry) ([]byte, error) {
+	data := make(log.Fields, len(entry.Data)+1)
+	for key, value := range entry.Data {
+		data[key] = redactField(key, value)
+	}
+	if id := RequestID(entry.Context); id != "" {
+		data["request_id"] = id
+	}
+	if span := trace.SpanContextFromContext(entry.Context); span.IsValid() {
+		data["trace_id"] = span.TraceID().String()
+		data["span_id"] = span.SpanID().String()
+	}
+
+	redacted := *entry
+	redacted.Data = data
+	redacted.Message = Redact(entry.Message)
+	return f.json.Format(&redacted)
+}
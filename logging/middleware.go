@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader - header with the id of the request, received or generated
+const RequestIDHeader = "X-Request-ID"
+
+// Ids received from clients that are used as is
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Requests - Gives every request an id, in its context and the response
+// headers, and logs it when it ends
+func Requests() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		ctx.Request = ctx.Request.WithContext(WithRequestID(ctx.Request.Context(), id))
+		ctx.Header(RequestIDHeader, id)
+
+		start := time.Now()
+		ctx.Next()
+
+		entry := log.WithContext(ctx.Request.Context()).WithFields(log.Fields{
+			"method":     ctx.Request.Method,
+			"path":       ctx.Request.URL.Path,
+			"query":      ctx.Request.URL.RawQuery,
+			"status":     ctx.Writer.Status(),
+			"latency_ms": time.Since(start).Milliseconds(),
+			"client_ip":  ctx.ClientIP(),
+		})
+		switch status := ctx.Writer.Status(); {
+		case status >= 500:
+			entry.Error("Request failed")
+		case status >= 400:
+			entry.Warn("Request rejected")
+		default:
+			entry.Info("Request")
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const mask = "***"
+
+// Fields whose value is masked whole
+var sensitiveKeys = []string{"email", "document", "token", "card", "password", "secret", "authorization", "signature", "phone"}
+
+var (
+	// user:password@ of connection strings
+	credentialsRe = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`)
+	// values of sensitive keys in JSON, query strings, headers and %v of maps and structs
+	keyValueRe = regexp.MustCompile(`(?i)((?:email|document|token|card_id|password|secret|authorization|signature|phone|x-trans-key|x-login)\\?["']?\s*[:=]\s*\\?["']?)([^"'\\\s,&}\])]+)`)
+	emailRe    = regexp.MustCompile(`[A-Za-z0-9._%+-]+@([A-Za-z0-9-]+\.[A-Za-z0-9.-]+)`)
+	// card numbers, the last 4 digits are kept
+	cardRe = regexp.MustCompile(`\b\d{9,15}(\d{4})\b`)
+)
+
+// Redact - Masks emails, documents, card numbers and tokens and secrets in s
+func Redact(s string) string {
+	s = credentialsRe.ReplaceAllString(s, "${1}"+mask+"@")
+	s = keyValueRe.ReplaceAllString(s, "${1}"+mask)
+	s = emailRe.ReplaceAllString(s, mask+"@${1}")
+	return cardRe.ReplaceAllString(s, mask+"${1}")
+}
+
+// Value of a log field, masked whole if the key is sensitive
+func redactField(key string, value interface{}) interface{} {
+	lower := strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(lower, sensitive) {
+			return mask
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return Redact(v)
+	case error:
+		return Redact(v.Error())
+	case fmt.Stringer:
+		return Redact(v.String())
+	}
+	return value
+}
@@ -1,11 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"systempayment/billing"
+	"systempayment/config"
 	"systempayment/controller"
 	"systempayment/database"
+	"systempayment/dlocal"
 	_ "systempayment/docs"
+	"systempayment/encryption"
+	"systempayment/httputil"
+	"systempayment/jobs"
+	"systempayment/logging"
+	"systempayment/metrics"
+	"systempayment/model"
+	"systempayment/notify"
+	"systempayment/ratelimit"
+	"systempayment/reconciliation"
+	"systempayment/risk"
+	"systempayment/tracing"
+	"systempayment/webhook"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -58,36 +80,100 @@ import (
 //	@scope.admin							Grants read and write access to administrative information
 
 func main() {
-	log.SetFormatter(&log.TextFormatter{
-		FullTimestamp:   true,
-		TimestampFormat: "2006-01-02 15:04:05",
-		ForceColors:     true,
-	})
+	// Settings from CONFIG_FILE and the environment, see config.Load
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		// the level may be one of the invalid settings
+		logging.Setup(config.Default().LogLevel)
+		log.Fatal(err)
+	}
+	logging.Setup(cfg.LogLevel)
+	dlocal.Configure(cfg.Dlocal)
+	risk.Configure(cfg.Risk)
 
-	var user string
-	var password string
-	var dbhost string
-	var dbname string
-	var port string
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	user = os.Getenv("POSTGRES_USER")
-	password = os.Getenv("POSTGRES_PASSWORD")
-	dbhost = os.Getenv("DATABASE_HOST")
-	dbname = os.Getenv("POSTGRES_DB")
-	port = os.Getenv("APPLICATION_PORT")
+	// Payer PII encryption keys
+	if cfg.PIIKeyFile != "" {
+		keys, err := encryption.LoadKeyFile(cfg.PIIKeyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		encryption.Configure(keys, keys.IndexKeyBytes())
+	} else {
+		log.Warn("PII_KEY_FILE not set, payer data will be stored in plaintext")
+	}
 
-	r := gin.Default()
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:3000"}
-	// config.AllowAllOrigins = true
+	// Notifications sender, SMTP or a file/log for local testing
+	var sender notify.Sender
+	if cfg.SMTP.Host != "" {
+		sender = notify.SMTPSender{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.User,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		}
+	} else {
+		sender = &notify.FileSender{Path: cfg.NotifyFile}
+		log.Warn("SMTP_HOST not set, notifications will be written to NOTIFY_FILE or the log")
+	}
 
-	r.Use(cors.New(config))
+	r := gin.New()
+	// gin trusts every proxy otherwise, X-Forwarded-For would pick the
+	// client IP of the rate limits
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal(err)
+	}
+	r.Use(tracing.Requests(), logging.Requests(), metrics.Requests(), gin.CustomRecoveryWithWriter(io.Discard, httputil.Recovered))
+	r.NoRoute(httputil.NoRoute)
+	corsConfig := cors.DefaultConfig()
+	for _, origin := range cfg.CORS.Origins {
+		if origin == "*" {
+			corsConfig.AllowAllOrigins = true
+		}
+	}
+	if !corsConfig.AllowAllOrigins {
+		corsConfig.AllowOrigins = cfg.CORS.Origins
+	}
+	// admin token
+	corsConfig.AddAllowHeaders("Authorization")
+
+	r.Use(cors.New(corsConfig))
 	// r.Use(CORSMiddleware())
 	// r.OPTIONS("/*path", CORSMiddleware())
 
-	database.DBInit(user, password, dbhost, dbname)
+	database.DBInit(cfg.Database)
+
+	// Exchange rates file loaded on startup
+	if ratesFile := cfg.FXRatesFile; ratesFile != "" {
+		file, err := os.Open(ratesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		total, _, err := model.QImportExchangeRates(database.DB, file)
+		file.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Info("Loaded ", total, " exchange rates from ", ratesFile)
+	}
+
+	c := controller.NewController(cfg)
 
-	c := controller.NewController()
+	// Card endpoints charge dlocal, limited against card testing
+	var limits ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == config.StorePostgres {
+		limits = ratelimit.PostgresStore{DB: database.DB}
+	}
+	cardLimit := ratelimit.Middleware(limits,
+		ratelimit.Rule{Name: "ip", Limit: cfg.RateLimit.PerIP.Requests, Window: cfg.RateLimit.PerIP.Window, Key: ratelimit.ByIP},
+		ratelimit.Rule{Name: "api_key", Limit: cfg.RateLimit.PerAPIKey.Requests, Window: cfg.RateLimit.PerAPIKey.Window, Key: ratelimit.ByAPIKey},
+		ratelimit.Rule{Name: "payer", Limit: cfg.RateLimit.PerPayer.Requests, Window: cfg.RateLimit.PerPayer.Window, Key: ratelimit.ByQueryID("payer_id")},
+	)
 
 	v1 := r.Group("/api/v1")
 	{
@@ -106,27 +192,224 @@ func main() {
 			product.GET("/products", c.Products)
 			product.GET(":id", c.GetProduct)
 			product.PUT(":id", c.UpdateProduct)
+			product.PUT(":id/activate", c.ActivateProduct)
+			product.PUT(":id/archive", c.ArchiveProduct)
+			product.GET(":id/prices", c.ProductPrices)
+			product.PUT(":id/prices", c.SetProductPrice)
+			product.DELETE(":id/prices/:currency", c.RemoveProductPrice)
 		}
 		order := v1.Group("/order")
 		{
 			order.POST("/new", c.NewOrder)
 			order.GET("/orders", c.Orders)
 			order.GET(":id", c.GetOrder)
+			order.GET(":id/balance", c.OrderBalance)
 		}
 		payment := v1.Group("/payment")
 		{
 			payment.POST("/new", c.NewPayment)
 			payment.GET("/payments", c.GetPayments)
+			payment.GET(":id/receipt", c.PaymentReceipt)
+			payment.POST(":id/refund", c.RefundPayment)
+			payment.GET(":id/refunds", c.PaymentRefunds)
 		}
 		card := v1.Group("/card")
 		{
-			card.POST("/save-card", c.SaveCard)
+			card.POST("/save-card", cardLimit, c.SaveCard)
 			card.GET(":id", c.GetCard)
 		}
+		plan := v1.Group("/plan")
+		{
+			plan.POST("/new", c.NewPlan)
+			plan.GET("/plans", c.Plans)
+			plan.GET(":id", c.GetPlan)
+			plan.PUT(":id/deactivate", c.DeactivatePlan)
+		}
+		subscription := v1.Group("/subscription")
+		{
+			subscription.POST("/new", c.NewSubscription)
+			subscription.GET("/subscriptions", c.Subscriptions)
+			subscription.GET(":id", c.GetSubscription)
+			subscription.PUT(":id/pause", c.PauseSubscription)
+			subscription.PUT(":id/resume", c.ResumeSubscription)
+			subscription.PUT(":id/cancel", c.CancelSubscription)
+		}
+		merchant := v1.Group("/merchant")
+		{
+			merchant.POST("/new", c.NewMerchant)
+			merchant.GET("/merchants", c.Merchants)
+			merchant.GET(":id", c.GetMerchant)
+			merchant.GET(":id/balance", c.MerchantBalance)
+		}
+		notification := v1.Group("/notification")
+		{
+			notification.GET("/notifications", c.Notifications)
+		}
+		coupon := v1.Group("/coupon")
+		{
+			coupon.POST("/new", c.NewCoupon)
+			coupon.GET("/coupons", c.Coupons)
+			coupon.GET(":id", c.GetCoupon)
+			coupon.PUT(":id/deactivate", c.DeactivateCoupon)
+		}
+		chargeback := v1.Group("/chargeback")
+		{
+			chargeback.POST("/new", c.NewChargeback)
+			chargeback.GET("/chargebacks", c.Chargebacks)
+			chargeback.GET(":id", c.GetChargeback)
+			chargeback.PUT(":id/status", c.UpdateChargebackStatus)
+			chargeback.POST(":id/evidence", c.UploadChargebackEvidence)
+			chargeback.GET(":id/evidence/:evidence_id", c.GetChargebackEvidence)
+		}
+		dlocal := v1.Group("/dlocal")
+		{
+			dlocal.POST("/notifications/chargebacks", c.DlocalChargebackNotification)
+		}
+	}
+
+	// Back office, not for payers
+	admin := r.Group("/api/v1/admin", httputil.RequireToken(cfg.AdminToken))
+	{
+		admin.GET("/payer/:id/export", c.ExportPayer)
+		admin.POST("/payer/:id/erase", c.ErasePayer)
+		admin.POST("/payer/reencrypt", c.ReencryptPayers)
+		admin.GET("/order/export", c.ExportOrders)
+		admin.GET("/payment/export", c.ExportPayments)
+		ledger := admin.Group("/ledger")
+		{
+			ledger.GET("/transactions", c.LedgerTransactions)
+		}
+		webhook := admin.Group("/webhook")
+		{
+			webhook.POST("/endpoints", c.NewWebhookEndpoint)
+			webhook.GET("/endpoints", c.WebhookEndpoints)
+			webhook.DELETE("/endpoints/:id", c.DeleteWebhookEndpoint)
+			webhook.GET("/events", c.WebhookEvents)
+			webhook.GET("/events/:id/deliveries", c.WebhookDeliveries)
+			webhook.POST("/events/:id/replay", c.ReplayWebhookEvent)
+		}
+		reconciliation := admin.Group("/reconciliation")
+		{
+			reconciliation.POST("/reports", c.ImportSettlementReport)
+			reconciliation.GET("/reports", c.SettlementReports)
+			reconciliation.GET("/reports/:id", c.GetSettlementReport)
+			reconciliation.GET("/discrepancies", c.SettlementDiscrepancies)
+			reconciliation.PUT("/discrepancies/:id/resolve", c.ResolveDiscrepancy)
+		}
+		reports := admin.Group("/reports")
+		{
+			reports.GET("/revenue", c.RevenueReport)
+			reports.GET("/mrr", c.MRRReport)
+			reports.GET("/orders", c.OrdersReport)
+			reports.GET("/aging", c.AgingReport)
+		}
+		fx := admin.Group("/fx")
+		{
+			fx.POST("/rates", c.NewExchangeRate)
+			fx.GET("/rates", c.ExchangeRates)
+			fx.POST("/rates/refresh", c.RefreshExchangeRate)
+			fx.POST("/rates/import", c.ImportExchangeRates)
+		}
+		risk := admin.Group("/risk")
+		{
+			risk.GET("/reviews", c.Reviews)
+			risk.GET("/reviews/:id", c.GetReview)
+			risk.PUT("/reviews/:id/approve", c.ApproveReview)
+			risk.PUT("/reviews/:id/deny", c.DenyReview)
+		}
 	}
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	r.Run(port)
+	r.GET("/metrics", metrics.Handler())
+	r.GET("/healthz", c.Healthz)
+	r.GET("/readyz", c.Readyz)
+
+	// Background jobs
+	scheduler := jobs.NewScheduler()
+	scheduler.Add("subscription renewals", 15*time.Minute, func(ctx context.Context) error {
+		renewed, err := billing.RenewDueSubscriptions(ctx, database.DB)
+		if renewed > 0 {
+			log.Info("Renewed ", renewed, " subscriptions")
+		}
+		return err
+	})
+	scheduler.Add("notifications", time.Minute, func(ctx context.Context) error {
+		sent, err := notify.Dispatch(ctx, database.DB, sender)
+		if sent > 0 {
+			log.Info("Sent ", sent, " notifications")
+		}
+		return err
+	})
+	scheduler.Add("pending refunds", 15*time.Minute, func(ctx context.Context) error {
+		settled, err := billing.SettlePendingRefunds(ctx, database.DB)
+		if settled > 0 {
+			log.Info("Settled ", settled, " refunds")
+		}
+		return err
+	})
+	webhookClient := webhook.NewClient()
+	scheduler.Add("webhooks", 30*time.Second, func(ctx context.Context) error {
+		_, err := webhook.Dispatch(ctx, database.DB, webhookClient)
+		return err
+	})
+	scheduler.Add("installment reminders", time.Hour, func(ctx context.Context) error {
+		_, err := notify.EnqueueReminders(database.DB)
+		return err
+	})
+	if cfg.RateLimit.Store == config.StorePostgres {
+		scheduler.Add("rate limits", time.Hour, func(ctx context.Context) error {
+			_, _, err := model.QDeleteExpiredRateLimits(database.DB.WithContext(ctx))
+			return err
+		})
+	}
+	// Settlement reports dropped in a directory (e.g. synced from dlocal's SFTP)
+	if settlementDir := cfg.SettlementDir; settlementDir != "" {
+		scheduler.Add("settlement reports", time.Hour, func(ctx context.Context) error {
+			_, err := reconciliation.ImportDir(ctx, database.DB, settlementDir)
+			return err
+		})
+	}
+	scheduler.Start(context.Background())
+
+	server := &http.Server{Addr: cfg.Port, Handler: r}
+	go func() {
+		log.Info("Listening on ", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// Graceful shutdown: readiness fails, DRAIN_DELAY later new connections
+	// are refused, and in-flight requests and running jobs get the rest of
+	// SHUTDOWN_TIMEOUT to finish
+	signals, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-signals.Done()
+
+	log.Info("Shutting down, waiting up to ", cfg.ShutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// readiness fails first, the load balancer stops routing requests here
+	// before the listener closes
+	c.Drain()
+	select {
+	case <-time.After(cfg.DrainDelay):
+	case <-ctx.Done():
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		log.Error("HTTP server shutdown - ", err)
+	}
+	if err := scheduler.Shutdown(ctx); err != nil {
+		log.Error("Background jobs shutdown - ", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("Tracing shutdown - ", err)
+	}
+	if sqlDB, err := database.DB.DB(); err == nil {
+		sqlDB.Close()
+	}
+	log.Info("Stopped")
 }
 
 // func CORSMiddleware() gin.HandlerFunc {
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "systempayment"
+
+// HTTP requests by gin route
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// dlocal API calls
+var (
+	dlocalRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dlocal_requests_total",
+		Help:      "dlocal API calls by endpoint, HTTP status (error when there was no answer) and dlocal status_code.",
+	}, []string{"endpoint", "status", "status_code"})
+	dlocalDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "dlocal_request_duration_seconds",
+		Help:      "dlocal API call latency by endpoint.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"endpoint"})
+)
+
+// Business counters
+var (
+	// Payments - charges by result: approved, rejected or failed (dlocal error or no answer)
+	Payments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_total",
+		Help:      "Payments charged with dlocal by result.",
+	}, []string{"result"})
+	// CardsSaved - cards saved with a token
+	CardsSaved = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cards_saved_total",
+		Help:      "Cards saved.",
+	})
+	// ScheduledCharges - subscription renewals charged by the scheduler, by
+	// result: renewed or failed
+	ScheduledCharges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduled_charges_total",
+		Help:      "Subscription renewals charged by the scheduler by result.",
+	}, []string{"result"})
+)
+
+// RateLimited - requests answered 429 by route and rule
+var RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "rate_limited_requests_total",
+	Help:      "Requests over a rate limit by route and rule.",
+}, []string{"route", "rule"})
+
+// Payment results
+const (
+	PaymentApproved = "approved"
+	PaymentRejected = "rejected"
+	PaymentFailed   = "failed"
+)
+
+// Scheduled charge results
+const (
+	ChargeRenewed = "renewed"
+	ChargeHeld    = "held"
+	ChargeFailed  = "failed"
+)
+
+// Handler - Serves the metrics in Prometheus' format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// Requests - Counts requests and their latency by gin route
+func Requests() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := ctx.Request.Method
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// DlocalCall - Records a dlocal call, status 0 when it didn't answer
+func DlocalCall(endpoint string, status int, statusCode string, elapsed time.Duration) {
+	label := "error"
+	if status != 0 {
+		label = strconv.Itoa(status)
+	}
+	dlocalRequests.WithLabelValues(endpoint, label, statusCode).Inc()
+	dlocalDuration.WithLabelValues(endpoint).Observe(elapsed.Seconds())
+}
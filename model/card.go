@@ -3,8 +3,6 @@ package model
 import (
 	"time"
 
-	log "github.com/sirupsen/logrus"
-	"gopkg.in/validator.v2"
 	"gorm.io/gorm"
 )
 
@@ -15,11 +13,13 @@ type Token struct {
 
 // Card example
 type Card struct {
-	ID        int            `json:"id" gorm:"primaryKey" example:"1"`
-	PayerID   int            `json:"payer_id" gorm:"column:payer_id" example:"1"  validate:"nonzero,min=1"`
-	CardId    *string        `json:"card_id" validate:"nonzero"`
-	Last4     *string        `json:"last_4" gorm:"column:last_4" example:"1234" validate:"nonzero,min=4,max=4"`
-	Brand     *string        `json:"brand" example:"Visa" validate:"nonzero"`
+	ID      int     `json:"id" gorm:"primaryKey" example:"1"`
+	PayerID int     `json:"payer_id" gorm:"column:payer_id" example:"1"  validate:"nonzero,min=1"`
+	CardId  *string `json:"card_id" validate:"nonzero"`
+	Last4   *string `json:"last_4" gorm:"column:last_4" example:"1234" validate:"nonzero,min=4,max=4"`
+	Brand   *string `json:"brand" example:"Visa" validate:"nonzero"`
+	// Issuing country, when dlocal reports it
+	Country   *string        `json:"country,omitempty" example:"UY"`
 	CreatedAt time.Time      `json:"created_at"`
 	DeletedAt gorm.DeletedAt `json:"-"`
 }
@@ -34,13 +34,56 @@ func (c *Card) SaveCardFromResponse(db *gorm.DB, response map[string]interface{}
 	cardId, _ := card["card_id"].(string)
 	last4, _ := card["last4"].(string)
 	brand, _ := card["brand"].(string)
+	if country, _ := card["country"].(string); country != "" {
+		c.Country = &country
+	}
 
 	c.CardId = &cardId
 	c.Last4 = &last4
 	c.Brand = &brand
 	c.CreatedAt = time.Now()
 
-	return c.QCreateCard(db)
+	code := 500
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if code, err = c.QCreateCard(tx); err != nil {
+			return err
+		}
+		code = 500
+		if err = QEnqueueNotification(tx, c.PayerID, NotifyCardSaved, map[string]interface{}{
+			"brand": brand,
+			"last4": last4,
+		}); err != nil {
+			return err
+		}
+		// without card_id, it's what charges the card
+		event := map[string]interface{}{
+			"id":         c.ID,
+			"payer_id":   c.PayerID,
+			"brand":      brand,
+			"last4":      last4,
+			"created_at": c.CreatedAt,
+		}
+		// payers aren't a merchant's, every merchant they ordered from gets
+		// it, endpoints without merchant once
+		merchantIDs, err := QPayerMerchantIDs(tx, c.PayerID)
+		if err != nil {
+			return err
+		}
+		if len(merchantIDs) == 0 {
+			return QEmitWebhookEvent(tx, nil, &c.PayerID, EventCardSaved, event)
+		}
+		for i := range merchantIDs {
+			if err := emitWebhookEvent(tx, &merchantIDs[i], &c.PayerID, EventCardSaved, event, i == 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return code, err
+	}
+	return 200, nil
 }
 
 // QCreateCard
@@ -48,14 +91,14 @@ func (c *Card) SaveCardFromResponse(db *gorm.DB, response map[string]interface{}
 // Inserts new Card
 func (c *Card) QCreateCard(db *gorm.DB) (int, error) {
 	var err error
-	if err = validator.Validate(c); err != nil {
-		log.Error("QCreateCard - ", err)
+	if err = validate(c); err != nil {
+		logger(db).Error("QCreateCard - ", err)
 		return 400, err
 	}
 	c.CreatedAt = time.Now()
 
 	if err = db.Create(c).Error; err != nil {
-		log.Error("QCreateCard - ", err)
+		logger(db).Error("QCreateCard - ", err)
 		return 500, err
 	}
 	return 200, nil
@@ -67,7 +110,7 @@ func (c *Card) QCreateCard(db *gorm.DB) (int, error) {
 func (c *Card) QGetCards(db *gorm.DB, payer_id int) ([]Card, int, error) {
 	var cards []Card
 	if err := db.Table("card").Where("payer_id=?", c.PayerID).Select("*").Scan(&cards).Error; err != nil {
-		log.Error("QGetCards - ", err)
+		logger(db).Error("QGetCards - ", err)
 		switch err {
 		case gorm.ErrRecordNotFound:
 			return cards, 200, err
@@ -84,7 +127,7 @@ func (c *Card) QGetCards(db *gorm.DB, payer_id int) ([]Card, int, error) {
 // Get one Card from Card.ID and Card.PayerID
 func (c *Card) QGetCard(db *gorm.DB) (int, error) {
 	if err := db.Where("id = ?", c.ID).First(&c).Error; err != nil {
-		log.Error("Get Card - " + err.Error())
+		logger(db).Error("Get Card - " + err.Error())
 		return 400, err
 	}
 	return 200, nil
@@ -0,0 +1,330 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"systempayment/apperror"
+	"systempayment/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Chargeback - payment disputed by the payer with their card issuer
+//
+// Opening one takes the amount from the merchant in the ledger and suspends
+// automatic charging of the payer's orders until every dispute is closed.
+type Chargeback struct {
+	ID            int                  `json:"id" gorm:"primaryKey" example:"1"`
+	PaymentID     int                  `json:"payment_id" gorm:"column:payment_id;index" example:"1"`
+	OrderID       int                  `json:"order_id" gorm:"column:order_id;index" example:"1"`
+	PayerID       int                  `json:"payer_id" gorm:"column:payer_id;index" example:"1"`
+	DlocalID      *string              `json:"dlocal_id,omitempty" gorm:"column:dlocal_id;uniqueIndex" example:"CHAR42-2023"`
+	ReasonCode    string               `json:"reason_code" example:"4837"`
+	Reason        string               `json:"reason" example:"No cardholder authorization"`
+	Amount        float64              `json:"amount" example:"100"`
+	Currency      *string              `json:"currency" example:"USD"`
+	Status        string               `json:"status" gorm:"index" example:"open" enums:"open,evidence_submitted,won,lost"`
+	EvidenceDueAt time.Time            `json:"evidence_due_at"`
+	ClosedAt      *time.Time           `json:"closed_at,omitempty"`
+	Note          string               `json:"note,omitempty"`
+	Evidence      []ChargebackEvidence `json:"evidence,omitempty" gorm:"foreignKey:ChargebackID"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// ChargebackEvidence - document uploaded to dispute a chargeback
+type ChargebackEvidence struct {
+	ID           int       `json:"id" gorm:"primaryKey" example:"1"`
+	ChargebackID int       `json:"chargeback_id" gorm:"column:chargeback_id;index" example:"1"`
+	FileName     string    `json:"file_name" example:"delivery-proof.pdf"`
+	ContentType  string    `json:"content_type" example:"application/pdf"`
+	Size         int       `json:"size" example:"48213"`
+	Description  string    `json:"description,omitempty" example:"Signed delivery receipt"`
+	Data         []byte    `json:"-" gorm:"type:bytea"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Chargeback status
+const (
+	ChargebackOpen              = "open"
+	ChargebackEvidenceSubmitted = "evidence_submitted"
+	ChargebackWon               = "won"
+	ChargebackLost              = "lost"
+)
+
+// ChargebackEvidenceDays - days to dispute when dlocal doesn't send a deadline
+const ChargebackEvidenceDays = 10
+
+// ChargebackMaxEvidenceSize - largest evidence document accepted, in bytes
+const ChargebackMaxEvidenceSize = 10 << 20
+
+// Status a chargeback can move to from each status
+var chargebackTransitions = map[string][]string{
+	ChargebackOpen:              {ChargebackEvidenceSubmitted, ChargebackWon, ChargebackLost},
+	ChargebackEvidenceSubmitted: {ChargebackWon, ChargebackLost},
+}
+
+func (Chargeback) TableName() string {
+	return "chargeback"
+}
+
+func (ChargebackEvidence) TableName() string {
+	return "chargeback_evidence"
+}
+
+// Open - true while the dispute isn't decided
+func (c *Chargeback) Open() bool {
+	return c.Status == ChargebackOpen || c.Status == ChargebackEvidenceSubmitted
+}
+
+// QCreateChargeback - Insert into chargeback for a payment
+//
+// Marks the payment as charged back, posts it to the ledger and suspends
+// the payer's automatic charges, all in the transaction of the caller.
+func (c *Chargeback) QCreateChargeback(db *gorm.DB) (int, error) {
+	var payment = Payment{ID: c.PaymentID}
+	if code, err := payment.QLockPayment(db); err != nil {
+		return code, err
+	}
+	if payment.Status == PaymentChargedBack {
+		return 400, apperror.Conflict("payment_charged_back", "payment already charged back")
+	}
+	// what was refunded, or is being refunded, isn't disputed again
+	unsettled, err := QUnsettledRefunds(db, payment.ID)
+	if err != nil {
+		return 500, err
+	}
+	disputable := math.Round((payment.Refundable()-unsettled)*100) / 100
+	if c.Amount == 0 {
+		c.Amount = disputable
+	}
+	c.Amount = math.Round(c.Amount*100) / 100
+	if c.Amount <= 0 || c.Amount > disputable {
+		return 400, apperror.Unprocessable("invalid_amount", "chargeback amount must be between 0 and %.2f", disputable)
+	}
+	if c.Currency != nil && !strings.EqualFold(*c.Currency, deref(payment.Currency)) {
+		return 400, errors.New("chargeback currency doesn't match the payment's")
+	}
+
+	var order Order
+	if err := db.Unscoped().Select("id", "payer_id").Where("id=?", payment.OrderID).First(&order).Error; err != nil {
+		logger(db).Error("QCreateChargeback - ", err)
+		return 500, err
+	}
+
+	now := time.Now()
+	c.OrderID = payment.OrderID
+	c.PayerID = order.PayerID
+	c.Currency = payment.Currency
+	c.Status = ChargebackOpen
+	if c.EvidenceDueAt.IsZero() {
+		c.EvidenceDueAt = now.AddDate(0, 0, ChargebackEvidenceDays)
+	}
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	if err := db.Create(c).Error; err != nil {
+		logger(db).Error("QCreateChargeback - ", err)
+		return 500, err
+	}
+
+	if err := payment.ChargedBack(db, true); err != nil {
+		return 500, err
+	}
+	if err := QPostChargeback(db, *c); err != nil {
+		return 500, err
+	}
+	if err := QSuspendAuto(db, c.PayerID); err != nil {
+		return 500, err
+	}
+	if err := QEmitOrderEvent(db, c.OrderID, EventChargebackOpened, c); err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QLockChargeback - Get chargeback by id (or dlocal id) and lock it until
+// the transaction ends
+func (c *Chargeback) QLockChargeback(tx *gorm.DB) (int, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	if c.ID != 0 {
+		query = query.Where("id=?", c.ID)
+	} else {
+		query = query.Where("dlocal_id=?", c.DlocalID)
+	}
+	if err := query.First(&c).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		logger(tx).Error("QLockChargeback - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QSetStatus - Moves the chargeback to a new status
+//
+// Closing it gives the payment its status back and, when won, the amount
+// back to the merchant. Automatic charges resume once the payer has no
+// open disputes left.
+func (c *Chargeback) QSetStatus(db *gorm.DB, status string, note string) (int, error) {
+	if status == c.Status {
+		return 200, nil
+	}
+	allowed := false
+	for _, next := range chargebackTransitions[c.Status] {
+		if next == status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return 400, apperror.Conflict("invalid_status_transition", "chargeback can't move from %s to %s", c.Status, status)
+	}
+
+	now := time.Now()
+	c.Status = status
+	c.UpdatedAt = now
+	if note != "" {
+		c.Note = note
+	}
+	if !c.Open() {
+		c.ClosedAt = &now
+	}
+	if err := db.Model(&c).Select("status", "note", "closed_at", "updated_at").Updates(c).Error; err != nil {
+		logger(db).Error("QSetStatus - ", err)
+		return 500, err
+	}
+	if c.Open() {
+		return 200, nil
+	}
+
+	if status == ChargebackWon {
+		var payment = Payment{ID: c.PaymentID}
+		if code, err := payment.QLockPayment(db); err != nil {
+			return code, err
+		}
+		if err := payment.ChargedBack(db, false); err != nil {
+			return 500, err
+		}
+		if err := QPostChargebackReversal(db, *c); err != nil {
+			return 500, err
+		}
+	}
+	if err := QResumeAuto(db, c.PayerID); err != nil {
+		return 500, err
+	}
+	if err := QEmitOrderEvent(db, c.OrderID, EventChargebackClosed, c); err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetChargebacks - Get a page of chargebacks (optional status and payment)
+func (c *Chargeback) QGetChargebacks(db *gorm.DB, page pagination.Params, status string, paymentID int) ([]Chargeback, int, error) {
+	var chargebacks []Chargeback
+	query := db.Model(&Chargeback{})
+	if status != "" {
+		query = query.Where("status=?", status)
+	}
+	if paymentID != 0 {
+		query = query.Where("payment_id=?", paymentID)
+	}
+	if err := page.Query(query).Find(&chargebacks).Error; err != nil {
+		logger(db).Error("QGetChargebacks - ", err)
+		return chargebacks, 500, err
+	}
+	return chargebacks, 200, nil
+}
+
+// QGetChargeback - Get chargeback by ID with its evidence (without the files)
+func (c *Chargeback) QGetChargeback(db *gorm.DB) (int, error) {
+	err := db.Preload("Evidence", func(db *gorm.DB) *gorm.DB {
+		return db.Omit("data").Order("id")
+	}).Where("id=?", c.ID).First(&c).Error
+	if err != nil {
+		logger(db).Error("QGetChargeback - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QAddEvidence - Attach a document to an open chargeback before its deadline
+func (c *Chargeback) QAddEvidence(db *gorm.DB, evidence *ChargebackEvidence) (int, error) {
+	if code, err := c.QGetChargeback(db); err != nil {
+		return code, err
+	}
+	if !c.Open() {
+		return 400, apperror.Conflict("chargeback_closed", "chargeback is closed")
+	}
+	if time.Now().After(c.EvidenceDueAt) {
+		return 400, apperror.Unprocessable("evidence_deadline_passed", "evidence deadline has passed")
+	}
+	if len(evidence.Data) == 0 || len(evidence.Data) > ChargebackMaxEvidenceSize {
+		return 400, fmt.Errorf("evidence must be between 1 byte and %d MB", ChargebackMaxEvidenceSize>>20)
+	}
+
+	evidence.ChargebackID = c.ID
+	evidence.Size = len(evidence.Data)
+	evidence.CreatedAt = time.Now()
+	if err := db.Create(evidence).Error; err != nil {
+		logger(db).Error("QAddEvidence - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetEvidence - Get an evidence document of the chargeback, with its file
+func (e *ChargebackEvidence) QGetEvidence(db *gorm.DB) (int, error) {
+	if err := db.Where("id=?", e.ID).Where("chargeback_id=?", e.ChargebackID).First(&e).Error; err != nil {
+		logger(db).Error("QGetEvidence - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QHasOpenChargeback - true if the payer has a dispute not decided yet
+func QHasOpenChargeback(db *gorm.DB, payerID int) (bool, error) {
+	var count int64
+	err := db.Model(&Chargeback{}).Where("payer_id=?", payerID).
+		Where("status IN ?", []string{ChargebackOpen, ChargebackEvidenceSubmitted}).Count(&count).Error
+	if err != nil {
+		logger(db).Error("QHasOpenChargeback - ", err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// QSuspendAuto - Stops automatic charging of the payer's unfinished orders
+func QSuspendAuto(db *gorm.DB, payerID int) error {
+	err := db.Model(&Order{}).Where("payer_id=?", payerID).Where("finished=?", false).
+		Where("auto=?", true).Updates(map[string]interface{}{"auto": false, "auto_suspended": true}).Error
+	if err != nil {
+		logger(db).Error("QSuspendAuto - ", err)
+	}
+	return err
+}
+
+// QResumeAuto - Resumes the orders QSuspendAuto stopped, if the payer has
+// no open chargebacks left
+func QResumeAuto(db *gorm.DB, payerID int) error {
+	open, err := QHasOpenChargeback(db, payerID)
+	if err != nil || open {
+		return err
+	}
+	err = db.Model(&Order{}).Where("payer_id=?", payerID).Where("finished=?", false).
+		Where("auto_suspended=?", true).Updates(map[string]interface{}{"auto": true, "auto_suspended": false}).Error
+	if err != nil {
+		logger(db).Error("QResumeAuto - ", err)
+	}
+	return err
+}
@@ -0,0 +1,258 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"systempayment/apperror"
+	"systempayment/pagination"
+
+	"gorm.io/gorm"
+)
+
+// Coupon - discount code applied at order creation
+//
+// Value is a percentage (0-100] or a fixed amount in Currency. Zero
+// MaxRedemptions/PerPayerLimit means unlimited, no ProductIDs means any product.
+type Coupon struct {
+	ID             int            `json:"id" gorm:"primaryKey" example:"1"`
+	Code           *string        `json:"code" gorm:"uniqueIndex" example:"VERANO10" validate:"nonzero,min=3,max=40"`
+	Type           string         `json:"type" example:"percentage" enums:"percentage,fixed" validate:"nonzero"`
+	Value          float64        `json:"value" example:"10" validate:"nonzero"`
+	Currency       *string        `json:"currency,omitempty" example:"USD"`
+	ExpiresAt      *time.Time     `json:"expires_at,omitempty"`
+	MaxRedemptions int            `json:"max_redemptions" example:"100" validate:"min=0"`
+	PerPayerLimit  int            `json:"per_payer_limit" example:"1" validate:"min=0"`
+	Redemptions    int            `json:"redemptions" example:"0"`
+	Products       []Product      `json:"-" gorm:"many2many:coupon_product"`
+	ProductIDs     []int          `json:"product_ids" gorm:"-" example:"1"`
+	Active         bool           `json:"active" gorm:"default:true"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-"`
+}
+
+// CouponRedemption - Coupon used on an Order
+type CouponRedemption struct {
+	ID        int       `json:"id" gorm:"primaryKey" example:"1"`
+	CouponID  int       `json:"coupon_id" gorm:"column:coupon_id;index" example:"1"`
+	PayerID   int       `json:"payer_id" gorm:"column:payer_id;index" example:"1"`
+	OrderID   int       `json:"order_id" gorm:"column:order_id" example:"1"`
+	Discount  float64   `json:"discount" example:"10"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Coupon types
+const (
+	CouponPercentage = "percentage"
+	CouponFixed      = "fixed"
+)
+
+func (Coupon) TableName() string {
+	return "coupon"
+}
+
+func (CouponRedemption) TableName() string {
+	return "coupon_redemption"
+}
+
+// QCreateCoupon - Insert into coupon
+//
+// Inserts new Coupon restricted to ProductIDs (if any)
+func (c *Coupon) QCreateCoupon(db *gorm.DB) (int, error) {
+	var err error
+	if err = validate(c); err != nil {
+		logger(db).Error("QCreateCoupon - ", err)
+		return 400, err
+	}
+	code := strings.ToUpper(strings.TrimSpace(*c.Code))
+	c.Code = &code
+
+	switch c.Type {
+	case CouponPercentage:
+		if c.Value <= 0 || c.Value > 100 {
+			return 400, errors.New("percentage must be between 0 and 100")
+		}
+		c.Currency = nil
+	case CouponFixed:
+		if c.Value <= 0 {
+			return 400, errors.New("amount must be greater than 0")
+		}
+		if c.Currency == nil || len(*c.Currency) != 3 {
+			return 400, errors.New("fixed amount coupons need a currency")
+		}
+		currency := strings.ToUpper(*c.Currency)
+		c.Currency = &currency
+	default:
+		return 400, errors.New("type must be percentage or fixed")
+	}
+
+	c.Products = nil
+	for _, id := range c.ProductIDs {
+		if exists, _ := ProductExists(db, id); !exists {
+			return 400, fmt.Errorf("product %d not found", id)
+		}
+		c.Products = append(c.Products, Product{ID: id})
+	}
+
+	c.Active = true
+	c.Redemptions = 0
+	c.CreatedAt = time.Now()
+	// only link the products, don't upsert them
+	if err = db.Omit("Products.*").Create(c).Error; err != nil {
+		logger(db).Error("QCreateCoupon - ", err)
+		return 400, err
+	}
+	return 200, nil
+}
+
+// QGetCoupon - Get Coupon by ID or by Code
+func (c *Coupon) QGetCoupon(db *gorm.DB) (int, error) {
+	query := db.Preload("Products", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id")
+	})
+	if c.ID != 0 {
+		query = query.Where("id=?", c.ID)
+	} else if c.Code != nil {
+		query = query.Where("code=?", strings.ToUpper(strings.TrimSpace(*c.Code)))
+	} else {
+		return 400, gorm.ErrRecordNotFound
+	}
+	if err := query.First(&c).Error; err != nil {
+		logger(db).Error("QGetCoupon - ", err)
+		return 400, err
+	}
+	c.setProductIDs()
+	return 200, nil
+}
+
+// QGetCoupons - Get all Coupons (optional only active)
+func (c *Coupon) QGetCoupons(db *gorm.DB, page pagination.Params, active bool) ([]Coupon, int, error) {
+	var coupons []Coupon
+	query := db.Model(&Coupon{}).Preload("Products", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id")
+	})
+	if active {
+		query = query.Where("active=?", true)
+	}
+	if err := page.Query(query).Find(&coupons).Error; err != nil {
+		logger(db).Error("QGetCoupons - ", err)
+		return coupons, 500, err
+	}
+	for i := range coupons {
+		coupons[i].setProductIDs()
+	}
+	return coupons, 200, nil
+}
+
+// QDeactivateCoupon - Coupon can't be redeemed anymore
+func (c *Coupon) QDeactivateCoupon(db *gorm.DB) (int, error) {
+	if code, err := c.QGetCoupon(db); err != nil {
+		return code, err
+	}
+	c.Active = false
+	c.UpdatedAt = time.Now()
+	if err := db.Model(&c).Select("active", "updated_at").Updates(c).Error; err != nil {
+		logger(db).Error("QDeactivateCoupon - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+func (c *Coupon) setProductIDs() {
+	c.ProductIDs = make([]int, 0, len(c.Products))
+	for _, p := range c.Products {
+		c.ProductIDs = append(c.ProductIDs, p.ID)
+	}
+}
+
+// Discount - validates the coupon for the order and returns the discount
+// on amount (in the order's currency)
+func (c *Coupon) Discount(db *gorm.DB, o *Order, amount float64) (float64, int, error) {
+	if !c.Active {
+		return 0, 400, apperror.Unprocessable("coupon_not_applicable", "coupon is not active")
+	}
+	if c.ExpiresAt != nil && c.ExpiresAt.Before(time.Now()) {
+		return 0, 400, apperror.Unprocessable("coupon_not_applicable", "coupon expired")
+	}
+	if c.MaxRedemptions > 0 && c.Redemptions >= c.MaxRedemptions {
+		return 0, 400, apperror.Conflict("coupon_redeemed", "coupon fully redeemed")
+	}
+	if len(c.ProductIDs) > 0 {
+		allowed := false
+		for _, id := range c.ProductIDs {
+			allowed = allowed || id == o.ProductID
+		}
+		if !allowed {
+			return 0, 400, apperror.Unprocessable("coupon_not_applicable", "coupon not valid for this product")
+		}
+	}
+	if code, err := c.checkPayerLimit(db, o.PayerID); err != nil {
+		return 0, code, err
+	}
+
+	var discount float64
+	switch c.Type {
+	case CouponPercentage:
+		discount = math.Round(amount*c.Value) / 100
+	case CouponFixed:
+		if !strings.EqualFold(*c.Currency, *o.Currency) {
+			return 0, 400, apperror.Unprocessable("coupon_not_applicable", "coupon only valid for %s orders", *c.Currency)
+		}
+		discount = math.Min(c.Value, amount)
+	}
+	return discount, 200, nil
+}
+
+// QRedeem - Counts the redemption, fails if the coupon ran out or the payer
+// used it up meanwhile. Must run in the same transaction that creates the order.
+func (c *Coupon) QRedeem(tx *gorm.DB, o *Order) (int, error) {
+	result := tx.Model(&Coupon{}).Where("id=?", c.ID).
+		Where("max_redemptions=0 OR redemptions<max_redemptions").
+		Update("redemptions", gorm.Expr("redemptions + 1"))
+	if result.Error != nil {
+		logger(tx).Error("QRedeem - ", result.Error)
+		return 500, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 400, apperror.Conflict("coupon_redeemed", "coupon fully redeemed")
+	}
+	// the update locks the coupon until commit, concurrent orders count
+	// the payer's redemptions one after the other
+	if code, err := c.checkPayerLimit(tx, o.PayerID); err != nil {
+		return code, err
+	}
+
+	redemption := CouponRedemption{
+		CouponID:  c.ID,
+		PayerID:   o.PayerID,
+		OrderID:   o.ID,
+		Discount:  o.Discount,
+		CreatedAt: time.Now(),
+	}
+	if err := tx.Create(&redemption).Error; err != nil {
+		logger(tx).Error("QRedeem - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// Fails when the payer already used the coupon PerPayerLimit times
+func (c *Coupon) checkPayerLimit(db *gorm.DB, payerID int) (int, error) {
+	if c.PerPayerLimit <= 0 {
+		return 200, nil
+	}
+	var used int64
+	if err := db.Model(&CouponRedemption{}).Where("coupon_id=?", c.ID).
+		Where("payer_id=?", payerID).Count(&used).Error; err != nil {
+		logger(db).Error("Coupon checkPayerLimit - ", err)
+		return 500, err
+	}
+	if int(used) >= c.PerPayerLimit {
+		return 400, apperror.Conflict("coupon_used", "coupon already used by this payer")
+	}
+	return 200, nil
+}
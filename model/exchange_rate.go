@@ -0,0 +1,196 @@
+package model
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"systempayment/apperror"
+	"systempayment/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Rates older than this are not used to convert orders
+var ExchangeRateMaxAge = 7 * 24 * time.Hour
+
+// ExchangeRate - 1 Base = Rate Quote, effective from Date
+type ExchangeRate struct {
+	ID        int       `json:"id" gorm:"primaryKey" example:"1"`
+	Base      *string   `json:"base" gorm:"uniqueIndex:idx_exchange_rate" example:"USD" validate:"nonzero,min=3,max=3"`
+	Quote     *string   `json:"quote" gorm:"uniqueIndex:idx_exchange_rate" example:"UYU" validate:"nonzero,min=3,max=3"`
+	Date      time.Time `json:"date" gorm:"uniqueIndex:idx_exchange_rate"`
+	Rate      float64   `json:"rate" example:"39.25" validate:"nonzero"`
+	Source    string    `json:"source" example:"manual"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Exchange rate sources
+const (
+	RateSourceManual = "manual"
+	RateSourceFile   = "file"
+	RateSourceDlocal = "dlocal"
+)
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rate"
+}
+
+// Convert amount from Base to Quote, rounded to cents
+func (r *ExchangeRate) Convert(amount float64) float64 {
+	return math.Round(amount*r.Rate*100) / 100
+}
+
+// rateDay - Rates are by UTC day, the time of the day is dropped
+func rateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// QCreateExchangeRate - Insert into exchange_rate
+//
+// Date is the UTC day, today by default. A rate for the same pair and date
+// replaces the previous one
+func (r *ExchangeRate) QCreateExchangeRate(db *gorm.DB) (int, error) {
+	var err error
+	if err = validate(r); err != nil || r.Rate <= 0 {
+		if err == nil {
+			err = errors.New("rate must be greater than 0")
+		}
+		logger(db).Error("QCreateExchangeRate - ", err)
+		return 400, err
+	}
+	base := strings.ToUpper(*r.Base)
+	quote := strings.ToUpper(*r.Quote)
+	if base == quote {
+		return 400, errors.New("base and quote currencies must differ")
+	}
+	r.Base, r.Quote = &base, &quote
+	if r.Date.IsZero() {
+		r.Date = time.Now()
+	}
+	r.Date = rateDay(r.Date)
+	if r.Source == "" {
+		r.Source = RateSourceManual
+	}
+	r.CreatedAt = time.Now()
+
+	if err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "created_at"}),
+	}).Create(r).Error; err != nil {
+		logger(db).Error("QCreateExchangeRate - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetExchangeRate - Get the latest Base -> Quote rate effective at `at`
+func (r *ExchangeRate) QGetExchangeRate(db *gorm.DB, at time.Time) (int, error) {
+	if r.Base == nil || r.Quote == nil {
+		return 400, errors.New("base and quote currencies are required")
+	}
+	if err := db.Where("base=?", strings.ToUpper(*r.Base)).Where("quote=?", strings.ToUpper(*r.Quote)).
+		Where("date<=?", at).Order("date desc").First(&r).Error; err != nil {
+		logger(db).Error("QGetExchangeRate - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	if at.Sub(r.Date) > ExchangeRateMaxAge {
+		return 400, apperror.Unprocessable("exchange_rate_unavailable", "latest %s/%s rate is from %s, too old to use", *r.Base, *r.Quote,
+			r.Date.Format("2006-01-02"))
+	}
+	return 200, nil
+}
+
+// QGetExchangeRates - Get a page of rates (optional base and quote)
+func (r *ExchangeRate) QGetExchangeRates(db *gorm.DB, page pagination.Params) ([]ExchangeRate, int, error) {
+	var rates []ExchangeRate
+	query := db.Model(&ExchangeRate{})
+	if r.Base != nil {
+		query = query.Where("base=?", strings.ToUpper(*r.Base))
+	}
+	if r.Quote != nil {
+		query = query.Where("quote=?", strings.ToUpper(*r.Quote))
+	}
+	if err := page.Query(query).Find(&rates).Error; err != nil {
+		logger(db).Error("QGetExchangeRates - ", err)
+		return rates, 500, err
+	}
+	return rates, 200, nil
+}
+
+// QImportExchangeRates - Load rates from CSV
+//
+//	date,base,quote,rate
+//	2023-02-20,USD,UYU,39.25
+//
+// Returns the number of rates stored
+func QImportExchangeRates(db *gorm.DB, r io.Reader) (int, int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = 4
+
+	var rates []ExchangeRate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, 400, err
+		}
+		if line == 1 && strings.EqualFold(record[0], "date") {
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", record[0])
+		if err != nil {
+			return 0, 400, fmt.Errorf("line %d: invalid date %q", line, record[0])
+		}
+		rate, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return 0, 400, fmt.Errorf("line %d: invalid rate %q", line, record[3])
+		}
+		base, quote := record[1], record[2]
+		rates = append(rates, ExchangeRate{Base: &base, Quote: &quote, Date: date, Rate: rate, Source: RateSourceFile})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range rates {
+			if _, err := rates[i].QCreateExchangeRate(tx); err != nil {
+				return fmt.Errorf("%s/%s %s: %w", *rates[i].Base, *rates[i].Quote,
+					rates[i].Date.Format("2006-01-02"), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger(db).Error("QImportExchangeRates - ", err)
+		return 0, 400, err
+	}
+	return len(rates), 200, nil
+}
+
+// Save rate from dlocal's currency exchange response
+func (r *ExchangeRate) SaveExchangeRateFromResponse(db *gorm.DB, response map[string]interface{}) (int, error) {
+	base, _ := response["from"].(string)
+	quote, _ := response["to"].(string)
+	rate, _ := response["rate"].(float64)
+
+	r.Base = &base
+	r.Quote = &quote
+	r.Rate = rate
+	r.Date = rateDay(time.Now())
+	r.Source = RateSourceDlocal
+
+	return r.QCreateExchangeRate(db)
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportFilter - filters of the payment and order exports, From and To
+// bound created_at (To excluded)
+type ExportFilter struct {
+	From      time.Time
+	To        time.Time
+	Currency  string
+	Country   string
+	Status    string
+	ProductID int
+}
+
+// Order status in exports
+const (
+	OrderActive   = "active"
+	OrderFinished = "finished"
+)
+
+// PaymentExport - payment with the product of its order
+type PaymentExport struct {
+	Payment
+	ProductID int
+}
+
+// QExportPayments - Calls fn with every payment of the filter, in id order,
+// reading them from the database as they're written
+func QExportPayments(db *gorm.DB, f ExportFilter, fn func(PaymentExport) error) error {
+	query := db.Model(&Payment{}).Select(`payment.*, "order".product_id`).
+		Joins(`JOIN "order" ON "order".id = payment.order_id`).
+		Where("payment.created_at >= ? AND payment.created_at < ?", f.From, f.To)
+	if f.Currency != "" {
+		query = query.Where("payment.currency=?", f.Currency)
+	}
+	if f.Country != "" {
+		query = query.Where("payment.country=?", f.Country)
+	}
+	if f.Status != "" {
+		query = query.Where("payment.status=?", f.Status)
+	}
+	if f.ProductID != 0 {
+		query = query.Where(`"order".product_id=?`, f.ProductID)
+	}
+
+	rows, err := query.Order("payment.id").Rows()
+	if err != nil {
+		logger(db).Error("QExportPayments - ", err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p PaymentExport
+		if err := db.ScanRows(rows, &p); err != nil {
+			logger(db).Error("QExportPayments - ", err)
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// QExportOrders - Calls fn with every order of the filter, in id order.
+// Country is the tax country, status active or finished.
+func QExportOrders(db *gorm.DB, f ExportFilter, fn func(Order) error) error {
+	query := db.Model(&Order{}).Where("created_at >= ? AND created_at < ?", f.From, f.To)
+	if f.Currency != "" {
+		query = query.Where("currency=?", f.Currency)
+	}
+	if f.Country != "" {
+		query = query.Where("tax_country=?", f.Country)
+	}
+	switch f.Status {
+	case OrderActive:
+		query = query.Where("finished=?", false)
+	case OrderFinished:
+		query = query.Where("finished=?", true)
+	}
+	if f.ProductID != 0 {
+		query = query.Where("product_id=?", f.ProductID)
+	}
+
+	rows, err := query.Order("id").Rows()
+	if err != nil {
+		logger(db).Error("QExportOrders - ", err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o Order
+		if err := db.ScanRows(rows, &o); err != nil {
+			logger(db).Error("QExportOrders - ", err)
+			return err
+		}
+		if err := fn(o); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
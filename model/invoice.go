@@ -0,0 +1,142 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Invoice - number given by the product's Merchant to a Payment
+//
+// Numbers are sequential per merchant, without gaps.
+type Invoice struct {
+	ID         int       `json:"id" gorm:"primaryKey" example:"1"`
+	MerchantID int       `json:"merchant_id" gorm:"column:merchant_id;uniqueIndex:idx_invoice_merchant_number" example:"1"`
+	Series     string    `json:"series" example:"A"`
+	Number     int       `json:"number" gorm:"uniqueIndex:idx_invoice_merchant_number" example:"1"`
+	PaymentID  int       `json:"payment_id" gorm:"column:payment_id;uniqueIndex" example:"1"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Invoice) TableName() string {
+	return "invoice"
+}
+
+// Code - printable invoice number, e.g. A-00000042
+func (i Invoice) Code() string {
+	if i.Series == "" {
+		return fmt.Sprintf("%08d", i.Number)
+	}
+	return fmt.Sprintf("%s-%08d", i.Series, i.Number)
+}
+
+// QIssueInvoice - Gives the payment the next number of its product's Merchant
+//
+// The merchant row stays locked until the transaction ends, so concurrent
+// payments can't get the same number.
+func (i *Invoice) QIssueInvoice(db *gorm.DB, payment Payment) (int, error) {
+	merchantID, err := QOrderMerchantID(db, payment.OrderID)
+	if err != nil {
+		return 500, err
+	}
+	if merchantID == 0 {
+		return 400, fmt.Errorf("order %d has no merchant", payment.OrderID)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var m Merchant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id=?", merchantID).First(&m).Error; err != nil {
+			return err
+		}
+		*i = Invoice{
+			MerchantID: m.ID,
+			Series:     m.InvoicePrefix,
+			Number:     m.NextInvoiceNumber,
+			PaymentID:  payment.ID,
+			CreatedAt:  time.Now(),
+		}
+		if err := tx.Create(i).Error; err != nil {
+			return err
+		}
+		return tx.Model(&m).Update("next_invoice_number", m.NextInvoiceNumber+1).Error
+	})
+	if err != nil {
+		logger(db).Error("QIssueInvoice - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetInvoice - Invoice of the payment, issued now for payments made
+// before invoices existed
+func (i *Invoice) QGetInvoice(db *gorm.DB, payment Payment) (int, error) {
+	err := db.Where("payment_id=?", payment.ID).First(&i).Error
+	if err == nil {
+		return 200, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger(db).Error("QGetInvoice - ", err)
+		return 500, err
+	}
+	return i.QIssueInvoice(db, payment)
+}
+
+// Receipt - everything printed on a payment's receipt
+type Receipt struct {
+	Invoice     Invoice
+	Merchant    Merchant
+	Payment     Payment
+	Order       Order
+	Payer       Payer
+	Card        Card
+	Installment int
+}
+
+// QGetReceipt - Loads the receipt of a payment
+func QGetReceipt(db *gorm.DB, paymentID int) (Receipt, int, error) {
+	var r Receipt
+	r.Payment.ID = paymentID
+	if code, err := r.Payment.QGetPayment(db); err != nil {
+		return r, code, err
+	}
+
+	// payers, cards and products may have been deleted since the payment
+	db = db.Unscoped()
+	r.Order.ID = r.Payment.OrderID
+	if err := db.Preload("Product").Where("id=?", r.Order.ID).First(&r.Order).Error; err != nil {
+		logger(db).Error("QGetReceipt - ", err)
+		return r, 500, err
+	}
+	if err := db.Preload("Address").Where("id=?", r.Order.PayerID).First(&r.Payer).Error; err != nil {
+		logger(db).Error("QGetReceipt - ", err)
+		return r, 500, err
+	}
+	if err := db.Where("id=?", r.Payment.CardID).First(&r.Card).Error; err != nil {
+		logger(db).Error("QGetReceipt - ", err)
+		return r, 500, err
+	}
+	if code, err := r.Invoice.QGetInvoice(db, r.Payment); err != nil {
+		return r, code, err
+	}
+	if err := db.Where("id=?", r.Invoice.MerchantID).First(&r.Merchant).Error; err != nil {
+		logger(db).Error("QGetReceipt - ", err)
+		return r, 500, err
+	}
+
+	r.Installment = r.Payment.Installment
+	if r.Installment == 0 {
+		// payments from before installments were recorded
+		var previous int64
+		if err := db.Model(&Payment{}).Where("order_id=?", r.Order.ID).
+			Where("id<=?", r.Payment.ID).Count(&previous).Error; err != nil {
+			logger(db).Error("QGetReceipt - ", err)
+			return r, 500, err
+		}
+		r.Installment = int(previous)
+	}
+	return r, 200, nil
+}
@@ -0,0 +1,457 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"systempayment/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ledger accounts
+//
+// Balances are debits - credits, merchant_balance is what's owed to the
+// merchant (credit) and refunds, fees and chargebacks are taken from it.
+const (
+	AccountPayerReceivable = "payer_receivable"
+	AccountMerchantBalance = "merchant_balance"
+	AccountDlocalClearing  = "dlocal_clearing"
+	AccountFees            = "fees"
+	AccountRefunds         = "refunds"
+	AccountChargebacks     = "chargebacks"
+)
+
+// Ledger transaction types
+const (
+	LedgerOrderPlaced = "order_placed"
+	LedgerPayment     = "payment"
+	LedgerRefund      = "refund"
+	LedgerFee         = "fee"
+	LedgerChargeback  = "chargeback"
+	// dispute won, the chargeback is given back
+	LedgerChargebackReversal = "chargeback_reversal"
+)
+
+// LedgerTransaction - balanced set of entries, append only
+//
+// Reference is unique, posting the same movement twice is a no-op.
+type LedgerTransaction struct {
+	ID           int           `json:"id" gorm:"primaryKey" example:"1"`
+	Type         string        `json:"type" gorm:"index" example:"payment"`
+	Reference    string        `json:"reference" gorm:"uniqueIndex" example:"payment:1"`
+	OrderID      int           `json:"order_id" gorm:"column:order_id;index" example:"1"`
+	MerchantID   int           `json:"merchant_id" gorm:"column:merchant_id;index" example:"1"`
+	PaymentID    *int          `json:"payment_id,omitempty" gorm:"column:payment_id" example:"1"`
+	RefundID     *int          `json:"refund_id,omitempty" gorm:"column:refund_id" example:"1"`
+	ChargebackID *int          `json:"chargeback_id,omitempty" gorm:"column:chargeback_id" example:"1"`
+	Currency     string        `json:"currency" example:"USD"`
+	Entries      []LedgerEntry `json:"entries" gorm:"foreignKey:TransactionID"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// LedgerEntry - one side of a LedgerTransaction
+type LedgerEntry struct {
+	ID            int       `json:"-" gorm:"primaryKey"`
+	TransactionID int       `json:"-" gorm:"column:transaction_id;index"`
+	Account       string    `json:"account" gorm:"index" example:"dlocal_clearing"`
+	OrderID       int       `json:"-" gorm:"column:order_id;index"`
+	MerchantID    int       `json:"-" gorm:"column:merchant_id;index"`
+	Currency      string    `json:"-"`
+	Debit         float64   `json:"debit" gorm:"type:numeric(14,2)" example:"100"`
+	Credit        float64   `json:"credit" gorm:"type:numeric(14,2)" example:"0"`
+	CreatedAt     time.Time `json:"-"`
+}
+
+// AccountBalance - totals of an account in a currency
+type AccountBalance struct {
+	Account  string  `json:"account" example:"payer_receivable"`
+	Currency string  `json:"currency" example:"USD"`
+	Debit    float64 `json:"debit" example:"300"`
+	Credit   float64 `json:"credit" example:"100"`
+	Balance  float64 `json:"balance" example:"200"`
+}
+
+// OrderBalance - what the payer still owes of an order
+type OrderBalance struct {
+	OrderID     int              `json:"order_id" example:"1"`
+	Currency    string           `json:"currency" example:"USD"`
+	Amount      float64          `json:"amount" example:"300"`
+	Paid        float64          `json:"paid" example:"100"`
+	Refunded    float64          `json:"refunded" example:"0"`
+	Outstanding float64          `json:"outstanding" example:"200"`
+	Accounts    []AccountBalance `json:"accounts"`
+}
+
+// MerchantBalance - what's owed to a merchant in a currency
+//
+// Net = Sales - Receivable - Refunds - Fees - Chargebacks, only what was collected
+type MerchantBalance struct {
+	MerchantID  int     `json:"merchant_id" example:"1"`
+	Currency    string  `json:"currency" example:"USD"`
+	Sales       float64 `json:"sales" example:"1000"`
+	Receivable  float64 `json:"receivable" example:"200"`
+	Refunds     float64 `json:"refunds" example:"50"`
+	Fees        float64 `json:"fees" example:"30"`
+	Chargebacks float64 `json:"chargebacks" example:"0"`
+	Net         float64 `json:"net" example:"720"`
+	// collected by dlocal and not paid back
+	Clearing float64 `json:"clearing" example:"720"`
+}
+
+func (LedgerTransaction) TableName() string {
+	return "ledger_transaction"
+}
+
+func (LedgerEntry) TableName() string {
+	return "ledger_entry"
+}
+
+// line - one side of a movement
+type line struct {
+	account string
+	debit   float64
+	credit  float64
+}
+
+// post - Inserts a balanced transaction and its entries
+func (t *LedgerTransaction) post(db *gorm.DB, lines ...line) error {
+	var debits, credits float64
+	for _, l := range lines {
+		debits += l.debit
+		credits += l.credit
+	}
+	if math.Round(debits*100) != math.Round(credits*100) {
+		return fmt.Errorf("ledger transaction %s not balanced: %.2f debit, %.2f credit", t.Reference, debits, credits)
+	}
+
+	t.Currency = strings.ToUpper(t.Currency)
+	t.CreatedAt = time.Now()
+	for _, l := range lines {
+		t.Entries = append(t.Entries, LedgerEntry{
+			Account:    l.account,
+			OrderID:    t.OrderID,
+			MerchantID: t.MerchantID,
+			Currency:   t.Currency,
+			Debit:      math.Round(l.debit*100) / 100,
+			Credit:     math.Round(l.credit*100) / 100,
+			CreatedAt:  t.CreatedAt,
+		})
+	}
+
+	result := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "reference"}}, DoNothing: true}).
+		Omit("Entries").Create(t)
+	if result.Error != nil {
+		logger(db).Error("LedgerTransaction.post - ", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// already posted
+		return nil
+	}
+	for i := range t.Entries {
+		t.Entries[i].TransactionID = t.ID
+	}
+	if err := db.Create(&t.Entries).Error; err != nil {
+		logger(db).Error("LedgerTransaction.post - ", err)
+		return err
+	}
+	return nil
+}
+
+// QPostOrderPlaced - payer owes the order amount, owed to the merchant once collected
+//
+//	Dr payer_receivable / Cr merchant_balance
+func QPostOrderPlaced(db *gorm.DB, o Order) error {
+	t, err := orderTransaction(db, o.ID, LedgerOrderPlaced, fmt.Sprintf("order:%d", o.ID))
+	if err != nil {
+		return err
+	}
+	return t.post(db,
+		line{account: AccountPayerReceivable, debit: o.Amount},
+		line{account: AccountMerchantBalance, credit: o.Amount},
+	)
+}
+
+// QPostPayment - dlocal collected an installment
+//
+//	Dr dlocal_clearing / Cr payer_receivable
+func QPostPayment(db *gorm.DB, p Payment) error {
+	t, err := orderTransaction(db, p.OrderID, LedgerPayment, fmt.Sprintf("payment:%d", p.ID))
+	if err != nil {
+		return err
+	}
+	t.PaymentID = &p.ID
+	return t.post(db,
+		line{account: AccountDlocalClearing, debit: p.Amount},
+		line{account: AccountPayerReceivable, credit: p.Amount},
+	)
+}
+
+// QPostRefund - money returned to the payer, taken from the merchant
+//
+//	Dr refunds / Cr dlocal_clearing
+func QPostRefund(db *gorm.DB, p Payment, r Refund) error {
+	t, err := orderTransaction(db, p.OrderID, LedgerRefund, fmt.Sprintf("refund:%d", r.ID))
+	if err != nil {
+		return err
+	}
+	t.PaymentID, t.RefundID = &p.ID, &r.ID
+	return t.post(db,
+		line{account: AccountRefunds, debit: r.Amount},
+		line{account: AccountDlocalClearing, credit: r.Amount},
+	)
+}
+
+// QPostFee - dlocal kept a processing fee of the payment
+//
+//	Dr fees / Cr dlocal_clearing
+func QPostFee(db *gorm.DB, p Payment, fee float64) error {
+	t, err := orderTransaction(db, p.OrderID, LedgerFee, fmt.Sprintf("fee:payment:%d", p.ID))
+	if err != nil {
+		return err
+	}
+	t.PaymentID = &p.ID
+	return t.post(db,
+		line{account: AccountFees, debit: fee},
+		line{account: AccountDlocalClearing, credit: fee},
+	)
+}
+
+// QPostChargeback - the payer disputed the payment and dlocal took the amount back
+//
+//	Dr chargebacks / Cr dlocal_clearing
+func QPostChargeback(db *gorm.DB, c Chargeback) error {
+	t, err := orderTransaction(db, c.OrderID, LedgerChargeback, fmt.Sprintf("chargeback:%d", c.ID))
+	if err != nil {
+		return err
+	}
+	t.PaymentID, t.ChargebackID = &c.PaymentID, &c.ID
+	return t.post(db,
+		line{account: AccountChargebacks, debit: c.Amount},
+		line{account: AccountDlocalClearing, credit: c.Amount},
+	)
+}
+
+// QPostChargebackReversal - the dispute was won, dlocal gives the amount back
+//
+//	Dr dlocal_clearing / Cr chargebacks
+func QPostChargebackReversal(db *gorm.DB, c Chargeback) error {
+	t, err := orderTransaction(db, c.OrderID, LedgerChargebackReversal, fmt.Sprintf("chargeback:%d:reversal", c.ID))
+	if err != nil {
+		return err
+	}
+	t.PaymentID, t.ChargebackID = &c.PaymentID, &c.ID
+	return t.post(db,
+		line{account: AccountDlocalClearing, debit: c.Amount},
+		line{account: AccountChargebacks, credit: c.Amount},
+	)
+}
+
+// New transaction for an order, with its merchant and currency
+func orderTransaction(db *gorm.DB, orderID int, kind string, reference string) (LedgerTransaction, error) {
+	t := LedgerTransaction{Type: kind, Reference: reference, OrderID: orderID}
+	var o Order
+	if err := db.Unscoped().Select("id", "currency", "product_id").Where("id=?", orderID).First(&o).Error; err != nil {
+		logger(db).Error("orderTransaction - ", err)
+		return t, err
+	}
+	merchantID, err := QOrderMerchantID(db, orderID)
+	if err != nil {
+		return t, err
+	}
+	t.MerchantID = merchantID
+	if o.Currency != nil {
+		t.Currency = *o.Currency
+	}
+	return t, nil
+}
+
+// QGetLedgerTransactions - Get transactions with entries (optional order and merchant)
+func (t *LedgerTransaction) QGetLedgerTransactions(db *gorm.DB, page pagination.Params, orderID int, merchantID int) ([]LedgerTransaction, int, error) {
+	var transactions []LedgerTransaction
+	query := db.Model(&LedgerTransaction{}).Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+	if orderID != 0 {
+		query = query.Where("order_id=?", orderID)
+	}
+	if merchantID != 0 {
+		query = query.Where("merchant_id=?", merchantID)
+	}
+	if err := page.Query(query).Find(&transactions).Error; err != nil {
+		logger(db).Error("QGetLedgerTransactions - ", err)
+		return transactions, 500, err
+	}
+	return transactions, 200, nil
+}
+
+// Totals of the entries matching column = id, per account and currency
+func accountBalances(db *gorm.DB, column string, id int) ([]AccountBalance, error) {
+	var balances []AccountBalance
+	err := db.Model(&LedgerEntry{}).
+		Select("account, currency, SUM(debit) AS debit, SUM(credit) AS credit, SUM(debit) - SUM(credit) AS balance").
+		Where(column+"=?", id).Group("account, currency").Order("currency, account").Scan(&balances).Error
+	if err != nil {
+		logger(db).Error("accountBalances - ", err)
+	}
+	return balances, err
+}
+
+// QGetOrderBalance - Ledger balance of an order
+func QGetOrderBalance(db *gorm.DB, orderID int) (OrderBalance, int, error) {
+	b := OrderBalance{OrderID: orderID}
+	if exists, err := orderExists(db, orderID); !exists {
+		return b, 400, err
+	}
+	accounts, err := accountBalances(db, "order_id", orderID)
+	if err != nil {
+		return b, 500, err
+	}
+
+	b.Accounts = accounts
+	for _, a := range accounts {
+		b.Currency = a.Currency
+		switch a.Account {
+		case AccountPayerReceivable:
+			b.Amount = a.Debit
+			b.Paid = a.Credit
+			b.Outstanding = a.Balance
+		case AccountRefunds:
+			b.Refunded = a.Balance
+		}
+	}
+	return b, 200, nil
+}
+
+// QGetMerchantBalances - Ledger balance of a merchant, one per currency
+func QGetMerchantBalances(db *gorm.DB, merchantID int) ([]MerchantBalance, int, error) {
+	var balances []MerchantBalance
+	merchant := Merchant{ID: merchantID}
+	if code, err := merchant.QGetMerchant(db); err != nil {
+		return balances, code, err
+	}
+	accounts, err := accountBalances(db, "merchant_id", merchantID)
+	if err != nil {
+		return balances, 500, err
+	}
+
+	var currencies []string
+	byCurrency := map[string]*MerchantBalance{}
+	for _, a := range accounts {
+		b, ok := byCurrency[a.Currency]
+		if !ok {
+			b = &MerchantBalance{MerchantID: merchantID, Currency: a.Currency}
+			byCurrency[a.Currency] = b
+			currencies = append(currencies, a.Currency)
+		}
+		switch a.Account {
+		case AccountMerchantBalance:
+			b.Sales = -a.Balance
+		case AccountRefunds:
+			b.Refunds = a.Balance
+		case AccountFees:
+			b.Fees = a.Balance
+		case AccountChargebacks:
+			b.Chargebacks = a.Balance
+		case AccountPayerReceivable:
+			b.Receivable = a.Balance
+		case AccountDlocalClearing:
+			b.Clearing = a.Balance
+		}
+	}
+	for _, currency := range currencies {
+		b := byCurrency[currency]
+		b.Net = math.Round((b.Sales-b.Receivable-b.Refunds-b.Fees-b.Chargebacks)*100) / 100
+		balances = append(balances, *b)
+	}
+	return balances, 200, nil
+}
+
+func orderExists(db *gorm.DB, id int) (bool, error) {
+	var o Order
+	if err := db.Select("id").Where("id=?", id).First(&o).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger(db).Error("orderExists - ", err)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// QProtectLedger - Makes the ledger tables append only, entries are fixed
+// with new transactions
+func QProtectLedger(db *gorm.DB) error {
+	err := db.Exec(`CREATE OR REPLACE FUNCTION ledger_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'ledger is append only, % on % not allowed', TG_OP, TG_TABLE_NAME;
+	END;
+	$$ LANGUAGE plpgsql`).Error
+	for _, table := range []string{"ledger_transaction", "ledger_entry"} {
+		if err != nil {
+			break
+		}
+		err = db.Exec(fmt.Sprintf(`DROP TRIGGER IF EXISTS %[1]s_append_only ON %[1]s`, table)).Error
+		if err == nil {
+			err = db.Exec(fmt.Sprintf(`CREATE TRIGGER %[1]s_append_only BEFORE UPDATE OR DELETE ON %[1]s
+			FOR EACH ROW EXECUTE FUNCTION ledger_append_only()`, table)).Error
+		}
+	}
+	if err != nil {
+		logger(db).Error("QProtectLedger - ", err)
+	}
+	return err
+}
+
+// QBackfillLedger - Posts the orders, payments and refunds made before the
+// ledger existed
+func QBackfillLedger(db *gorm.DB) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var orders []Order
+		if err := tx.Unscoped().Where(`NOT EXISTS (SELECT 1 FROM ledger_transaction t
+			WHERE t.reference = 'order:' || "order".id)`).Find(&orders).Error; err != nil {
+			return err
+		}
+		for _, o := range orders {
+			if err := QPostOrderPlaced(tx, o); err != nil {
+				return err
+			}
+		}
+
+		var payments []Payment
+		if err := tx.Unscoped().Where(`NOT EXISTS (SELECT 1 FROM ledger_transaction t
+			WHERE t.reference = 'payment:' || payment.id)`).Order("id").Find(&payments).Error; err != nil {
+			return err
+		}
+		for _, p := range payments {
+			if err := QPostPayment(tx, p); err != nil {
+				return err
+			}
+		}
+
+		var refunds []Refund
+		if err := tx.Where("status=?", RefundSuccess).Where(`NOT EXISTS (SELECT 1 FROM ledger_transaction t
+			WHERE t.reference = 'refund:' || refund.id)`).Order("id").Find(&refunds).Error; err != nil {
+			return err
+		}
+		for _, r := range refunds {
+			var p Payment
+			if err := tx.Unscoped().Where("id=?", r.PaymentID).First(&p).Error; err != nil {
+				return err
+			}
+			if err := QPostRefund(tx, p, r); err != nil {
+				return err
+			}
+		}
+		if n := len(orders) + len(payments) + len(refunds); n > 0 {
+			logger(db).Info("Ledger backfilled with ", n, " transactions")
+		}
+		return nil
+	})
+	if err != nil {
+		logger(db).Error("QBackfillLedger - ", err)
+	}
+	return err
+}
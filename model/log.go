@@ -0,0 +1,11 @@
+package model
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// logger - Log entry with the request id of db's context
+func logger(db *gorm.DB) *log.Entry {
+	return log.WithContext(db.Statement.Context)
+}
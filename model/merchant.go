@@ -0,0 +1,105 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"systempayment/pagination"
+
+	"gorm.io/gorm"
+)
+
+// Merchant - seller of Products, issues the invoices of their payments
+type Merchant struct {
+	ID                int            `json:"id" gorm:"primaryKey" example:"1"`
+	Name              *string        `json:"name" example:"Academia Online" validate:"nonzero,min=3,max=100"`
+	TaxID             *string        `json:"tax_id" example:"214563780018"`
+	Address           *string        `json:"address" example:"Av. 18 de Julio 1234, Montevideo"`
+	Email             *string        `json:"email" example:"facturacion@academia.com"`
+	Country           *string        `json:"country" example:"UY" validate:"nonzero,min=2,max=2"`
+	InvoicePrefix     string         `json:"invoice_prefix" example:"A" validate:"max=10"`
+	NextInvoiceNumber int            `json:"next_invoice_number" gorm:"default:1" example:"1"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-"`
+}
+
+func (Merchant) TableName() string {
+	return "merchant"
+}
+
+// QCreateMerchant - Insert into merchant
+func (m *Merchant) QCreateMerchant(db *gorm.DB) (int, error) {
+	var err error
+	if err = validate(m); err != nil {
+		logger(db).Error("QCreateMerchant - ", err)
+		return 400, err
+	}
+
+	country := strings.ToUpper(*m.Country)
+	m.Country = &country
+	m.InvoicePrefix = strings.ToUpper(m.InvoicePrefix)
+	// numbering always starts at 1
+	m.NextInvoiceNumber = 1
+	m.CreatedAt = time.Now()
+	if err = db.Create(m).Error; err != nil {
+		logger(db).Error("QCreateMerchant - ", err)
+		return 400, err
+	}
+	return 200, nil
+}
+
+// QGetMerchants - Get all Merchants
+func (m *Merchant) QGetMerchants(db *gorm.DB, page pagination.Params) ([]Merchant, int, error) {
+	var merchants []Merchant
+	if err := page.Query(db.Model(&Merchant{})).Find(&merchants).Error; err != nil {
+		logger(db).Error("QGetMerchants - ", err)
+		return merchants, 500, err
+	}
+	return merchants, 200, nil
+}
+
+// QGetMerchant - Get Merchant by ID
+func (m *Merchant) QGetMerchant(db *gorm.DB) (int, error) {
+	if err := db.Where("id=?", m.ID).First(&m).Error; err != nil {
+		logger(db).Error("QGetMerchant - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QDefaultMerchant - first Merchant, owner of products created without one
+func (m *Merchant) QDefaultMerchant(db *gorm.DB) (int, error) {
+	if err := db.Order("id").First(&m).Error; err != nil {
+		logger(db).Error("QDefaultMerchant - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, errors.New("no merchant configured")
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QBackfillMerchants - Creates the default Merchant and assigns it the
+// products created before merchants existed
+func QBackfillMerchants(db *gorm.DB) error {
+	var m Merchant
+	err := db.Order("id").First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		name, country := "Default merchant", "UY"
+		m = Merchant{Name: &name, Country: &country, NextInvoiceNumber: 1, CreatedAt: time.Now()}
+		err = db.Create(&m).Error
+	}
+	if err == nil {
+		err = db.Model(&Product{}).Where("merchant_id IS NULL OR merchant_id = 0").
+			Update("merchant_id", m.ID).Error
+	}
+	if err != nil {
+		logger(db).Error("QBackfillMerchants - ", err)
+	}
+	return err
+}
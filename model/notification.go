@@ -0,0 +1,207 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"systempayment/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Notification - email to a Payer (outbox)
+//
+// It's written in the same transaction as the change it is about and sent
+// by the dispatcher once that transaction committed. Data only holds what
+// the templates need, the recipient is read from the payer when sending.
+type Notification struct {
+	ID            int                    `json:"id" gorm:"primaryKey" example:"1"`
+	PayerID       int                    `json:"payer_id" gorm:"column:payer_id;index" example:"1"`
+	Event         string                 `json:"event" example:"payment_succeeded"`
+	Data          map[string]interface{} `json:"data" gorm:"serializer:json;type:text"`
+	DedupKey      *string                `json:"-" gorm:"uniqueIndex"`
+	Status        string                 `json:"status" gorm:"default:pending;index" example:"pending"`
+	Attempts      int                    `json:"attempts" example:"0"`
+	LastError     string                 `json:"last_error,omitempty"`
+	NextAttemptAt time.Time              `json:"next_attempt_at" gorm:"index"`
+	SentAt        *time.Time             `json:"sent_at,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// Notification events
+const (
+	NotifyCardSaved           = "card_saved"
+	NotifyPaymentSucceeded    = "payment_succeeded"
+	NotifyPaymentFailed       = "payment_failed"
+	NotifyInstallmentUpcoming = "installment_upcoming"
+	NotifyOrderFinished       = "order_finished"
+)
+
+// Notification status
+const (
+	NotificationPending  = "pending"
+	NotificationSent     = "sent"
+	NotificationFailed   = "failed"
+	NotificationCanceled = "canceled"
+)
+
+// NotificationMaxAttempts - sends before a notification is marked failed
+const NotificationMaxAttempts = 5
+
+func (Notification) TableName() string {
+	return "notification"
+}
+
+// QEnqueueNotification - Insert a pending Notification
+//
+// Call it with the transaction of the triggering change.
+func QEnqueueNotification(db *gorm.DB, payerID int, event string, data map[string]interface{}) error {
+	n := Notification{PayerID: payerID, Event: event, Data: data}
+	return n.QEnqueue(db)
+}
+
+// QEnqueue - Insert the Notification, if DedupKey is set and already
+// exists nothing is inserted
+func (n *Notification) QEnqueue(db *gorm.DB) error {
+	n.Status = NotificationPending
+	n.CreatedAt = time.Now()
+	n.NextAttemptAt = n.CreatedAt
+	query := db
+	if n.DedupKey != nil {
+		query = query.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "dedup_key"}}, DoNothing: true})
+	}
+	if err := query.Create(n).Error; err != nil {
+		logger(db).Error("QEnqueueNotification - ", err)
+		return err
+	}
+	return nil
+}
+
+// QLockNextNotification - Locks the oldest Notification due to be sent,
+// skipping those locked by other dispatchers
+func (n *Notification) QLockNextNotification(tx *gorm.DB, now time.Time) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status=?", NotificationPending).Where("next_attempt_at<=?", now).
+		Order("next_attempt_at").First(&n).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger(tx).Error("QLockNextNotification - ", err)
+	}
+	return err
+}
+
+// Sent - marks the Notification as sent
+func (n *Notification) Sent(db *gorm.DB) error {
+	now := time.Now()
+	n.Status = NotificationSent
+	n.SentAt = &now
+	n.Attempts++
+	n.LastError = ""
+	return n.save(db, "status", "sent_at", "attempts", "last_error")
+}
+
+// Failed - schedules a retry with exponential backoff (1, 2, 4... minutes),
+// gives up after NotificationMaxAttempts
+func (n *Notification) Failed(db *gorm.DB, sendErr error) error {
+	n.Attempts++
+	n.LastError = sendErr.Error()
+	if n.Attempts >= NotificationMaxAttempts {
+		n.Status = NotificationFailed
+	} else {
+		backoff := time.Duration(math.Pow(2, float64(n.Attempts-1))) * time.Minute
+		n.NextAttemptAt = time.Now().Add(backoff)
+	}
+	return n.save(db, "status", "attempts", "last_error", "next_attempt_at")
+}
+
+// Cancel - the notification won't be sent (e.g. erased payer)
+func (n *Notification) Cancel(db *gorm.DB, reason string) error {
+	n.Status = NotificationCanceled
+	n.LastError = reason
+	return n.save(db, "status", "last_error")
+}
+
+func (n *Notification) save(db *gorm.DB, fields ...string) error {
+	if err := db.Model(n).Select(fields).Updates(n).Error; err != nil {
+		logger(db).Error("Notification.save - ", err)
+		return err
+	}
+	return nil
+}
+
+// QGetNotifications - Get Notifications (optional payer and status)
+func (n *Notification) QGetNotifications(db *gorm.DB, page pagination.Params, payerID int, status string) ([]Notification, int, error) {
+	var notifications []Notification
+	query := db.Model(&Notification{})
+	if payerID != 0 {
+		query = query.Where("payer_id=?", payerID)
+	}
+	if status != "" {
+		query = query.Where("status=?", status)
+	}
+	if err := page.Query(query).Find(&notifications).Error; err != nil {
+		logger(db).Error("QGetNotifications - ", err)
+		return notifications, 500, err
+	}
+	return notifications, 200, nil
+}
+
+// QEnqueueInstallmentReminders - Reminds payers of installments due before
+// `until`, once per installment. Returns how many were enqueued.
+func QEnqueueInstallmentReminders(db *gorm.DB, now time.Time, until time.Time) (int, error) {
+	var orders []Order
+	err := db.Preload("Product").Where("finished=?", false).Where("current_fee>?", 1).
+		Where("next_payment BETWEEN ? AND ?", now, until).Find(&orders).Error
+	if err != nil {
+		logger(db).Error("QEnqueueInstallmentReminders - ", err)
+		return 0, err
+	}
+
+	enqueued := 0
+	for _, o := range orders {
+		key := fmt.Sprintf("%s:%d:%d", NotifyInstallmentUpcoming, o.ID, o.CurrentFee)
+		n := Notification{
+			PayerID:  o.PayerID,
+			Event:    NotifyInstallmentUpcoming,
+			Data:     o.NotificationData(db, o.InstallmentAmount()),
+			DedupKey: &key,
+		}
+		if err := n.QEnqueue(db); err != nil {
+			return enqueued, err
+		}
+		if n.ID != 0 {
+			enqueued++
+		}
+	}
+	return enqueued, nil
+}
+
+// NotificationData - template values of the order for an amount
+func (o *Order) NotificationData(db *gorm.DB, amount float64) map[string]interface{} {
+	product := o.Product
+	if product.ID == 0 {
+		// best effort, templates work without the product name
+		db.Unscoped().Select("id", "name").Where("id=?", o.ProductID).Find(&product)
+	}
+	data := map[string]interface{}{
+		"order_id":     o.ID,
+		"amount":       fmt.Sprintf("%.2f", amount),
+		"currency":     deref(o.Currency),
+		"installment":  o.CurrentFee,
+		"total_fees":   o.TotalFees,
+		"next_payment": o.NextPayment.Format("02/01/2006"),
+	}
+	if product.Name != nil {
+		data["product"] = *product.Name
+	}
+	return data
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -1,29 +1,54 @@
 package model
 
 import (
+	"errors"
+	"math"
+	"strings"
 	"time"
 
+	"systempayment/apperror"
+	"systempayment/pagination"
+	"systempayment/tax"
+
 	"github.com/google/uuid"
-	log "github.com/sirupsen/logrus"
-	"gopkg.in/validator.v2"
 	"gorm.io/gorm"
 )
 
 // Order object
 type Order struct {
-	ID          int            `json:"id" gorm:"primaryKey" example:"1"`
-	Amount      float64        `json:"amount"`
-	OrderId     string         `json:"order_id"`
-	Currency    *string        `json:"currency" example:"USD" validate:"nonzero"`
-	PayerID     int            `json:"payer_id" gorm:"column:payer_id" example:"1"  validate:"nonzero"`
-	ProductID   int            `json:"product_id" example:"1"  validate:"nonzero"`
-	Product     Product        `json:"product"`
-	TotalFees   int            `json:"total_fees" example:"3"  validate:"nonzero,min=1,max=24"`
-	CurrentFee  int            `json:"current_fee" example:"1"`
-	Auto        bool           `json:"-"`
-	NextPayment time.Time      `json:"next_payment"`
-	Payments    []Payment      `json:"payments"`
-	Finished    bool           `json:"finished" gorm:"default:false"`
+	ID         int     `json:"id" gorm:"primaryKey" example:"1"`
+	Amount     float64 `json:"amount"`
+	OrderId    string  `json:"order_id"`
+	Currency   *string `json:"currency" example:"USD" validate:"nonzero"`
+	PayerID    int     `json:"payer_id" gorm:"column:payer_id" example:"1"  validate:"nonzero"`
+	ProductID  int     `json:"product_id" example:"1"  validate:"nonzero"`
+	Product    Product `json:"product"`
+	PriceID    int     `json:"price_id" gorm:"column:price_id" example:"1"`
+	TotalFees  int     `json:"total_fees" example:"3"  validate:"nonzero,min=1,max=24"`
+	CurrentFee int     `json:"current_fee" example:"1"`
+	Auto       bool    `json:"-"`
+	// automatic charging paused while the payer has an open chargeback
+	AutoSuspended bool      `json:"auto_suspended" gorm:"default:false"`
+	NextPayment   time.Time `json:"next_payment"`
+	Payments      []Payment `json:"payments"`
+	Finished      bool      `json:"finished" gorm:"default:false"`
+	// Set when the price was converted from the product's default currency
+	OriginalAmount   float64 `json:"original_amount,omitempty" example:"100"`
+	OriginalCurrency *string `json:"original_currency,omitempty" example:"USD"`
+	FxRateID         *int    `json:"fx_rate_id,omitempty" gorm:"column:fx_rate_id" example:"1"`
+	FxRate           float64 `json:"fx_rate,omitempty" example:"39.25"`
+	SubscriptionID   *int    `json:"subscription_id,omitempty" gorm:"column:subscription_id;index" example:"1"`
+	// Amount = Subtotal - Discount when a coupon was applied
+	CouponCode *string `json:"coupon_code,omitempty" example:"VERANO10"`
+	CouponID   *int    `json:"coupon_id,omitempty" gorm:"column:coupon_id" example:"1"`
+	Subtotal   float64 `json:"subtotal,omitempty" example:"100"`
+	Discount   float64 `json:"discount,omitempty" example:"10"`
+	// Amount is the gross amount, NetAmount + TaxAmount
+	TaxCountry  *string        `json:"tax_country,omitempty" example:"UY"`
+	TaxCategory string         `json:"tax_category,omitempty" example:"standard"`
+	NetAmount   float64        `json:"net_amount" example:"73.77"`
+	TaxAmount   float64        `json:"tax_amount" example:"16.23"`
+	TaxLines    []tax.Line     `json:"tax_lines" gorm:"serializer:json;type:text"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-"`
@@ -37,9 +62,20 @@ func (Order) TableName() string {
 //
 // Inserts new Order
 func (o *Order) QCreateOrder(db *gorm.DB) (int, error) {
+	// only what the payer chooses is kept, amounts, rates, coupon and
+	// subscription are set here
+	*o = Order{
+		PayerID:    o.PayerID,
+		ProductID:  o.ProductID,
+		Currency:   o.Currency,
+		TotalFees:  o.TotalFees,
+		CouponCode: o.CouponCode,
+		Auto:       o.Auto,
+	}
+
 	var err error
 	if t, err := PayerExists(db, o.PayerID); !t {
-		log.Error("QCreateOrder - ", err)
+		logger(db).Error("QCreateOrder - ", err)
 		return 400, err
 	}
 
@@ -48,8 +84,8 @@ func (o *Order) QCreateOrder(db *gorm.DB) (int, error) {
 		TotalFees: o.TotalFees,
 		Currency:  o.Currency,
 	}
-	if err = validator.Validate(o_req); err != nil {
-		log.Error("QCreateOrder - ", err)
+	if err = validate(o_req); err != nil {
+		logger(db).Error("QCreateOrder - ", err)
 		return 400, err
 	}
 
@@ -58,6 +94,55 @@ func (o *Order) QCreateOrder(db *gorm.DB) (int, error) {
 	if err != nil {
 		return code, err
 	}
+	if product.Status != ProductActive {
+		logger(db).Error("QCreateOrder - product ", product.ID, " is ", product.Status)
+		return 400, apperror.Unprocessable("product_not_active", "product is not active")
+	}
+
+	// Snapshot the current price in the order's currency, later price
+	// changes don't affect this order
+	currency := strings.ToUpper(*o.Currency)
+	o.Currency = &currency
+	var price = ProductPrice{ProductID: product.ID, Currency: o.Currency}
+	_, err = price.QGetCurrentPrice(db)
+	switch {
+	case err == nil:
+		o.PriceID = price.ID
+		o.Amount = price.Amount
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// no price in that currency, convert the default one
+		if code, err = o.convertPrice(db, product); err != nil {
+			return code, err
+		}
+	default:
+		return 500, err
+	}
+
+	var coupon Coupon
+	if o.CouponCode != nil && *o.CouponCode != "" {
+		coupon.Code = o.CouponCode
+		if _, err = coupon.QGetCoupon(db); err != nil {
+			return 400, errors.New("coupon not found")
+		}
+		discount, code, err := coupon.Discount(db, o, o.Amount)
+		if err != nil {
+			return code, err
+		}
+		o.CouponCode = coupon.Code
+		o.CouponID = &coupon.ID
+		o.Subtotal = o.Amount
+		o.Discount = discount
+		o.Amount = math.Round((o.Subtotal-discount)*100) / 100
+	} else {
+		o.CouponCode = nil
+		o.Subtotal = o.Amount
+	}
+	if o.Amount <= 0 {
+		return 400, errors.New("order amount must be greater than 0")
+	}
+	if code, err = o.applyTax(db, product); err != nil {
+		return code, err
+	}
 
 	o.OrderId = uuid.New().String()
 	o.CreatedAt = time.Now()
@@ -65,45 +150,164 @@ func (o *Order) QCreateOrder(db *gorm.DB) (int, error) {
 	o.CurrentFee = 1
 
 	// Create Order
-	if err = db.Create(o).Error; err != nil {
-		log.Error("QCreateOrder - ", err)
+	code = 400
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(o).Error; err != nil {
+			return err
+		}
+		if err := QPostOrderPlaced(tx, *o); err != nil {
+			return err
+		}
+		if o.CouponID == nil {
+			return nil
+		}
+		var err error
+		code, err = coupon.QRedeem(tx, o)
+		return err
+	})
+	if err != nil {
+		logger(db).Error("QCreateOrder - ", err)
+		return code, err
+	}
+
+	return 200, nil
+}
+
+// InstallmentAmount - amount of the current installment, rounded to cents.
+// The last installment absorbs the rounding difference.
+func (o *Order) InstallmentAmount() float64 {
+	fee := math.Round(o.Amount/float64(o.TotalFees)*100) / 100
+	if o.CurrentFee >= o.TotalFees {
+		return math.Round((o.Amount-fee*float64(o.TotalFees-1))*100) / 100
+	}
+	return fee
+}
+
+// QOrderMerchantID - Merchant of the order's product
+func QOrderMerchantID(db *gorm.DB, orderID int) (int, error) {
+	var merchantID int
+	err := db.Table("order").Select("product.merchant_id").
+		Joins("JOIN product ON product.id = \"order\".product_id").
+		Where("\"order\".id=?", orderID).Scan(&merchantID).Error
+	if err != nil {
+		logger(db).Error("QOrderMerchantID - ", err)
+	}
+	return merchantID, err
+}
+
+// QOrderPayerID - Payer of the order
+func QOrderPayerID(db *gorm.DB, orderID int) (int, error) {
+	var payerID int
+	err := db.Table("order").Select("payer_id").Where("id=?", orderID).Scan(&payerID).Error
+	if err != nil {
+		logger(db).Error("QOrderPayerID - ", err)
+	}
+	return payerID, err
+}
+
+// QPayerMerchantIDs - Merchants of the products the payer ordered
+func QPayerMerchantIDs(db *gorm.DB, payerID int) ([]int, error) {
+	var merchantIDs []int
+	err := db.Table("order").
+		Joins("JOIN product ON product.id = \"order\".product_id").
+		Where("\"order\".payer_id=?", payerID).Where("\"order\".deleted_at IS NULL").
+		Order("product.merchant_id").Pluck("DISTINCT product.merchant_id", &merchantIDs).Error
+	if err != nil {
+		logger(db).Error("QPayerMerchantIDs - ", err)
+	}
+	return merchantIDs, err
+}
+
+// Breaks the order's amount down in net + taxes for the payer's country
+// and the product's tax category
+func (o *Order) applyTax(db *gorm.DB, product Product) (int, error) {
+	var payer Payer
+	if err := db.Select("id", "country").Where("id=?", o.PayerID).First(&payer).Error; err != nil {
+		logger(db).Error("applyTax - ", err)
+		return 500, err
+	}
+	var country string
+	if payer.Country != nil {
+		country = strings.ToUpper(*payer.Country)
+	}
+
+	breakdown, err := tax.Calculate(country, product.TaxCategory, o.Amount)
+	if err != nil {
+		logger(db).Error("applyTax - ", err)
 		return 400, err
 	}
+	o.TaxCountry = &country
+	o.TaxCategory = product.TaxCategory
+	o.NetAmount = breakdown.Net
+	o.TaxAmount = breakdown.Tax
+	o.TaxLines = breakdown.Lines
+	return 200, nil
+}
+
+// Tax - breaks down an amount charged for this order (an installment)
+// with the order's tax country and category
+func (o *Order) Tax(amount float64) (tax.Breakdown, error) {
+	if o.TaxCountry == nil {
+		// orders from before taxes were broken out
+		return tax.Breakdown{Net: amount, Gross: amount, Lines: []tax.Line{}}, nil
+	}
+	return tax.Calculate(*o.TaxCountry, o.TaxCategory, amount)
+}
 
+// Prices the order converting the product's default price with the latest exchange rate
+func (o *Order) convertPrice(db *gorm.DB, product Product) (int, error) {
+	var price = ProductPrice{ProductID: product.ID, Currency: product.Currency}
+	if code, err := price.QGetCurrentPrice(db); err != nil {
+		return code, err
+	}
+
+	var rate = ExchangeRate{Base: price.Currency, Quote: o.Currency}
+	if code, err := rate.QGetExchangeRate(db, time.Now()); err != nil {
+		if code == 400 {
+			return 400, apperror.Unprocessable("exchange_rate_unavailable", "product %d has no price in %s and no usable %s/%s exchange rate",
+				product.ID, *o.Currency, *price.Currency, *o.Currency)
+		}
+		return code, err
+	}
+
+	o.PriceID = price.ID
+	o.OriginalAmount = price.Amount
+	o.OriginalCurrency = price.Currency
+	o.FxRateID = &rate.ID
+	o.FxRate = rate.Rate
+	o.Amount = rate.Convert(price.Amount)
 	return 200, nil
 }
 
-func (o *Order) QGetOrders(db *gorm.DB, start int, count int, payer_id int) ([]Order, int, error) {
+// QGetOrders - Get a page of orders with their product and payments (optional
+// payer, product, currency and finished)
+func (o *Order) QGetOrders(db *gorm.DB, page pagination.Params, payer_id int, product_id int, currency string, finished *bool) ([]Order, int, error) {
 	var orders []Order
+	query := db.Model(&Order{}).Preload("Product").Preload("Payments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
 	if payer_id != 0 {
-		if err := db.Model(&Order{}).Where("payer_id=?", payer_id).Preload("Product").Limit(count).
-			Offset(start).Find(&orders).Error; err != nil {
-			log.Error("QGetOrders - ", err)
-			return orders, 400, err
-		}
-	} else {
-		if err := db.Model(&Order{}).Preload("Product").Limit(count).Offset(start).
-			Find(&orders).Error; err != nil {
-			log.Error("QGetOrders - ", err)
-			return orders, 400, err
-		}
+		query = query.Where("payer_id=?", payer_id)
 	}
-
-	for idx, order := range orders {
-		p := Payment{OrderID: order.ID}
-		payments, code, err := p.QGetPayments(db)
-		if err != nil {
-			return orders, code, err
-		}
-		order.Payments = payments
-		orders[idx] = order
+	if product_id != 0 {
+		query = query.Where("product_id=?", product_id)
+	}
+	if currency != "" {
+		query = query.Where("currency=?", currency)
+	}
+	if finished != nil {
+		query = query.Where("finished=?", *finished)
+	}
+	if err := page.Query(query).Find(&orders).Error; err != nil {
+		logger(db).Error("QGetOrders - ", err)
+		return orders, 400, err
 	}
 	return orders, 200, nil
 }
 
 func (o *Order) QGetOrder(db *gorm.DB) (int, error) {
 	if err := db.Table("order").Preload("Product").Where("id=?", o.ID).First(&o).Error; err != nil {
-		log.Error("QGetOrder - ", err)
+		logger(db).Error("QGetOrder - ", err)
 		return 400, err
 	}
 	p := Payment{OrderID: o.ID}
@@ -117,14 +321,14 @@ func (o *Order) QGetOrder(db *gorm.DB) (int, error) {
 
 func (o *Order) QUpdateOrder(db *gorm.DB) (int, error) {
 	var err error
-	if err = validator.Validate(o); err != nil {
-		log.Error("QUpdateOrder - ", err)
+	if err = validate(o); err != nil {
+		logger(db).Error("QUpdateOrder - ", err)
 		return 400, err
 	}
 
 	o.UpdatedAt = time.Now()
 	if err = db.Model(&o).Updates(o).Error; err != nil {
-		log.Error("QUpdateOrder - ", err)
+		logger(db).Error("QUpdateOrder - ", err)
 		return 400, err
 	}
 	return 200, nil
@@ -133,7 +337,7 @@ func (o *Order) QUpdateOrder(db *gorm.DB) (int, error) {
 // Fetches one order by ID only with the necessary data for making a payment
 func (o *Order) GetOrderForPayment(db *gorm.DB) (int, error) {
 	if err := db.Table("order").Where("id=?", o.ID).Where("finished=?", false).First(&o).Error; err != nil {
-		log.Error("GetOrderForPayment - ", err)
+		logger(db).Error("GetOrderForPayment - ", err)
 		return 400, err
 	}
 	return 200, nil
@@ -146,7 +350,7 @@ func (o *Order) PaymentSuccessful(db *gorm.DB) (int, error) {
 		o.Auto = false
 	} else {
 		o.CurrentFee++
-		_ = o.NextPayment.AddDate(0, 1, 0)
+		o.NextPayment = o.NextPayment.AddDate(0, 1, 0)
 	}
 	return o.QUpdateOrder(db)
 }
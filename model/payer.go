@@ -4,26 +4,32 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-	"gopkg.in/validator.v2"
+	"systempayment/apperror"
+	"systempayment/encryption"
+	"systempayment/pagination"
+
 	"gorm.io/gorm"
 )
 
 // Payer example
 type Payer struct {
 	ID            int            `json:"id" gorm:"primaryKey" example:"1"`
-	Name          *string        `json:"name" example:"Jhon Doe" validate:"nonzero,min=3,max=100"`
-	Email         *string        `json:"email" example:"jhondoe@mail.com" validate:"nonzero,min=8,max=100"`
-	BirthDate     *string        `json:"birth_date" example:"24/07/1992" validate:"nonzero"`
-	Phone         *string        `json:"phone" example:"+123456789" validate:"nonzero"`
-	Document      *string        `json:"document" example:"23415162" validate:"nonzero"`
+	Name          *string        `json:"name" gorm:"serializer:encrypted" example:"Jhon Doe" validate:"nonzero,min=3,max=100"`
+	Email         *string        `json:"email" gorm:"serializer:encrypted" example:"jhondoe@mail.com" validate:"nonzero,min=8,max=100"`
+	EmailIndex    string         `json:"-" gorm:"index"`
+	BirthDate     *string        `json:"birth_date" gorm:"serializer:encrypted" example:"24/07/1992" validate:"nonzero"`
+	Phone         *string        `json:"phone" gorm:"serializer:encrypted" example:"+123456789" validate:"nonzero"`
+	Document      *string        `json:"document" gorm:"serializer:encrypted" example:"23415162" validate:"nonzero"`
+	DocumentIndex string         `json:"-" gorm:"index"`
 	UserReference string         `json:"user_reference"`
 	Address       Address        `json:"address" gorm:"foreignKey:PayerID;references:ID" validate:"nonzero"`
 	AddressID     int            `json:"-"`
 	Country       *string        `json:"country" example:"UY" validate:"nonzero,min=2,max=2"`
 	CardID        int            `json:"card_id"`
+	ErasedAt      *time.Time     `json:"erased_at,omitempty"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-"`
@@ -32,11 +38,11 @@ type Payer struct {
 type Address struct {
 	ID        int            `json:"-" gorm:"primaryKey" example:"1"`
 	PayerID   int            `json:"-" gorm:"column:payer_id" example:"1"`
-	State     *string        `json:"state" example:"Rio de Janeiro" validate:"nonzero"`
-	City      *string        `json:"city" example:"Volta Redonda" validate:"nonzero"`
-	ZipCode   *string        `json:"zip_code" example:"27275-595" validate:"nonzero"`
-	Street    *string        `json:"street" example:"Servidão B-1" validate:"nonzero"`
-	Number    *string        `json:"number" example:"1106" validate:"nonzero"`
+	State     *string        `json:"state" gorm:"serializer:encrypted" example:"Rio de Janeiro" validate:"nonzero"`
+	City      *string        `json:"city" gorm:"serializer:encrypted" example:"Volta Redonda" validate:"nonzero"`
+	ZipCode   *string        `json:"zip_code" gorm:"serializer:encrypted" example:"27275-595" validate:"nonzero"`
+	Street    *string        `json:"street" gorm:"serializer:encrypted" example:"Servidão B-1" validate:"nonzero"`
+	Number    *string        `json:"number" gorm:"serializer:encrypted" example:"1106" validate:"nonzero"`
 	CreatedAt time.Time      `json:"created_at"`
 	DeletedAt gorm.DeletedAt `json:"-"`
 }
@@ -51,8 +57,9 @@ func (Address) TableName() string {
 
 func PayerExists(db *gorm.DB, id int) (bool, error) {
 	var p Payer
-	if err := db.Table("payer").Select("id").Where("id=?", id).First(&p).Error; err != nil {
-		log.Error("PayerExists - ", err)
+	if err := db.Table("payer").Select("id").Where("id=?", id).Where("erased_at IS NULL").
+		First(&p).Error; err != nil {
+		logger(db).Error("PayerExists - ", err)
 		return false, err
 	}
 	return true, nil
@@ -61,21 +68,29 @@ func PayerExists(db *gorm.DB, id int) (bool, error) {
 func PreloadPayer(db *gorm.DB, id int) (*Payer, error) {
 	var p *Payer
 	if err := db.Table("payer").Select("id, card_id").Where("id=?", id).First(&p).Error; err != nil {
-		log.Error("PreloadPayer - ", err)
+		logger(db).Error("PreloadPayer - ", err)
 		return nil, err
 	}
 	return p, nil
 }
 
-// QGetPayerFromEmail - Get payer from email
-//
-// Filter by Payer.Email, returns Payer{} or ErrRecordNotFound
-func (p *Payer) QGetPayerFromEmail(db *gorm.DB) error {
-	err := db.Where("email = ?", p.Email).First(&p).Error
-	if err != nil {
-		log.Error("QGetPayerFromEmail - ", err)
+// Blind indexes of the encrypted columns used for lookups
+func (p *Payer) setBlindIndexes() {
+	if p.Email != nil {
+		p.EmailIndex = encryption.BlindIndex(*p.Email)
+	}
+	if p.Document != nil {
+		p.DocumentIndex = encryption.BlindIndex(*p.Document)
+	}
+}
+
+// whereIndexed - Filter by an encrypted column through its blind index, or
+// by the plaintext value when there's no index key (nothing is encrypted)
+func whereIndexed(query *gorm.DB, column string, value string) *gorm.DB {
+	if index := encryption.BlindIndex(value); index != "" {
+		return query.Where(column+"_index = ?", index)
 	}
-	return err
+	return query.Where("LOWER(TRIM("+column+")) = ?", strings.ToLower(strings.TrimSpace(value)))
 }
 
 // QCreatePayer - Insert into payer
@@ -83,21 +98,20 @@ func (p *Payer) QGetPayerFromEmail(db *gorm.DB) error {
 // Inserts new Payer + Address
 func (p *Payer) QCreatePayer(db *gorm.DB) (int, error) {
 	var err error
-	if err = validator.Validate(p); err != nil {
-		log.Error("QCreatePayer - ", err)
+	if err = validate(p); err != nil {
+		logger(db).Error("QCreatePayer - ", err)
 		return 400, err
 	}
-	if err = validator.Validate(p.Address); err != nil {
-		log.Error("QCreatePayer - ", err)
+	if err = validate(p.Address); err != nil {
+		logger(db).Error("QCreatePayer - ", err)
 		return 400, err
 	}
 
 	p.CreatedAt = time.Now()
-	// Create Payer
-	if err = db.Raw(`INSERT INTO payer(name, email, country, birth_date, phone, document, created_at) 
-	VALUES(?, ?, ?, ?, ?, ?, ?) RETURNING id`, p.Name, p.Email, p.Country, p.BirthDate, p.Phone,
-		p.Document, p.CreatedAt).Scan(&p.ID).Error; err != nil {
-		log.Error("QCreatePayer - ", err)
+	p.setBlindIndexes()
+	// Create Payer (PII columns are encrypted by the serializer)
+	if err = db.Omit("Address").Create(p).Error; err != nil {
+		logger(db).Error("QCreatePayer - ", err)
 		return 400, err
 	}
 	str_payer_id := strconv.Itoa(p.ID)
@@ -114,21 +128,27 @@ func (a *Address) QCreateAddress(db *gorm.DB, p *Payer) (int, error) {
 	var err error
 	a.PayerID = p.ID
 	a.CreatedAt = time.Now()
-	if err = db.Raw(`INSERT INTO address(payer_id, state, city, zip_code, street, number, created_at) 
-	VALUES(?, ?, ?, ?, ?, ?, ?) RETURNING id`,
-		a.PayerID, a.State, a.City, a.ZipCode, a.Street, a.Number, a.CreatedAt).Scan(&a.ID).Error; err != nil {
-		log.Error("QCreateAddress - ", err)
+	if err = db.Create(a).Error; err != nil {
+		logger(db).Error("QCreateAddress - ", err)
 		return 400, err
 	}
 	p.AddressID = a.ID
 	return p.QUpdatePayer(db)
 }
 
-// QGetPayers - Get all Payers
-func (p *Payer) QGetPayers(db *gorm.DB, start int, count int) ([]Payer, int, error) {
+// QGetPayers - Get all Payers (optional email and document, matched by
+// their blind indexes)
+func (p *Payer) QGetPayers(db *gorm.DB, page pagination.Params) ([]Payer, int, error) {
 	var payers []Payer
-	if err := db.Model(&Payer{}).Preload("Address").Limit(count).Offset(start).Find(&payers).Error; err != nil {
-		log.Error("QGetPayers - ", err)
+	query := db.Model(&Payer{}).Preload("Address")
+	if p.Email != nil {
+		query = whereIndexed(query, "email", *p.Email)
+	}
+	if p.Document != nil {
+		query = whereIndexed(query, "document", *p.Document)
+	}
+	if err := page.Query(query).Find(&payers).Error; err != nil {
+		logger(db).Error("QGetPayers - ", err)
 		switch err {
 		case gorm.ErrRecordNotFound:
 			return payers, 200, err
@@ -142,7 +162,7 @@ func (p *Payer) QGetPayers(db *gorm.DB, start int, count int) ([]Payer, int, err
 // QGetPayer - Get Payer by ID
 func (p *Payer) QGetPayer(db *gorm.DB) (int, error) {
 	if err := db.Preload("Address").Where("payer.id=?", p.ID).First(&p).Error; err != nil {
-		log.Error("QGetPayer - ", err)
+		logger(db).Error("QGetPayer - ", err)
 		return 400, err
 	}
 	return 200, nil
@@ -150,19 +170,28 @@ func (p *Payer) QGetPayer(db *gorm.DB) (int, error) {
 
 func (p *Payer) QUpdatePayer(db *gorm.DB) (int, error) {
 	var err error
-	if err = validator.Validate(p); err != nil {
-		log.Error("QUpdatePayer - ", err)
+	// erased data isn't written back
+	var current = Payer{ID: p.ID}
+	if code, err := current.QGetPayer(db); err != nil {
+		return code, err
+	}
+	if current.ErasedAt != nil {
+		return 409, apperror.Conflict("payer_erased", "payer is erased, it can't be updated")
+	}
+	if err = validate(p); err != nil {
+		logger(db).Error("QUpdatePayer - ", err)
 		return 400, err
 	}
 
-	if err = validator.Validate(p.Address); err != nil {
-		log.Error("QUpdatePayer - ", err)
+	if err = validate(p.Address); err != nil {
+		logger(db).Error("QUpdatePayer - ", err)
 		return 400, err
 	}
 
 	p.UpdatedAt = time.Now()
+	p.setBlindIndexes()
 	if err = db.Model(&p).Updates(p).Error; err != nil {
-		log.Error("QUpdatePayer - ", err)
+		logger(db).Error("QUpdatePayer - ", err)
 		return 400, err
 	}
 	return 200, nil
@@ -178,8 +207,142 @@ func (p *Payer) QPrimaryCard(db *gorm.DB, card_id int) (int, error) {
 		return 400, errors.New("invalid card id")
 	}
 	if err = db.Model(&p).Update("card_id", card_id).Error; err != nil {
-		log.Error("QPrimaryCard - ", err)
+		logger(db).Error("QPrimaryCard - ", err)
 		return 400, err
 	}
 	return 200, nil
 }
+
+// QExportPayer - Get all data tied to a Payer
+//
+// Payer + Address, Cards (including removed ones), Orders and their Payments
+func (p *Payer) QExportPayer(db *gorm.DB) (PayerExport, int, error) {
+	var export PayerExport
+	if code, err := p.QGetPayer(db); err != nil {
+		return export, code, err
+	}
+
+	if err := db.Unscoped().Where("payer_id=?", p.ID).Order("id").Find(&export.Cards).Error; err != nil {
+		logger(db).Error("QExportPayer - ", err)
+		return export, 500, err
+	}
+
+	if err := db.Where("payer_id=?", p.ID).Preload("Product").Preload("Payments").
+		Order("id").Find(&export.Orders).Error; err != nil {
+		logger(db).Error("QExportPayer - ", err)
+		return export, 500, err
+	}
+
+	export.Payer = *p
+	export.ExportedAt = time.Now()
+	return export, 200, nil
+}
+
+// QErasePayer - Pseudonymize Payer + Address
+//
+// Replaces personal fields with placeholders, removes saved cards and stops
+// automatic charges. The payer's notifications, webhook events (and their
+// logged responses) and the IPs of its payment attempts are erased too.
+// Orders and Payments are kept for accounting.
+func (p *Payer) QErasePayer(db *gorm.DB) (int, error) {
+	if code, err := p.QGetPayer(db); err != nil {
+		return code, err
+	}
+	if p.ErasedAt != nil {
+		return 409, apperror.Conflict("payer_erased", "payer already erased")
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("Erased Payer %05d", p.ID)
+	email := fmt.Sprintf("erased-%d@erased.invalid", p.ID)
+	empty := ""
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		erased := Payer{
+			Name:      &name,
+			Email:     &email,
+			BirthDate: &empty,
+			Phone:     &empty,
+			Document:  &empty,
+			CardID:    0,
+			ErasedAt:  &now,
+			UpdatedAt: now,
+		}
+		erased.setBlindIndexes()
+		if err := tx.Model(p).Select("name", "email", "email_index", "birth_date", "phone", "document",
+			"document_index", "card_id", "erased_at", "updated_at").Updates(erased).Error; err != nil {
+			return err
+		}
+
+		address := Address{State: &empty, City: &empty, ZipCode: &empty, Street: &empty, Number: &empty}
+		if err := tx.Model(&Address{}).Where("payer_id=?", p.ID).
+			Select("state", "city", "zip_code", "street", "number").Updates(address).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("payer_id=?", p.ID).Delete(&Card{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Order{}).Where("payer_id=?", p.ID).Where("finished=?", false).
+			Update("auto", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Subscription{}).Where("payer_id=?", p.ID).Where("status<>?", SubscriptionCanceled).
+			Updates(map[string]interface{}{"status": SubscriptionCanceled, "canceled_at": now, "retry_at": nil, "updated_at": now}).Error; err != nil {
+			return err
+		}
+
+		// unsent ones aren't sent, data and errors may hold card and address details
+		if err := tx.Model(&Notification{}).Where("payer_id=?", p.ID).Where("status=?", NotificationPending).
+			Update("status", NotificationCanceled).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Notification{}).Where("payer_id=?", p.ID).Select("data", "last_error").
+			Updates(Notification{Data: map[string]interface{}{}}).Error; err != nil {
+			return err
+		}
+		if err := QEraseWebhookEvents(tx, p.ID); err != nil {
+			return err
+		}
+		return tx.Model(&PaymentAttempt{}).Where("payer_id=?", p.ID).Update("ip", "").Error
+	})
+	if err != nil {
+		logger(db).Error("QErasePayer - ", err)
+		return 500, err
+	}
+
+	return p.QGetPayer(db)
+}
+
+// QReencryptPayers - Re-encrypt Payer + Address PII with the active key
+//
+// Run after rotating keys. Also encrypts rows written before encryption was
+// enabled and fills their blind indexes. Returns the number of payers updated.
+func QReencryptPayers(db *gorm.DB) (int, int, error) {
+	var total int
+	var payers []Payer
+	err := db.Unscoped().Preload("Address").FindInBatches(&payers, 100, func(_ *gorm.DB, _ int) error {
+		for i := range payers {
+			p := &payers[i]
+			p.setBlindIndexes()
+			if err := db.Unscoped().Model(p).Select("name", "email", "email_index", "birth_date", "phone",
+				"document", "document_index").Updates(p).Error; err != nil {
+				return err
+			}
+			if p.Address.ID != 0 {
+				if err := db.Model(&p.Address).Select("state", "city", "zip_code", "street", "number").
+					Updates(&p.Address).Error; err != nil {
+					return err
+				}
+			}
+			total++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		logger(db).Error("QReencryptPayers - ", err)
+		return total, 500, err
+	}
+	return total, 200, nil
+}
@@ -1,35 +1,60 @@
 package model
 
 import (
+	"errors"
+	"math"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-	"gopkg.in/validator.v2"
+	"systempayment/pagination"
+	"systempayment/tax"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Payment object
 type Payment struct {
-	ID                int            `json:"id" gorm:"primaryKey" example:"1"`
-	Amount            float64        `json:"amount" example:"5000.00" validate:"nonzero"`
-	Currency          *string        `json:"currency" example:"USD" validate:"nonzero,min=3,max=3,uppercase"`
-	Country           *string        `json:"country" example:"UY" validate:"nonzero,min=2,max=2,uppercase"`
-	PaymentMethodID   *string        `json:"payment_method_id" example:"CARD" validate:"nonzero,min=2,max=4"`
-	PaymentMethodFlow *string        `json:"payment_method_flow" example:"DIRECT" validate:"nonzero,min=2,max=10"`
-	OrderID           int            `json:"order_id" gorm:"column:order_id" example:"1"  validate:"nonzero"`
-	OrderNumber       *string        `json:"order_number" validate:"nonzero"`
-	CardID            int            `json:"card_id" gorm:"column:card_id" example:"1"  validate:"nonzero"`
-	Description       *string        `json:"description"`
-	CreatedAt         time.Time      `json:"created_at"`
-	DeletedAt         gorm.DeletedAt `json:"-"`
+	ID                int        `json:"id" gorm:"primaryKey" example:"1"`
+	Amount            float64    `json:"amount" example:"5000.00" validate:"nonzero"`
+	Currency          *string    `json:"currency" example:"USD" validate:"nonzero,min=3,max=3,uppercase"`
+	Country           *string    `json:"country" example:"UY" validate:"nonzero,min=2,max=2,uppercase"`
+	PaymentMethodID   *string    `json:"payment_method_id" example:"CARD" validate:"nonzero,min=2,max=4"`
+	PaymentMethodFlow *string    `json:"payment_method_flow" example:"DIRECT" validate:"nonzero,min=2,max=10"`
+	OrderID           int        `json:"order_id" gorm:"column:order_id" example:"1"  validate:"nonzero"`
+	OrderNumber       *string    `json:"order_number" validate:"nonzero"`
+	CardID            int        `json:"card_id" gorm:"column:card_id" example:"1"  validate:"nonzero"`
+	Installment       int        `json:"installment" example:"1"`
+	Description       *string    `json:"description"`
+	FxRateID          *int       `json:"fx_rate_id,omitempty" gorm:"column:fx_rate_id" example:"1"`
+	FxRate            float64    `json:"fx_rate,omitempty" example:"39.25"`
+	NetAmount         float64    `json:"net_amount" example:"4098.36"`
+	TaxAmount         float64    `json:"tax_amount" example:"901.64"`
+	TaxLines          []tax.Line `json:"tax_lines" gorm:"serializer:json;type:text"`
+	DlocalID          *string    `json:"dlocal_id" gorm:"column:dlocal_id;index" example:"D-4-cf2d3e7a"`
+	Status            string     `json:"status" gorm:"default:paid" example:"paid"`
+	RefundedAmount    float64    `json:"refunded_amount" example:"0"`
+	// set when a dlocal settlement report includes the payment
+	SettlementReportID *int           `json:"settlement_report_id,omitempty" gorm:"column:settlement_report_id;index" example:"1"`
+	SettledAt          *time.Time     `json:"settled_at,omitempty"`
+	CreatedAt          time.Time      `json:"created_at"`
+	DeletedAt          gorm.DeletedAt `json:"-"`
 }
 
+// Payment status
+const (
+	PaymentPaid              = "paid"
+	PaymentPartiallyRefunded = "partially_refunded"
+	PaymentRefunded          = "refunded"
+	PaymentChargedBack       = "charged_back"
+)
+
 func (Payment) TableName() string {
 	return "payment"
 }
 
 // Save payment from dlocal's payment response
 func (p *Payment) SavePaymentFromResponse(db *gorm.DB, response map[string]interface{}) (int, error) {
+	dlocal_id, _ := response["id"].(string)
 	amount, _ := response["amount"].(float64)
 	currency, _ := response["currency"].(string)
 	country, _ := response["country"].(string)
@@ -38,7 +63,11 @@ func (p *Payment) SavePaymentFromResponse(db *gorm.DB, response map[string]inter
 	order_number, _ := response["order_id"].(string)
 	description, _ := response["description"].(string)
 
+	if dlocal_id != "" {
+		p.DlocalID = &dlocal_id
+	}
 	p.Amount = amount
+	p.Status = PaymentPaid
 	p.Currency = &currency
 	p.Country = &country
 	p.PaymentMethodID = &payment_method_id
@@ -55,15 +84,15 @@ func (p *Payment) SavePaymentFromResponse(db *gorm.DB, response map[string]inter
 // Inserts new Payment
 func (p *Payment) QCreatePayment(db *gorm.DB) (int, error) {
 	var err error
-	if err = validator.Validate(p); err != nil {
-		log.Error("QCreatePayment - ", err)
+	if err = validate(p); err != nil {
+		logger(db).Error("QCreatePayment - ", err)
 		return 400, err
 	}
 
 	p.CreatedAt = time.Now()
 	// Create Payment
 	if err = db.Create(p).Error; err != nil {
-		log.Error("QCreatePayment - ", err)
+		logger(db).Error("QCreatePayment - ", err)
 		return 400, err
 	}
 
@@ -74,7 +103,7 @@ func (p *Payment) QCreatePayment(db *gorm.DB) (int, error) {
 func (p *Payment) QGetPayments(db *gorm.DB) ([]Payment, int, error) {
 	var payments []Payment
 	if err := db.Table("payment").Select("*").Where("order_id=?", p.OrderID).Scan(&payments).Error; err != nil {
-		log.Error("QGetPayments - ", err)
+		logger(db).Error("QGetPayments - ", err)
 		return payments, 400, err
 	}
 
@@ -84,28 +113,93 @@ func (p *Payment) QGetPayments(db *gorm.DB) ([]Payment, int, error) {
 // QGetPayment - Get payment from id
 func (p *Payment) QGetPayment(db *gorm.DB) (int, error) {
 	if err := db.Where("id = ?", p.ID).First(&p).Error; err != nil {
-		log.Error("QGetPayment - ", err)
+		logger(db).Error("QGetPayment - ", err)
 		return 400, err
 	}
 	return 200, nil
 }
 
-// Get all payments (optional order_id)
-func (p *Payment) QGetAllPayments(db *gorm.DB, start int, count int, order_id int) ([]Payment, int, error) {
+// QPaymentIDByDlocalID - ID of the payment with a dlocal payment id
+func QPaymentIDByDlocalID(db *gorm.DB, dlocalID string) (int, error) {
+	var payment Payment
+	if err := db.Select("id").Where("dlocal_id=?", dlocalID).First(&payment).Error; err != nil {
+		logger(db).Error("QPaymentIDByDlocalID - ", err)
+		return 0, err
+	}
+	return payment.ID, nil
+}
+
+// Get a page of payments (optional order_id, status and currency)
+func (p *Payment) QGetAllPayments(db *gorm.DB, page pagination.Params, order_id int, status string, currency string) ([]Payment, int, error) {
 	var payments []Payment
+	query := db.Model(&Payment{})
 	if order_id != 0 {
-		if err := db.Table("payment").Where("order_id=?", order_id).Select("*").
-			Order("created_at desc").Limit(count).Offset(start).Scan(&payments).Error; err != nil {
-			log.Error("QGetAllPayments - ", err)
-			return payments, 400, err
-		}
-	} else {
-		if err := db.Table("payment").Select("*").Order("created_at desc").
-			Limit(count).Offset(start).Scan(&payments).Error; err != nil {
-			log.Error("QGetAllPayments - ", err)
-			return payments, 400, err
-		}
+		query = query.Where("order_id=?", order_id)
+	}
+	if status != "" {
+		query = query.Where("status=?", status)
+	}
+	if currency != "" {
+		query = query.Where("currency=?", currency)
+	}
+	if err := page.Query(query).Find(&payments).Error; err != nil {
+		logger(db).Error("QGetAllPayments - ", err)
+		return payments, 400, err
 	}
 
 	return payments, 200, nil
 }
+
+// QLockPayment - Get payment by id and lock it until the transaction ends
+func (p *Payment) QLockPayment(tx *gorm.DB) (int, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", p.ID).First(&p).Error; err != nil {
+		logger(tx).Error("QLockPayment - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+// Refundable - amount not refunded yet
+func (p *Payment) Refundable() float64 {
+	return math.Round((p.Amount-p.RefundedAmount)*100) / 100
+}
+
+// Status by the refunded amount
+func (p *Payment) refundStatus() string {
+	switch {
+	case p.RefundedAmount <= 0:
+		return PaymentPaid
+	case p.Refundable() <= 0:
+		return PaymentRefunded
+	default:
+		return PaymentPartiallyRefunded
+	}
+}
+
+// ChargedBack - marks the payment as disputed, or back to its refund
+// status when the dispute was won
+func (p *Payment) ChargedBack(db *gorm.DB, disputed bool) error {
+	p.Status = PaymentChargedBack
+	if !disputed {
+		p.Status = p.refundStatus()
+	}
+	if err := db.Model(&p).Select("status").Updates(p).Error; err != nil {
+		logger(db).Error("ChargedBack - ", err)
+		return err
+	}
+	return nil
+}
+
+// Refunded - adds a refund to the payment's refunded amount
+func (p *Payment) Refunded(db *gorm.DB, amount float64) (int, error) {
+	p.RefundedAmount = math.Round((p.RefundedAmount+amount)*100) / 100
+	p.Status = p.refundStatus()
+	if err := db.Model(&p).Select("refunded_amount", "status").Updates(p).Error; err != nil {
+		logger(db).Error("Refunded - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"systempayment/apperror"
+	"systempayment/pagination"
+
+	"gorm.io/gorm"
+)
+
+// PaymentAttempt - a charge attempted with dlocal, or stopped before it,
+// kept for the velocity rules and the review queue
+type PaymentAttempt struct {
+	ID        int    `json:"id" gorm:"primaryKey" example:"1"`
+	Kind      string `json:"kind" gorm:"index:idx_payment_attempt_payer" example:"payment" enums:"card_save,payment"`
+	PayerID   int    `json:"payer_id" gorm:"column:payer_id;index:idx_payment_attempt_payer" example:"1"`
+	CardID    *int   `json:"card_id" gorm:"column:card_id;index" example:"1"`
+	OrderID   *int   `json:"order_id,omitempty" gorm:"column:order_id;index" example:"1"`
+	PaymentID *int   `json:"payment_id,omitempty" gorm:"column:payment_id" example:"1"`
+	// Installment of the order charged
+	Installment int     `json:"installment,omitempty" example:"1"`
+	Amount      float64 `json:"amount,omitempty" example:"100"`
+	Currency    string  `json:"currency,omitempty" example:"USD"`
+	IP          string  `json:"ip,omitempty" example:"203.0.113.7"`
+	Result      string  `json:"result" example:"held" enums:"approved,rejected,failed,blocked,held"`
+	Reason      string  `json:"reason" example:"insufficient funds"`
+	// Risk rules' outcome and the rules that matched, comma separated
+	RiskOutcome  string     `json:"risk_outcome,omitempty" example:"review" enums:"allow,review,deny"`
+	RiskReasons  string     `json:"risk_reasons,omitempty" example:"amount_over_review_limit,card_country_mismatch"`
+	ReviewStatus string     `json:"review_status,omitempty" gorm:"index" example:"pending" enums:"pending,approved,denied"`
+	ReviewNote   string     `json:"review_note,omitempty" example:"payer confirmed by phone"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"index:idx_payment_attempt_payer"`
+}
+
+// Payment attempt kinds
+const (
+	AttemptCardSave = "card_save"
+	AttemptPayment  = "payment"
+)
+
+// Payment attempt results. Failed attempts got no answer from dlocal,
+// rejected ones were declined by it, blocked and held ones weren't sent
+const (
+	AttemptApproved = "approved"
+	AttemptRejected = "rejected"
+	AttemptFailed   = "failed"
+	AttemptBlocked  = "blocked"
+	AttemptHeld     = "held"
+)
+
+// Review status of the attempts held by the risk rules
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewDenied   = "denied"
+)
+
+func (PaymentAttempt) TableName() string {
+	return "payment_attempt"
+}
+
+// QSavePaymentAttempt - Insert into payment_attempt, or update it when it
+// has an ID
+func (a *PaymentAttempt) QSavePaymentAttempt(db *gorm.DB) (int, error) {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	if err := db.Save(a).Error; err != nil {
+		logger(db).Error("QSavePaymentAttempt - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QCountPaymentAttempts - Attempts of the kind with one of the results
+// since the time given, of the payer and of the card when they're set
+func (a *PaymentAttempt) QCountPaymentAttempts(db *gorm.DB, results []string, since time.Time) (int64, int, error) {
+	query := db.Model(&PaymentAttempt{}).Where("kind=? AND result IN ? AND created_at>=?", a.Kind, results, since)
+	if a.PayerID != 0 {
+		query = query.Where("payer_id=?", a.PayerID)
+	}
+	if a.CardID != nil {
+		query = query.Where("card_id=?", *a.CardID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		logger(db).Error("QCountPaymentAttempts - ", err)
+		return 0, 500, err
+	}
+	return count, 200, nil
+}
+
+// QGetPaymentAttempt - Get attempt by ID
+func (a *PaymentAttempt) QGetPaymentAttempt(db *gorm.DB) (int, error) {
+	if err := db.Where("id=?", a.ID).First(&a).Error; err != nil {
+		logger(db).Error("QGetPaymentAttempt - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetReviews - Get a page of the attempts held for review, by review status
+func (a *PaymentAttempt) QGetReviews(db *gorm.DB, page pagination.Params, status string) ([]PaymentAttempt, int, error) {
+	var attempts []PaymentAttempt
+	query := db.Model(&PaymentAttempt{}).Where("review_status<>''")
+	if status != "" {
+		query = query.Where("review_status=?", status)
+	}
+	if err := page.Query(query).Find(&attempts).Error; err != nil {
+		logger(db).Error("QGetReviews - ", err)
+		return attempts, 500, err
+	}
+	return attempts, 200, nil
+}
+
+// QLastOrderReview - Latest review of the order's attempts, not found when
+// none was held
+func (a *PaymentAttempt) QLastOrderReview(db *gorm.DB, orderID int) (int, error) {
+	err := db.Where("order_id=? AND review_status<>''", orderID).Order("id DESC").First(&a).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		logger(db).Error("QLastOrderReview - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QDecideReview - Approves or denies a pending review, only once
+func (a *PaymentAttempt) QDecideReview(db *gorm.DB, status string, note string) (int, error) {
+	if code, err := a.QGetPaymentAttempt(db); err != nil {
+		return code, err
+	}
+	if a.ReviewStatus == "" {
+		return 400, errors.New("attempt wasn't held for review")
+	}
+	if a.ReviewStatus != ReviewPending {
+		return 409, apperror.Conflict("review_decided", "review already %s", a.ReviewStatus)
+	}
+
+	now := time.Now()
+	result := db.Model(&PaymentAttempt{}).Where("id=? AND review_status=?", a.ID, ReviewPending).
+		Updates(map[string]interface{}{"review_status": status, "review_note": note, "reviewed_at": now})
+	if result.Error != nil {
+		logger(db).Error("QDecideReview - ", result.Error)
+		return 500, result.Error
+	}
+	// decided by someone else in between
+	if result.RowsAffected == 0 {
+		return 409, apperror.Conflict("review_decided", "review already decided")
+	}
+	a.ReviewStatus, a.ReviewNote, a.ReviewedAt = status, note, &now
+	return 200, nil
+}
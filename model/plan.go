@@ -0,0 +1,126 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"systempayment/pagination"
+
+	"gorm.io/gorm"
+)
+
+// Plan - recurring price of a Product, charged every IntervalCount Interval
+type Plan struct {
+	ID            int            `json:"id" gorm:"primaryKey" example:"1"`
+	ProductID     int            `json:"product_id" gorm:"column:product_id" example:"1" validate:"nonzero"`
+	Name          *string        `json:"name" example:"Membresia mensual" validate:"nonzero,min=3,max=100"`
+	Amount        float64        `json:"amount" example:"15.00" validate:"nonzero"`
+	Currency      *string        `json:"currency" example:"USD" validate:"nonzero,min=3,max=3"`
+	Interval      string         `json:"interval" example:"month" enums:"day,week,month,year" validate:"nonzero"`
+	IntervalCount int            `json:"interval_count" example:"1" validate:"min=0,max=365"`
+	TrialDays     int            `json:"trial_days" example:"7" validate:"min=0,max=365"`
+	Active        bool           `json:"active" gorm:"default:true"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-"`
+}
+
+// Plan intervals
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+func (Plan) TableName() string {
+	return "plan"
+}
+
+// NextPeriod - end of the billing period starting at `from`
+func (p *Plan) NextPeriod(from time.Time) time.Time {
+	count := p.IntervalCount
+	if count < 1 {
+		count = 1
+	}
+	switch p.Interval {
+	case IntervalDay:
+		return from.AddDate(0, 0, count)
+	case IntervalWeek:
+		return from.AddDate(0, 0, 7*count)
+	case IntervalYear:
+		return from.AddDate(count, 0, 0)
+	default:
+		return from.AddDate(0, count, 0)
+	}
+}
+
+// QCreatePlan - Insert into plan
+//
+// Inserts new Plan for an existing Product
+func (p *Plan) QCreatePlan(db *gorm.DB) (int, error) {
+	var err error
+	if err = validate(p); err != nil {
+		logger(db).Error("QCreatePlan - ", err)
+		return 400, err
+	}
+	switch p.Interval {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+	default:
+		return 400, errors.New("interval must be day, week, month or year")
+	}
+	if p.IntervalCount < 1 {
+		p.IntervalCount = 1
+	}
+	if exists, err := ProductExists(db, p.ProductID); !exists {
+		return 400, err
+	}
+	currency := strings.ToUpper(*p.Currency)
+	p.Currency = &currency
+
+	p.Active = true
+	p.CreatedAt = time.Now()
+	if err = db.Create(p).Error; err != nil {
+		logger(db).Error("QCreatePlan - ", err)
+		return 400, err
+	}
+	return 200, nil
+}
+
+// QGetPlans - Get all Plans (optional only active)
+func (p *Plan) QGetPlans(db *gorm.DB, page pagination.Params, active bool) ([]Plan, int, error) {
+	var plans []Plan
+	query := db.Model(&Plan{})
+	if active {
+		query = query.Where("active=?", true)
+	}
+	if err := page.Query(query).Find(&plans).Error; err != nil {
+		logger(db).Error("QGetPlans - ", err)
+		return plans, 500, err
+	}
+	return plans, 200, nil
+}
+
+// QGetPlan - Get Plan by ID
+func (p *Plan) QGetPlan(db *gorm.DB) (int, error) {
+	if err := db.Where("id = ?", p.ID).First(&p).Error; err != nil {
+		logger(db).Error("QGetPlan - ", err)
+		return 400, err
+	}
+	return 200, nil
+}
+
+// QDeactivatePlan - Stop offering the Plan, existing subscriptions keep renewing
+func (p *Plan) QDeactivatePlan(db *gorm.DB) (int, error) {
+	if code, err := p.QGetPlan(db); err != nil {
+		return code, err
+	}
+	p.Active = false
+	p.UpdatedAt = time.Now()
+	if err := db.Model(&p).Select("active", "updated_at").Updates(p).Error; err != nil {
+		logger(db).Error("QDeactivatePlan - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
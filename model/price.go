@@ -0,0 +1,109 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductPrice - price of a Product in one currency over time
+//
+// The current price in each currency has EffectiveTo = nil. Orders keep a
+// reference to the price effective when they were placed.
+type ProductPrice struct {
+	ID            int        `json:"id" gorm:"primaryKey" example:"1"`
+	ProductID     int        `json:"product_id" gorm:"column:product_id;index" example:"1"`
+	Amount        float64    `json:"amount" example:"5000.00"`
+	Currency      *string    `json:"currency" example:"USD" validate:"nonzero,min=3,max=3"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+}
+
+func (ProductPrice) TableName() string {
+	return "product_price"
+}
+
+// QCreatePrice - Insert into product_price
+//
+// Closes the current price of the product in the same currency and inserts the new one
+func (pp *ProductPrice) QCreatePrice(db *gorm.DB) (int, error) {
+	if err := validate(pp); err != nil || pp.Amount <= 0 {
+		if err == nil {
+			err = errors.New("price amount must be greater than 0")
+		}
+		logger(db).Error("QCreatePrice - ", err)
+		return 400, err
+	}
+	currency := strings.ToUpper(*pp.Currency)
+	pp.Currency = &currency
+
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ProductPrice{}).Where("product_id=?", pp.ProductID).Where("currency=?", currency).
+			Where("effective_to IS NULL").Update("effective_to", now).Error; err != nil {
+			return err
+		}
+		pp.EffectiveFrom = now
+		pp.EffectiveTo = nil
+		return tx.Create(pp).Error
+	})
+	if err != nil {
+		logger(db).Error("QCreatePrice - ", err)
+		return 400, err
+	}
+	return 200, nil
+}
+
+// QGetCurrentPrice - Get the price in effect for ProductPrice.ProductID in ProductPrice.Currency
+func (pp *ProductPrice) QGetCurrentPrice(db *gorm.DB) (int, error) {
+	if pp.Currency == nil {
+		return 400, errors.New("price currency is required")
+	}
+	if err := db.Where("product_id=?", pp.ProductID).Where("currency=?", strings.ToUpper(*pp.Currency)).
+		Where("effective_to IS NULL").Order("effective_from desc").First(&pp).Error; err != nil {
+		logger(db).Error("QGetCurrentPrice - ", err)
+		return 400, err
+	}
+	return 200, nil
+}
+
+// QClosePrice - Stop selling a product in ProductPrice.Currency
+func (pp *ProductPrice) QClosePrice(db *gorm.DB) (int, error) {
+	if code, err := pp.QGetCurrentPrice(db); err != nil {
+		return code, err
+	}
+	now := time.Now()
+	pp.EffectiveTo = &now
+	if err := db.Model(&pp).Update("effective_to", now).Error; err != nil {
+		logger(db).Error("QClosePrice - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetPriceHistory - Get all prices of a product (optional currency), newest first
+func (pp *ProductPrice) QGetPriceHistory(db *gorm.DB) ([]ProductPrice, int, error) {
+	var prices []ProductPrice
+	query := db.Where("product_id=?", pp.ProductID)
+	if pp.Currency != nil {
+		query = query.Where("currency=?", strings.ToUpper(*pp.Currency))
+	}
+	if err := query.Order("effective_from desc").Find(&prices).Error; err != nil {
+		logger(db).Error("QGetPriceHistory - ", err)
+		return prices, 500, err
+	}
+	return prices, 200, nil
+}
+
+// QBackfillProductPrices - Seed price history for products created before it existed
+func QBackfillProductPrices(db *gorm.DB) error {
+	err := db.Exec(`INSERT INTO product_price(product_id, amount, currency, effective_from)
+	SELECT p.id, p.amount, p.currency, p.created_at FROM product p
+	WHERE NOT EXISTS (SELECT 1 FROM product_price pp WHERE pp.product_id = p.id)`).Error
+	if err != nil {
+		logger(db).Error("QBackfillProductPrices - ", err)
+	}
+	return err
+}
@@ -1,10 +1,15 @@
 package model
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-	"gopkg.in/validator.v2"
+	"systempayment/apperror"
+	"systempayment/pagination"
+	"systempayment/tax"
+
 	"gorm.io/gorm"
 )
 
@@ -15,11 +20,22 @@ type Product struct {
 	Description *string        `json:"description" example:"Curso de Programacion" validate:"nonzero,min=6,max=100"`
 	Amount      float64        `json:"amount" example:"5000.00" validate:"nonzero"`
 	Currency    *string        `json:"currency" example:"USD" validate:"nonzero,min=3,max=3"`
+	Prices      []ProductPrice `json:"prices" gorm:"foreignKey:ProductID"`
+	Status      string         `json:"status" gorm:"default:active;index" example:"active"`
+	TaxCategory string         `json:"tax_category" gorm:"default:standard" example:"standard"`
+	MerchantID  int            `json:"merchant_id" gorm:"column:merchant_id;index" example:"1"`
 	CreatedAt   time.Time      `json:"-"`
 	UpdatedAt   time.Time      `json:"-"`
 	DeletedAt   gorm.DeletedAt `json:"-"`
 }
 
+// Product status
+const (
+	ProductDraft    = "draft"
+	ProductActive   = "active"
+	ProductArchived = "archived"
+)
+
 func (Product) TableName() string {
 	return "product"
 }
@@ -27,7 +43,7 @@ func (Product) TableName() string {
 func ProductExists(db *gorm.DB, id int) (bool, error) {
 	var p Product
 	if err := db.Table("product").Select("id").Where("id=?", id).First(&p).Error; err != nil {
-		log.Error("ProductExists - ", err)
+		logger(db).Error("ProductExists - ", err)
 		return false, err
 	}
 	return true, nil
@@ -38,34 +54,91 @@ func ProductExists(db *gorm.DB, id int) (bool, error) {
 // Inserts new product
 func (p *Product) QCreateProduct(db *gorm.DB) (int, error) {
 	var err error
-	if err = validator.Validate(p); err != nil {
-		log.Error("QCreateProduct - ", err)
+	if err = validate(p); err != nil {
+		logger(db).Error("QCreateProduct - ", err)
 		return 400, err
 	}
 
+	switch p.Status {
+	case "":
+		p.Status = ProductActive
+	case ProductDraft, ProductActive:
+	default:
+		return 400, errors.New("new products must be draft or active")
+	}
+
+	if p.TaxCategory == "" {
+		p.TaxCategory = tax.Standard
+	}
+	if !tax.ValidCategory(p.TaxCategory) {
+		return 400, fmt.Errorf("invalid tax category %q", p.TaxCategory)
+	}
+
+	var merchant = Merchant{ID: p.MerchantID}
+	if p.MerchantID == 0 {
+		if code, err := merchant.QDefaultMerchant(db); err != nil {
+			return code, err
+		}
+		p.MerchantID = merchant.ID
+	} else if _, err := merchant.QGetMerchant(db); err != nil {
+		return 400, errors.New("merchant not found")
+	}
+
+	// Amount/Currency is the default price, Prices adds other currencies
+	prices := append([]ProductPrice{{Amount: p.Amount, Currency: p.Currency}}, p.Prices...)
+	seen := map[string]bool{}
+	for _, price := range prices {
+		if price.Currency == nil {
+			return 400, errors.New("price currency is required")
+		}
+		currency := strings.ToUpper(*price.Currency)
+		if seen[currency] {
+			return 400, fmt.Errorf("duplicate price for currency %s", currency)
+		}
+		seen[currency] = true
+	}
+
 	p.CreatedAt = time.Now()
-	// Create product
-	if err = db.Create(p).Error; err != nil {
-		log.Error("QCreateProduct - ", err)
+	// Create product + prices
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Prices").Create(p).Error; err != nil {
+			return err
+		}
+		for _, price := range prices {
+			price.ProductID = p.ID
+			if _, err := price.QCreatePrice(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger(db).Error("QCreateProduct - ", err)
 		return 400, err
 	}
 
-	return 200, nil
+	return p.QGetProduct(db)
 }
 
-func (p *Product) QGetProducts(db *gorm.DB, start int, count int) ([]Product, int, error) {
+// QGetProducts - Get all Products (optional status)
+func (p *Product) QGetProducts(db *gorm.DB, page pagination.Params, status string) ([]Product, int, error) {
 	var products []Product
-	if err := db.Table("product").Select("*").Limit(count).Offset(start).Scan(&products).Error; err != nil {
-		log.Error("QGetProducts - ", err)
+	query := db.Model(&Product{}).Preload("Prices", "effective_to IS NULL")
+	if status != "" {
+		query = query.Where("status=?", status)
+	}
+	if err := page.Query(query).Find(&products).Error; err != nil {
+		logger(db).Error("QGetProducts - ", err)
 		return products, 400, err
 	}
 
 	return products, 200, nil
 }
 
+// QGetProduct - Get Product by ID with its current prices
 func (p *Product) QGetProduct(db *gorm.DB) (int, error) {
-	if err := db.Where("id = ?", p.ID).First(&p).Error; err != nil {
-		log.Error("QGetProduct - ", err)
+	if err := db.Preload("Prices", "effective_to IS NULL").Where("id = ?", p.ID).First(&p).Error; err != nil {
+		logger(db).Error("QGetProduct - ", err)
 		return 400, err
 	}
 	return 200, nil
@@ -73,15 +146,115 @@ func (p *Product) QGetProduct(db *gorm.DB) (int, error) {
 
 func (p *Product) QUpdateProduct(db *gorm.DB) (int, error) {
 	var err error
-	if err = validator.Validate(p); err != nil {
-		log.Error("QUpdateProduct - ", err)
+	if err = validate(p); err != nil {
+		logger(db).Error("QUpdateProduct - ", err)
 		return 400, err
 	}
 
+	if p.TaxCategory != "" && !tax.ValidCategory(p.TaxCategory) {
+		return 400, fmt.Errorf("invalid tax category %q", p.TaxCategory)
+	}
+
+	if p.MerchantID != 0 {
+		var merchant = Merchant{ID: p.MerchantID}
+		if _, err := merchant.QGetMerchant(db); err != nil {
+			return 400, errors.New("merchant not found")
+		}
+	}
+
+	// default price, a new default currency just adds a price in that currency
+	current := ProductPrice{ProductID: p.ID, Currency: p.Currency}
+	if _, err := current.QGetCurrentPrice(db); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 500, err
+	}
+
 	p.UpdatedAt = time.Now()
-	if err = db.Model(&p).Updates(p).Error; err != nil {
-		log.Error("QUpdateProduct - ", err)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// status only changes through QSetStatus, prices through QSetPrice
+		if err := tx.Model(&p).Omit("status", "Prices").Updates(p).Error; err != nil {
+			return err
+		}
+		if current.ID != 0 && current.Amount == p.Amount {
+			return nil
+		}
+		price := ProductPrice{ProductID: p.ID, Amount: p.Amount, Currency: p.Currency}
+		_, err := price.QCreatePrice(tx)
+		return err
+	})
+	if err != nil {
+		logger(db).Error("QUpdateProduct - ", err)
 		return 400, err
 	}
+	return p.QGetProduct(db)
+}
+
+// QSetPrice - Set the Product's price in ProductPrice.Currency
+//
+// Keeps Product.Amount in sync when it is the default currency
+func (p *Product) QSetPrice(db *gorm.DB, price *ProductPrice) (int, error) {
+	if code, err := p.QGetProduct(db); err != nil {
+		return code, err
+	}
+
+	price.ProductID = p.ID
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := price.QCreatePrice(tx); err != nil {
+			return err
+		}
+		if *price.Currency != strings.ToUpper(*p.Currency) {
+			return nil
+		}
+		return tx.Model(&p).Select("amount", "updated_at").
+			Updates(Product{Amount: price.Amount, UpdatedAt: time.Now()}).Error
+	})
+	if err != nil {
+		logger(db).Error("QSetPrice - ", err)
+		return 400, err
+	}
+	return p.QGetProduct(db)
+}
+
+// QRemovePrice - Stop selling the Product in a currency (not the default one)
+func (p *Product) QRemovePrice(db *gorm.DB, currency string) (int, error) {
+	if code, err := p.QGetProduct(db); err != nil {
+		return code, err
+	}
+	currency = strings.ToUpper(currency)
+	if currency == strings.ToUpper(*p.Currency) {
+		return 400, errors.New("can't remove the default currency price")
+	}
+
+	price := ProductPrice{ProductID: p.ID, Currency: &currency}
+	if code, err := price.QClosePrice(db); err != nil {
+		return code, err
+	}
+	return p.QGetProduct(db)
+}
+
+// QSetStatus - Move Product through draft -> active <-> archived
+func (p *Product) QSetStatus(db *gorm.DB, status string) (int, error) {
+	if code, err := p.QGetProduct(db); err != nil {
+		return code, err
+	}
+
+	switch status {
+	case ProductActive:
+		if p.Status == ProductActive {
+			return 400, apperror.Conflict("invalid_status_transition", "product already active")
+		}
+	case ProductArchived:
+		if p.Status == ProductArchived {
+			return 400, apperror.Conflict("invalid_status_transition", "product already archived")
+		}
+	default:
+		return 400, errors.New("invalid product status")
+	}
+
+	p.Status = status
+	p.UpdatedAt = time.Now()
+	if err := db.Model(&p).Select("status", "updated_at").Updates(p).Error; err != nil {
+		logger(db).Error("QSetStatus - ", err)
+		return 500, err
+	}
 	return 200, nil
 }
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RateLimit - requests counted for a key in the window starting at
+// WindowStart, shared by every instance of the API
+type RateLimit struct {
+	Key         string    `gorm:"primaryKey"`
+	WindowStart time.Time `gorm:"primaryKey"`
+	Count       int
+	ExpiresAt   time.Time `gorm:"index"`
+}
+
+func (RateLimit) TableName() string {
+	return "rate_limit"
+}
+
+// QIncrementRateLimit - Counts a request for key in the window, returns the
+// requests counted so far
+func QIncrementRateLimit(db *gorm.DB, key string, windowStart time.Time, window time.Duration) (int, int, error) {
+	var count int
+	err := db.Raw(`INSERT INTO rate_limit (key, window_start, count, expires_at) VALUES (?, ?, 1, ?)
+		ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limit.count + 1
+		RETURNING count`, key, windowStart, windowStart.Add(window)).Scan(&count).Error
+	if err != nil {
+		logger(db).Error("QIncrementRateLimit - ", err)
+		return 0, 500, err
+	}
+	return count, 200, nil
+}
+
+// QDeleteExpiredRateLimits - Deletes the windows already over
+func QDeleteExpiredRateLimits(db *gorm.DB) (int64, int, error) {
+	result := db.Where("expires_at < ?", time.Now()).Delete(&RateLimit{})
+	if result.Error != nil {
+		logger(db).Error("QDeleteExpiredRateLimits - ", result.Error)
+		return 0, 500, result.Error
+	}
+	return result.RowsAffected, 200, nil
+}
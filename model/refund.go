@@ -0,0 +1,111 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Refund - money returned to the payer's card for a Payment
+type Refund struct {
+	ID        int     `json:"id" gorm:"primaryKey" example:"1"`
+	PaymentID int     `json:"payment_id" gorm:"column:payment_id;index" example:"1"`
+	DlocalID  *string `json:"dlocal_id" gorm:"column:dlocal_id" example:"REF-15-1a2b3c"`
+	Amount    float64 `json:"amount" example:"100.00"`
+	Currency  *string `json:"currency" example:"USD"`
+	Status    string  `json:"status" example:"success" enums:"requested,success,pending,failed"`
+	Reason    string  `json:"reason" example:"customer request"`
+	// dlocal's answer when the refund failed
+	Error     string    `json:"error,omitempty" example:"dlocal payment not approved (402 REJECTED): Insufficient funds"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Refund status. Requested refunds were sent to dlocal and its answer isn't
+// saved yet, pending ones wait for dlocal to settle them. Their amount can't
+// be refunded again meanwhile, and it's only taken from the payment and the
+// ledger once it's success
+const (
+	RefundRequested = "requested"
+	RefundSuccess   = "success"
+	RefundPending   = "pending"
+	RefundFailed    = "failed"
+)
+
+func (Refund) TableName() string {
+	return "refund"
+}
+
+// QCreateRefund - Insert into refund
+func (r *Refund) QCreateRefund(db *gorm.DB) (int, error) {
+	r.CreatedAt = time.Now()
+	if err := db.Create(r).Error; err != nil {
+		logger(db).Error("QCreateRefund - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QUpdateRefund - Saves dlocal's answer to a requested refund
+func (r *Refund) QUpdateRefund(db *gorm.DB) (int, error) {
+	if err := db.Model(&r).Select("dlocal_id", "status", "error").Updates(r).Error; err != nil {
+		logger(db).Error("QUpdateRefund - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QUnsettledRefunds - Amount of the payment's refunds waiting for dlocal,
+// requested or pending
+func QUnsettledRefunds(db *gorm.DB, paymentID int) (float64, error) {
+	var amount float64
+	err := db.Model(&Refund{}).Where("payment_id=? AND status IN ?", paymentID, []string{RefundRequested, RefundPending}).
+		Select("COALESCE(SUM(amount), 0)").Scan(&amount).Error
+	if err != nil {
+		logger(db).Error("QUnsettledRefunds - ", err)
+	}
+	return amount, err
+}
+
+// QPendingRefunds - IDs of the refunds dlocal hasn't settled yet
+func QPendingRefunds(db *gorm.DB) ([]int, error) {
+	var ids []int
+	err := db.Model(&Refund{}).Where("status=? AND dlocal_id IS NOT NULL", RefundPending).
+		Order("id").Pluck("id", &ids).Error
+	if err != nil {
+		logger(db).Error("QPendingRefunds - ", err)
+	}
+	return ids, err
+}
+
+// QGetRefund - Get Refund by ID
+func (r *Refund) QGetRefund(db *gorm.DB) (int, error) {
+	if err := db.Where("id=?", r.ID).First(&r).Error; err != nil {
+		logger(db).Error("QGetRefund - ", err)
+		return 400, err
+	}
+	return 200, nil
+}
+
+// QLockRefund - Lock a refund by ID. Must run inside a transaction
+func (r *Refund) QLockRefund(tx *gorm.DB) (int, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", r.ID).First(&r).Error; err != nil {
+		logger(tx).Error("QLockRefund - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetRefunds - Get refunds of a payment
+func (r *Refund) QGetRefunds(db *gorm.DB) ([]Refund, int, error) {
+	var refunds []Refund
+	if err := db.Where("payment_id=?", r.PaymentID).Order("id").Find(&refunds).Error; err != nil {
+		logger(db).Error("QGetRefunds - ", err)
+		return refunds, 500, err
+	}
+	return refunds, 200, nil
+}
@@ -0,0 +1,291 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReportFilter - period (To excluded) and optional currency of a report
+type ReportFilter struct {
+	From     time.Time
+	To       time.Time
+	Currency string
+}
+
+// Revenue report grouping
+const (
+	ReportDay   = "day"
+	ReportWeek  = "week"
+	ReportMonth = "month"
+)
+
+// ReportAbandonedDays - days an installment is overdue before the order counts as abandoned
+const ReportAbandonedDays = 30
+
+// Revenue - money collected in a period, refunds and chargebacks (not won)
+// are counted when they happened
+type Revenue struct {
+	Period      time.Time `json:"period"`
+	Currency    string    `json:"currency" example:"USD"`
+	Payments    int       `json:"payments" example:"42"`
+	Gross       float64   `json:"gross" example:"4200"`
+	Tax         float64   `json:"tax" example:"757.38"`
+	Refunds     float64   `json:"refunds" example:"100"`
+	Chargebacks float64   `json:"chargebacks" example:"0"`
+	Net         float64   `json:"net" example:"4100"`
+}
+
+// MRR - recurring revenue of the active auto orders (their installment)
+// and subscriptions (plan amount per month)
+type MRR struct {
+	Currency           string  `json:"currency" example:"USD"`
+	Orders             int     `json:"orders" example:"12"`
+	OrdersAmount       float64 `json:"orders_amount" example:"400"`
+	Subscriptions      int     `json:"subscriptions" example:"30"`
+	SubscriptionAmount float64 `json:"subscription_amount" example:"450"`
+	Total              float64 `json:"total" example:"850"`
+}
+
+// RecurringMonth - collected from auto orders and subscriptions in a month
+type RecurringMonth struct {
+	Month     time.Time `json:"month"`
+	Currency  string    `json:"currency" example:"USD"`
+	Payments  int       `json:"payments" example:"40"`
+	Collected float64   `json:"collected" example:"820"`
+}
+
+// MRRReport - current MRR and what was collected each month of the period
+type MRRReport struct {
+	Current []MRR            `json:"current"`
+	Months  []RecurringMonth `json:"months"`
+}
+
+// OrdersReport - outcome of the orders created in the period, abandoned
+// orders have an installment overdue more than the abandoned days
+type OrdersReport struct {
+	Currency  string  `json:"currency" example:"USD"`
+	Created   int     `json:"created" example:"100"`
+	Finished  int     `json:"finished" example:"60"`
+	Active    int     `json:"active" example:"32"`
+	Abandoned int     `json:"abandoned" example:"8"`
+	ChurnRate float64 `json:"churn_rate" example:"0.08"`
+}
+
+// AgingBucket - unfinished orders by days their installment is overdue
+type AgingBucket struct {
+	Bucket      string  `json:"bucket" example:"1-30" enums:"1-30,31-60,61-90,90+"`
+	Currency    string  `json:"currency" example:"USD"`
+	Orders      int     `json:"orders" example:"5"`
+	Overdue     float64 `json:"overdue" example:"250"`
+	Outstanding float64 `json:"outstanding" example:"1200"`
+}
+
+// Aging buckets in order
+var agingBuckets = []string{"1-30", "31-60", "61-90", "90+"}
+
+// Amount of an order's installment in SQL, like Order.InstallmentAmount
+const installmentSQL = `ROUND(("order".amount / "order".total_fees)::numeric, 2)`
+
+func (f ReportFilter) currency(query *gorm.DB, column string) *gorm.DB {
+	if f.Currency == "" {
+		return query
+	}
+	return query.Where(column+"=?", f.Currency)
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// QRevenueReport - Revenue by day, week or month and currency
+func QRevenueReport(db *gorm.DB, f ReportFilter, interval string) ([]Revenue, int, error) {
+	if interval != ReportDay && interval != ReportWeek && interval != ReportMonth {
+		return nil, 400, fmt.Errorf("interval must be %s, %s or %s", ReportDay, ReportWeek, ReportMonth)
+	}
+
+	var payments []Revenue
+	query := db.Model(&Payment{}).
+		Select("date_trunc(?, created_at) AS period, currency, COUNT(*) AS payments, SUM(amount) AS gross, SUM(tax_amount) AS tax", interval).
+		Where("created_at >= ? AND created_at < ?", f.From, f.To)
+	if err := f.currency(query, "currency").Group("1, 2").Scan(&payments).Error; err != nil {
+		logger(db).Error("QRevenueReport - ", err)
+		return nil, 500, err
+	}
+
+	var refunds []Revenue
+	query = db.Model(&Refund{}).Select("date_trunc(?, created_at) AS period, currency, SUM(amount) AS refunds", interval).
+		Where("status=?", RefundSuccess).
+		Where("created_at >= ? AND created_at < ?", f.From, f.To)
+	if err := f.currency(query, "currency").Group("1, 2").Scan(&refunds).Error; err != nil {
+		logger(db).Error("QRevenueReport - ", err)
+		return nil, 500, err
+	}
+
+	var chargebacks []Revenue
+	query = db.Model(&Chargeback{}).Select("date_trunc(?, created_at) AS period, currency, SUM(amount) AS chargebacks", interval).
+		Where("created_at >= ? AND created_at < ?", f.From, f.To).Where("status<>?", ChargebackWon)
+	if err := f.currency(query, "currency").Group("1, 2").Scan(&chargebacks).Error; err != nil {
+		logger(db).Error("QRevenueReport - ", err)
+		return nil, 500, err
+	}
+
+	byKey := map[string]*Revenue{}
+	var report []*Revenue
+	row := func(r Revenue) *Revenue {
+		key := r.Period.Format(time.RFC3339) + r.Currency
+		if byKey[key] == nil {
+			byKey[key] = &Revenue{Period: r.Period, Currency: r.Currency}
+			report = append(report, byKey[key])
+		}
+		return byKey[key]
+	}
+	for _, p := range payments {
+		r := row(p)
+		r.Payments, r.Gross, r.Tax = p.Payments, p.Gross, p.Tax
+	}
+	for _, p := range refunds {
+		row(p).Refunds = p.Refunds
+	}
+	for _, p := range chargebacks {
+		row(p).Chargebacks = p.Chargebacks
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		if !report[i].Period.Equal(report[j].Period) {
+			return report[i].Period.Before(report[j].Period)
+		}
+		return report[i].Currency < report[j].Currency
+	})
+	revenue := make([]Revenue, 0, len(report))
+	for _, r := range report {
+		r.Gross, r.Tax, r.Refunds, r.Chargebacks = round(r.Gross), round(r.Tax), round(r.Refunds), round(r.Chargebacks)
+		r.Net = round(r.Gross - r.Refunds - r.Chargebacks)
+		revenue = append(revenue, *r)
+	}
+	return revenue, 200, nil
+}
+
+// QMRRReport - Current MRR by currency and recurring revenue collected
+// each month of the period
+func QMRRReport(db *gorm.DB, f ReportFilter) (MRRReport, int, error) {
+	report := MRRReport{Current: []MRR{}, Months: []RecurringMonth{}}
+
+	var orders []MRR
+	query := db.Model(&Order{}).
+		Select(`currency, COUNT(*) AS orders, SUM(`+installmentSQL+`) AS orders_amount`).
+		Where("auto=?", true).Where("finished=?", false)
+	if err := f.currency(query, "currency").Group("currency").Scan(&orders).Error; err != nil {
+		logger(db).Error("QMRRReport - ", err)
+		return report, 500, err
+	}
+
+	// plan amount per month
+	var subscriptions []MRR
+	query = db.Model(&Subscription{}).
+		Select(`plan.currency, COUNT(*) AS subscriptions, SUM(plan.amount * CASE plan."interval"
+			WHEN 'day' THEN 30.0 WHEN 'week' THEN 52.0 / 12 WHEN 'year' THEN 1.0 / 12 ELSE 1 END
+			/ GREATEST(plan.interval_count, 1)) AS subscription_amount`).
+		Joins("JOIN plan ON plan.id = subscription.plan_id").
+		Where("subscription.status IN ?", []string{SubscriptionActive, SubscriptionPastDue, SubscriptionRenewing, SubscriptionAttention, SubscriptionInReview})
+	if err := f.currency(query, "plan.currency").Group("plan.currency").Scan(&subscriptions).Error; err != nil {
+		logger(db).Error("QMRRReport - ", err)
+		return report, 500, err
+	}
+
+	byCurrency := map[string]int{}
+	for _, o := range orders {
+		byCurrency[o.Currency] = len(report.Current)
+		report.Current = append(report.Current, MRR{Currency: o.Currency, Orders: o.Orders, OrdersAmount: round(o.OrdersAmount)})
+	}
+	for _, s := range subscriptions {
+		i, ok := byCurrency[s.Currency]
+		if !ok {
+			i = len(report.Current)
+			byCurrency[s.Currency] = i
+			report.Current = append(report.Current, MRR{Currency: s.Currency})
+		}
+		report.Current[i].Subscriptions = s.Subscriptions
+		report.Current[i].SubscriptionAmount = round(s.SubscriptionAmount)
+	}
+	for i := range report.Current {
+		report.Current[i].Total = round(report.Current[i].OrdersAmount + report.Current[i].SubscriptionAmount)
+	}
+	sort.Slice(report.Current, func(i, j int) bool { return report.Current[i].Currency < report.Current[j].Currency })
+
+	query = db.Model(&Payment{}).
+		Select("date_trunc('month', payment.created_at) AS month, payment.currency, COUNT(*) AS payments, SUM(payment.amount) AS collected").
+		Joins(`JOIN "order" ON "order".id = payment.order_id`).
+		Where(`("order".auto OR "order".auto_suspended OR "order".subscription_id IS NOT NULL)`).
+		Where("payment.created_at >= ? AND payment.created_at < ?", f.From, f.To)
+	if err := f.currency(query, "payment.currency").Group("1, 2").Order("1, 2").Scan(&report.Months).Error; err != nil {
+		logger(db).Error("QMRRReport - ", err)
+		return report, 500, err
+	}
+	for i := range report.Months {
+		report.Months[i].Collected = round(report.Months[i].Collected)
+	}
+	return report, 200, nil
+}
+
+// QOrdersReport - Finished, active and abandoned orders created in the period
+func QOrdersReport(db *gorm.DB, f ReportFilter, abandonedDays int) ([]OrdersReport, int, error) {
+	report := []OrdersReport{}
+	abandoned := time.Now().AddDate(0, 0, -abandonedDays)
+	query := db.Model(&Order{}).
+		Select(`currency, COUNT(*) AS created, COUNT(*) FILTER (WHERE finished) AS finished,
+			COUNT(*) FILTER (WHERE NOT finished AND next_payment < ?) AS abandoned`, abandoned).
+		Where("created_at >= ? AND created_at < ?", f.From, f.To)
+	if err := f.currency(query, "currency").Group("currency").Order("currency").Scan(&report).Error; err != nil {
+		logger(db).Error("QOrdersReport - ", err)
+		return report, 500, err
+	}
+	for i, r := range report {
+		report[i].Active = r.Created - r.Finished - r.Abandoned
+		if r.Created > 0 {
+			report[i].ChurnRate = math.Round(float64(r.Abandoned)/float64(r.Created)*10000) / 10000
+		}
+	}
+	return report, 200, nil
+}
+
+// QAgingReport - Overdue installments of unfinished orders by days late,
+// for installments due in the period
+func QAgingReport(db *gorm.DB, f ReportFilter) ([]AgingBucket, int, error) {
+	report := []AgingBucket{}
+	now := time.Now()
+	to := f.To
+	if to.After(now) {
+		to = now
+	}
+	query := db.Model(&Order{}).
+		Select(`CASE WHEN next_payment >= ? THEN '1-30' WHEN next_payment >= ? THEN '31-60'
+			WHEN next_payment >= ? THEN '61-90' ELSE '90+' END AS bucket,
+			currency, COUNT(*) AS orders, SUM(`+installmentSQL+`) AS overdue,
+			SUM("order".amount - `+installmentSQL+` * (current_fee - 1)) AS outstanding`,
+			now.AddDate(0, 0, -30), now.AddDate(0, 0, -60), now.AddDate(0, 0, -90)).
+		Where("finished=?", false).
+		Where("next_payment >= ? AND next_payment < ?", f.From, to)
+	if err := f.currency(query, "currency").Group("1, 2").Scan(&report).Error; err != nil {
+		logger(db).Error("QAgingReport - ", err)
+		return report, 500, err
+	}
+
+	position := map[string]int{}
+	for i, bucket := range agingBuckets {
+		position[bucket] = i
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Bucket != report[j].Bucket {
+			return position[report[i].Bucket] < position[report[j].Bucket]
+		}
+		return report[i].Currency < report[j].Currency
+	})
+	for i := range report {
+		report[i].Overdue, report[i].Outstanding = round(report[i].Overdue), round(report[i].Outstanding)
+	}
+	return report, 200, nil
+}
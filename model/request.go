@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type PayerRequest struct {
 	Name          *string        `json:"name" example:"Jhon Doe"`
 	Email         *string        `json:"email" example:"jhondoe@mail.com"`
@@ -35,14 +37,92 @@ type PaymentRequest struct {
 }
 
 type OrderRequest struct {
-	ProductID int     `json:"product_id" example:"1"`
-	Currency  *string `json:"currency" validate:"nonzero,min=3,max=3"`
-	TotalFees int     `json:"total_fees" validate:"nonzero" example:"3"`
+	ProductID  int     `json:"product_id" example:"1"`
+	Currency   *string `json:"currency" validate:"nonzero,min=3,max=3"`
+	TotalFees  int     `json:"total_fees" validate:"nonzero" example:"3"`
+	CouponCode *string `json:"coupon_code" example:"VERANO10"`
+}
+
+type CouponRequest struct {
+	Code           *string    `json:"code" example:"VERANO10"`
+	Type           string     `json:"type" example:"percentage" enums:"percentage,fixed"`
+	Value          float64    `json:"value" example:"10"`
+	Currency       *string    `json:"currency" example:"USD"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	MaxRedemptions int        `json:"max_redemptions" example:"100"`
+	PerPayerLimit  int        `json:"per_payer_limit" example:"1"`
+	ProductIDs     []int      `json:"product_ids"`
+}
+
+type MerchantRequest struct {
+	Name          *string `json:"name" example:"Academia Online"`
+	TaxID         *string `json:"tax_id" example:"214563780018"`
+	Address       *string `json:"address" example:"Av. 18 de Julio 1234, Montevideo"`
+	Email         *string `json:"email" example:"facturacion@academia.com"`
+	Country       *string `json:"country" example:"UY"`
+	InvoicePrefix string  `json:"invoice_prefix" example:"A"`
+}
+
+type WebhookEndpointRequest struct {
+	MerchantID *int     `json:"merchant_id" example:"1"`
+	URL        *string  `json:"url" example:"https://shop.example.com/webhooks/payments"`
+	Events     []string `json:"events" example:"payment.succeeded,order.finished"`
+}
+
+type RefundRequest struct {
+	Amount float64 `json:"amount" example:"50.00"`
+	Reason string  `json:"reason" example:"customer request"`
+}
+
+type ChargebackRequest struct {
+	PaymentID     int        `json:"payment_id" example:"1"`
+	DlocalID      *string    `json:"dlocal_id" example:"CHAR42-2023"`
+	ReasonCode    string     `json:"reason_code" example:"4837"`
+	Reason        string     `json:"reason" example:"No cardholder authorization"`
+	Amount        float64    `json:"amount" example:"100"`
+	EvidenceDueAt *time.Time `json:"evidence_due_at"`
+}
+
+type ChargebackStatusRequest struct {
+	Status string `json:"status" example:"won" enums:"evidence_submitted,won,lost"`
+	Note   string `json:"note" example:"Issuer accepted the delivery proof"`
+}
+
+type ResolveRequest struct {
+	Note string `json:"note" example:"fee adjustment confirmed by dlocal"`
+}
+
+type ReviewRequest struct {
+	Note string `json:"note" example:"payer confirmed by phone"`
 }
 
 type ProductRequest struct {
-	Name        *string `json:"name" example:"programacion en C" validate:"nonzero,min=6,max=100"`
-	Description *string `json:"description" example:"Curso de Programacion" validate:"nonzero,min=6,max=100"`
-	Amount      float64 `json:"amount" example:"5000.00" validate:"nonzero"`
-	Currency    *string `json:"currency" example:"USD" validate:"nonzero,min=3,max=3"`
+	Name        *string        `json:"name" example:"programacion en C" validate:"nonzero,min=6,max=100"`
+	Description *string        `json:"description" example:"Curso de Programacion" validate:"nonzero,min=6,max=100"`
+	Amount      float64        `json:"amount" example:"5000.00" validate:"nonzero"`
+	Currency    *string        `json:"currency" example:"USD" validate:"nonzero,min=3,max=3"`
+	Prices      []PriceRequest `json:"prices"`
+	Status      string         `json:"status" example:"active" enums:"draft,active"`
+	TaxCategory string         `json:"tax_category" example:"standard" enums:"standard,reduced,service,exempt"`
+	MerchantID  int            `json:"merchant_id" example:"1"`
+}
+
+type PriceRequest struct {
+	Amount   float64 `json:"amount" example:"25000.00" validate:"nonzero"`
+	Currency *string `json:"currency" example:"UYU" validate:"nonzero,min=3,max=3"`
+}
+
+type PlanRequest struct {
+	ProductID     int     `json:"product_id" example:"1"`
+	Name          *string `json:"name" example:"Membresia mensual"`
+	Amount        float64 `json:"amount" example:"15.00"`
+	Currency      *string `json:"currency" example:"USD"`
+	Interval      string  `json:"interval" example:"month" enums:"day,week,month,year"`
+	IntervalCount int     `json:"interval_count" example:"1"`
+	TrialDays     int     `json:"trial_days" example:"7"`
+}
+
+type SubscriptionRequest struct {
+	PlanID int `json:"plan_id" example:"1"`
+	CardID int `json:"card_id" example:"1"`
 }
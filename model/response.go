@@ -2,6 +2,8 @@ package model
 
 import (
 	"time"
+
+	"systempayment/tax"
 )
 
 type PayerResponse struct {
@@ -37,20 +39,30 @@ type CardResponse struct {
 }
 
 type PaymentResponse struct {
-	ID                *string   `json:"id" example:"PAY2323243343543"`
-	Amount            float64   `json:"amount" example:"125"`
-	Currency          *string   `json:"currency" example:"USD"`
-	Country           *string   `json:"country" example:"UY"`
-	PaymentMethodID   *string   `json:"payment_method_id" example:"CARD"`
-	PaymentMethodFlow *string   `json:"payment_method_flow"`
-	OrderNumber       *string   `json:"order_number"`
-	Card              Card      `json:"card"`
-	CreatedAt         time.Time `json:"created_at"`
+	ID                *string    `json:"id" example:"PAY2323243343543"`
+	Amount            float64    `json:"amount" example:"125"`
+	Installment       int        `json:"installment" example:"1"`
+	NetAmount         float64    `json:"net_amount" example:"102.46"`
+	TaxAmount         float64    `json:"tax_amount" example:"22.54"`
+	TaxLines          []tax.Line `json:"tax_lines"`
+	Currency          *string    `json:"currency" example:"USD"`
+	Country           *string    `json:"country" example:"UY"`
+	PaymentMethodID   *string    `json:"payment_method_id" example:"CARD"`
+	PaymentMethodFlow *string    `json:"payment_method_flow"`
+	OrderNumber       *string    `json:"order_number"`
+	Card              Card       `json:"card"`
+	CreatedAt         time.Time  `json:"created_at"`
 }
 
 type OrderResponse struct {
 	ID        int               `json:"id"`
 	Product   Product           `json:"product" `
+	Amount    float64           `json:"amount"`
+	Subtotal  float64           `json:"subtotal"`
+	Discount  float64           `json:"discount"`
+	NetAmount float64           `json:"net_amount"`
+	TaxAmount float64           `json:"tax_amount"`
+	TaxLines  []tax.Line        `json:"tax_lines"`
 	TotalFees int               `json:"total_fees"`
 	Payments  []PaymentResponse `json:"payments"`
 	Finished  bool              `json:"finished"`
@@ -58,11 +70,23 @@ type OrderResponse struct {
 }
 
 type ProductResponse struct {
-	ID          int       `json:"id" example:"1"`
-	Name        *string   `json:"name" example:"programacion en C" validate:"nonzero,min=6,max=100"`
-	Description *string   `json:"description" example:"Curso de Programacion" validate:"nonzero,min=6,max=100"`
-	Amount      float64   `json:"amount" example:"5000.00"`
-	Currency    *string   `json:"currency" example:"USD" validate:"nonzero,min=3,max=3"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int            `json:"id" example:"1"`
+	Name        *string        `json:"name" example:"programacion en C" validate:"nonzero,min=6,max=100"`
+	Description *string        `json:"description" example:"Curso de Programacion" validate:"nonzero,min=6,max=100"`
+	Amount      float64        `json:"amount" example:"5000.00"`
+	Currency    *string        `json:"currency" example:"USD" validate:"nonzero,min=3,max=3"`
+	Prices      []ProductPrice `json:"prices"`
+	Status      string         `json:"status" example:"active"`
+	TaxCategory string         `json:"tax_category" example:"standard"`
+	MerchantID  int            `json:"merchant_id" example:"1"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// PayerExport - data subject access archive
+type PayerExport struct {
+	ExportedAt time.Time `json:"exported_at"`
+	Payer      Payer     `json:"payer"`
+	Cards      []Card    `json:"cards"`
+	Orders     []Order   `json:"orders"`
 }
@@ -0,0 +1,201 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"systempayment/apperror"
+	"systempayment/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SettlementReport - dlocal settlement file imported and reconciled against payment
+type SettlementReport struct {
+	ID            int       `json:"id" gorm:"primaryKey" example:"1"`
+	FileName      string    `json:"file_name" example:"settlement_2023-02-20.csv"`
+	Checksum      string    `json:"-" gorm:"uniqueIndex"`
+	PeriodFrom    time.Time `json:"period_from"`
+	PeriodTo      time.Time `json:"period_to"`
+	Rows          int       `json:"rows" example:"120"`
+	Skipped       int       `json:"skipped" example:"3"`
+	Matched       int       `json:"matched" example:"115"`
+	Discrepancies int       `json:"discrepancies" example:"2"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SettlementDiscrepancy - difference between a report and the payment table
+type SettlementDiscrepancy struct {
+	ID          int        `json:"id" gorm:"primaryKey" example:"1"`
+	ReportID    int        `json:"report_id" gorm:"column:report_id;index" example:"1"`
+	Kind        string     `json:"kind" gorm:"index" example:"amount_mismatch" enums:"missing,extra,amount_mismatch"`
+	PaymentID   *int       `json:"payment_id,omitempty" gorm:"column:payment_id;index" example:"1"`
+	DlocalID    string     `json:"dlocal_id,omitempty" example:"D-4-cf2d3e7a"`
+	OrderNumber string     `json:"order_number,omitempty"`
+	Currency    string     `json:"currency" example:"USD"`
+	Recorded    float64    `json:"recorded" example:"100"`
+	Settled     float64    `json:"settled" example:"99.5"`
+	Resolved    bool       `json:"resolved" gorm:"default:false;index"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Discrepancy kinds
+const (
+	// recorded payment not in any settlement of its period
+	DiscrepancyMissing = "missing"
+	// settled payment that wasn't recorded
+	DiscrepancyExtra = "extra"
+	// settled with a different amount or currency
+	DiscrepancyAmountMismatch = "amount_mismatch"
+)
+
+func (SettlementReport) TableName() string {
+	return "settlement_report"
+}
+
+func (SettlementDiscrepancy) TableName() string {
+	return "settlement_discrepancy"
+}
+
+// QSettlementImported - true if a file with the checksum was already imported
+func QSettlementImported(db *gorm.DB, checksum string) (bool, error) {
+	var count int64
+	if err := db.Model(&SettlementReport{}).Where("checksum=?", checksum).Count(&count).Error; err != nil {
+		logger(db).Error("QSettlementImported - ", err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// QFindSettledPayment - Payment of a settlement row, by dlocal id or else
+// by order number (several installments share it, the one with the same
+// amount not settled yet is preferred)
+func QFindSettledPayment(db *gorm.DB, dlocalID string, orderNumber string, amount float64) (Payment, error) {
+	var payment Payment
+	if dlocalID != "" {
+		err := db.Where("dlocal_id=?", dlocalID).First(&payment).Error
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return payment, err
+		}
+	}
+	if orderNumber == "" {
+		return payment, gorm.ErrRecordNotFound
+	}
+	return payment, db.Where("order_number=?", orderNumber).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "settlement_report_id IS NOT NULL, ABS(amount - ?), id",
+			Vars: []interface{}{amount},
+		}}).First(&payment).Error
+}
+
+// Settled - marks the payment as included in a settlement report
+func (p *Payment) Settled(db *gorm.DB, reportID int) error {
+	now := time.Now()
+	p.SettlementReportID = &reportID
+	p.SettledAt = &now
+	if err := db.Model(&p).Select("settlement_report_id", "settled_at").Updates(p).Error; err != nil {
+		logger(db).Error("Payment.Settled - ", err)
+		return err
+	}
+	return nil
+}
+
+// QGetUnsettledPayments - Payments made in the period no report included,
+// except those already flagged as missing
+func QGetUnsettledPayments(db *gorm.DB, from time.Time, to time.Time) ([]Payment, error) {
+	var payments []Payment
+	err := db.Where("settlement_report_id IS NULL").Where("created_at BETWEEN ? AND ?", from, to).
+		Where(`NOT EXISTS (SELECT 1 FROM settlement_discrepancy d WHERE d.payment_id = payment.id
+		AND d.kind = ? AND NOT d.resolved)`, DiscrepancyMissing).
+		Order("id").Find(&payments).Error
+	if err != nil {
+		logger(db).Error("QGetUnsettledPayments - ", err)
+	}
+	return payments, err
+}
+
+// QResolveMissing - Resolves the missing discrepancies of a payment that
+// a later report included
+func QResolveMissing(db *gorm.DB, paymentID int, reportID int) error {
+	err := db.Model(&SettlementDiscrepancy{}).Where("payment_id=?", paymentID).
+		Where("kind=?", DiscrepancyMissing).Where("resolved=?", false).
+		Updates(map[string]interface{}{
+			"resolved":    true,
+			"resolved_at": time.Now(),
+			"note":        fmt.Sprintf("settled in report %d", reportID),
+		}).Error
+	if err != nil {
+		logger(db).Error("QResolveMissing - ", err)
+	}
+	return err
+}
+
+// QGetSettlementReports - Get a page of imported reports
+func (r *SettlementReport) QGetSettlementReports(db *gorm.DB, page pagination.Params) ([]SettlementReport, int, error) {
+	var reports []SettlementReport
+	if err := page.Query(db.Model(&SettlementReport{})).Find(&reports).Error; err != nil {
+		logger(db).Error("QGetSettlementReports - ", err)
+		return reports, 500, err
+	}
+	return reports, 200, nil
+}
+
+// QGetSettlementReport - Get report by ID
+func (r *SettlementReport) QGetSettlementReport(db *gorm.DB) (int, error) {
+	if err := db.Where("id=?", r.ID).First(&r).Error; err != nil {
+		logger(db).Error("QGetSettlementReport - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetDiscrepancies - Get discrepancies (optional report, kind and resolved)
+func (d *SettlementDiscrepancy) QGetDiscrepancies(db *gorm.DB, page pagination.Params, reportID int, kind string, resolved *bool) ([]SettlementDiscrepancy, int, error) {
+	var discrepancies []SettlementDiscrepancy
+	query := db.Model(&SettlementDiscrepancy{})
+	if reportID != 0 {
+		query = query.Where("report_id=?", reportID)
+	}
+	if kind != "" {
+		query = query.Where("kind=?", kind)
+	}
+	if resolved != nil {
+		query = query.Where("resolved=?", *resolved)
+	}
+	if err := page.Query(query).Find(&discrepancies).Error; err != nil {
+		logger(db).Error("QGetDiscrepancies - ", err)
+		return discrepancies, 500, err
+	}
+	return discrepancies, 200, nil
+}
+
+// QResolve - Marks the discrepancy as resolved with a note
+func (d *SettlementDiscrepancy) QResolve(db *gorm.DB, note string) (int, error) {
+	if err := db.Where("id=?", d.ID).First(&d).Error; err != nil {
+		logger(db).Error("QResolve - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	if d.Resolved {
+		return 400, apperror.Conflict("discrepancy_resolved", "discrepancy already resolved")
+	}
+
+	now := time.Now()
+	d.Resolved = true
+	d.ResolvedAt = &now
+	d.Note = note
+	if err := db.Model(&d).Select("resolved", "resolved_at", "note").Updates(d).Error; err != nil {
+		logger(db).Error("QResolve - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
@@ -0,0 +1,345 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"systempayment/apperror"
+	"systempayment/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Subscription - Payer subscribed to a Plan, renewed at CurrentPeriodEnd
+// with the subscription's Card
+type Subscription struct {
+	ID                 int            `json:"id" gorm:"primaryKey" example:"1"`
+	PlanID             int            `json:"plan_id" gorm:"column:plan_id" example:"1" validate:"nonzero"`
+	Plan               Plan           `json:"plan"`
+	PayerID            int            `json:"payer_id" gorm:"column:payer_id;index" example:"1" validate:"nonzero"`
+	CardID             int            `json:"card_id" gorm:"column:card_id" example:"1"`
+	Status             string         `json:"status" gorm:"index" example:"active"`
+	TrialEnd           *time.Time     `json:"trial_end,omitempty"`
+	CurrentPeriodStart time.Time      `json:"current_period_start"`
+	CurrentPeriodEnd   time.Time      `json:"current_period_end" gorm:"index"`
+	CancelAtPeriodEnd  bool           `json:"cancel_at_period_end"`
+	CanceledAt         *time.Time     `json:"canceled_at,omitempty"`
+	PausedAt           *time.Time     `json:"paused_at,omitempty"`
+	FailedAttempts     int            `json:"failed_attempts"`
+	RetryAt            *time.Time     `json:"retry_at,omitempty"`
+	Orders             []Order        `json:"orders,omitempty"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-"`
+}
+
+// Subscription status
+const (
+	SubscriptionTrialing = "trialing"
+	SubscriptionActive   = "active"
+	SubscriptionPastDue  = "past_due"
+	SubscriptionPaused   = "paused"
+	SubscriptionCanceled = "canceled"
+	// being charged, out of the renewals meanwhile
+	SubscriptionRenewing = "renewing"
+	// charged, or maybe charged, but the renewal couldn't be saved, needs
+	// manual attention
+	SubscriptionAttention = "needs_attention"
+	// renewal held by the risk rules, waiting for the review
+	SubscriptionInReview = "in_review"
+)
+
+// Failed renewals are retried after SubscriptionRetryDelay, the subscription
+// is canceled after SubscriptionMaxAttempts
+var (
+	SubscriptionRetryDelay  = 24 * time.Hour
+	SubscriptionMaxAttempts = 3
+)
+
+func (Subscription) TableName() string {
+	return "subscription"
+}
+
+// QCreateSubscription - Insert into subscription
+//
+// Starts the trial if the plan has one, otherwise the first period is due now
+func (s *Subscription) QCreateSubscription(db *gorm.DB) (int, error) {
+	if exists, err := PayerExists(db, s.PayerID); !exists {
+		return 400, err
+	}
+	s.Plan = Plan{ID: s.PlanID}
+	if code, err := s.Plan.QGetPlan(db); err != nil {
+		return code, err
+	}
+	if !s.Plan.Active {
+		return 400, apperror.Unprocessable("plan_not_active", "plan is not active")
+	}
+
+	card := Card{ID: s.CardID}
+	if _, err := card.QGetCard(db); err != nil {
+		return 400, errors.New("card not found")
+	}
+	if card.PayerID != s.PayerID {
+		return 400, errors.New("invalid card id")
+	}
+
+	now := time.Now()
+	s.CurrentPeriodStart = now
+	s.CurrentPeriodEnd = now
+	s.Status = SubscriptionActive
+	if s.Plan.TrialDays > 0 {
+		trialEnd := now.AddDate(0, 0, s.Plan.TrialDays)
+		s.TrialEnd = &trialEnd
+		s.CurrentPeriodEnd = trialEnd
+		s.Status = SubscriptionTrialing
+	}
+	s.CreatedAt = now
+
+	if err := db.Omit("Plan", "Orders").Create(s).Error; err != nil {
+		logger(db).Error("QCreateSubscription - ", err)
+		return 400, err
+	}
+	return 200, nil
+}
+
+// QGetSubscription - Get Subscription by ID with its Plan and renewal Orders
+func (s *Subscription) QGetSubscription(db *gorm.DB) (int, error) {
+	if err := db.Preload("Plan").Preload("Orders", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id desc")
+	}).Where("id=?", s.ID).First(&s).Error; err != nil {
+		logger(db).Error("QGetSubscription - ", err)
+		return 400, err
+	}
+	return 200, nil
+}
+
+// QGetSubscriptions - Get Subscriptions (optional payer_id and status)
+func (s *Subscription) QGetSubscriptions(db *gorm.DB, page pagination.Params) ([]Subscription, int, error) {
+	var subscriptions []Subscription
+	query := db.Model(&Subscription{}).Preload("Plan")
+	if s.PayerID != 0 {
+		query = query.Where("payer_id=?", s.PayerID)
+	}
+	if s.Status != "" {
+		query = query.Where("status=?", s.Status)
+	}
+	if err := page.Query(query).Find(&subscriptions).Error; err != nil {
+		logger(db).Error("QGetSubscriptions - ", err)
+		return subscriptions, 500, err
+	}
+	return subscriptions, 200, nil
+}
+
+// QGetDueSubscriptions - IDs of subscriptions whose period ended and are not waiting for a retry
+func QGetDueSubscriptions(db *gorm.DB, now time.Time) ([]int, error) {
+	var ids []int
+	err := db.Model(&Subscription{}).
+		Where("status IN ?", []string{SubscriptionTrialing, SubscriptionActive, SubscriptionPastDue}).
+		Where("current_period_end<=?", now).
+		Where("retry_at IS NULL OR retry_at<=?", now).
+		// automatic charges are suspended while the payer disputes a payment
+		Where("NOT EXISTS (SELECT 1 FROM chargeback c WHERE c.payer_id = subscription.payer_id AND c.status IN ?)",
+			[]string{ChargebackOpen, ChargebackEvidenceSubmitted}).
+		Order("current_period_end").Pluck("id", &ids).Error
+	if err != nil {
+		logger(db).Error("QGetDueSubscriptions - ", err)
+	}
+	return ids, err
+}
+
+// QLockDueSubscription - Lock a due subscription for renewal, skipping it if
+// another instance is already renewing it. Must run inside a transaction.
+func (s *Subscription) QLockDueSubscription(tx *gorm.DB, now time.Time) (int, error) {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("id=?", s.ID).
+		Where("status IN ?", []string{SubscriptionTrialing, SubscriptionActive, SubscriptionPastDue}).
+		Where("current_period_end<=?", now).First(&s).Error
+	if err != nil {
+		return 400, err
+	}
+	s.Plan = Plan{ID: s.PlanID}
+	return s.Plan.QGetPlan(tx)
+}
+
+// QRenewalOrder - Unpaid order for the current period, created if needed
+func (s *Subscription) QRenewalOrder(db *gorm.DB) (Order, int, error) {
+	var order Order
+	err := db.Where("subscription_id=?", s.ID).Where("finished=?", false).
+		Order("id desc").First(&order).Error
+	if err == nil {
+		return order, 200, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger(db).Error("QRenewalOrder - ", err)
+		return order, 500, err
+	}
+
+	product := Product{ID: s.Plan.ProductID}
+	if code, err := product.QGetProduct(db); err != nil {
+		return order, code, err
+	}
+
+	now := time.Now()
+	order = Order{
+		Amount:         s.Plan.Amount,
+		Subtotal:       s.Plan.Amount,
+		OrderId:        uuid.New().String(),
+		Currency:       s.Plan.Currency,
+		PayerID:        s.PayerID,
+		ProductID:      s.Plan.ProductID,
+		TotalFees:      1,
+		CurrentFee:     1,
+		NextPayment:    now,
+		SubscriptionID: &s.ID,
+		CreatedAt:      now,
+	}
+	if code, err := order.applyTax(db, product); err != nil {
+		return order, code, err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		return QPostOrderPlaced(tx, order)
+	})
+	if err != nil {
+		logger(db).Error("QRenewalOrder - ", err)
+		return order, 500, err
+	}
+	return order, 200, nil
+}
+
+// StartRenewal - Takes the subscription out of the renewals while it's
+// charged. Left renewing, it isn't charged again until someone checks it
+func (s *Subscription) StartRenewal(db *gorm.DB) (int, error) {
+	s.Status = SubscriptionRenewing
+	return s.save(db, "status")
+}
+
+// RenewalSucceeded - Starts the next period
+func (s *Subscription) RenewalSucceeded(db *gorm.DB) (int, error) {
+	s.CurrentPeriodStart = s.CurrentPeriodEnd
+	if now := time.Now(); s.Plan.NextPeriod(s.CurrentPeriodStart).Before(now) {
+		// renewed more than a period late, start from now
+		s.CurrentPeriodStart = now
+	}
+	s.CurrentPeriodEnd = s.Plan.NextPeriod(s.CurrentPeriodStart)
+	s.Status = SubscriptionActive
+	s.FailedAttempts = 0
+	s.RetryAt = nil
+	return s.save(db, "current_period_start", "current_period_end", "status", "failed_attempts", "retry_at")
+}
+
+// RenewalFailed - Retries later, cancels after SubscriptionMaxAttempts
+func (s *Subscription) RenewalFailed(db *gorm.DB) (int, error) {
+	s.FailedAttempts++
+	if s.FailedAttempts >= SubscriptionMaxAttempts {
+		now := time.Now()
+		s.Status = SubscriptionCanceled
+		s.CanceledAt = &now
+		s.RetryAt = nil
+	} else {
+		retryAt := time.Now().Add(SubscriptionRetryDelay)
+		s.Status = SubscriptionPastDue
+		s.RetryAt = &retryAt
+	}
+	return s.save(db, "status", "failed_attempts", "retry_at", "canceled_at")
+}
+
+// RenewalNotSaved - The card was or may have been charged (dlocal didn't
+// answer) but the renewal wasn't saved, the subscription isn't renewed
+// again until someone checks it
+func (s *Subscription) RenewalNotSaved(db *gorm.DB) (int, error) {
+	s.Status = SubscriptionAttention
+	s.RetryAt = nil
+	return s.save(db, "status", "retry_at")
+}
+
+// RenewalHeld - The risk rules held the renewal, it isn't renewed again
+// until the review is decided
+func (s *Subscription) RenewalHeld(db *gorm.DB) (int, error) {
+	s.Status = SubscriptionInReview
+	s.RetryAt = nil
+	return s.save(db, "status", "retry_at")
+}
+
+// QPause - Stop renewing until resumed
+func (s *Subscription) QPause(db *gorm.DB) (int, error) {
+	if code, err := s.QGetSubscription(db); err != nil {
+		return code, err
+	}
+	switch s.Status {
+	case SubscriptionTrialing, SubscriptionActive, SubscriptionPastDue:
+	default:
+		return 400, apperror.Conflict("invalid_status_transition", "only trialing, active or past due subscriptions can be paused")
+	}
+	now := time.Now()
+	s.Status = SubscriptionPaused
+	s.PausedAt = &now
+	return s.save(db, "status", "paused_at")
+}
+
+// QResume - Resume a paused subscription. If its period ended while paused
+// a new one starts now and is charged on the next renewal run.
+func (s *Subscription) QResume(db *gorm.DB) (int, error) {
+	if code, err := s.QGetSubscription(db); err != nil {
+		return code, err
+	}
+	if s.Status != SubscriptionPaused {
+		return 400, apperror.Conflict("invalid_status_transition", "subscription is not paused")
+	}
+	now := time.Now()
+	if s.CurrentPeriodEnd.Before(now) {
+		s.CurrentPeriodEnd = now
+	}
+	s.Status = SubscriptionActive
+	s.PausedAt = nil
+	s.FailedAttempts = 0
+	s.RetryAt = nil
+	return s.save(db, "status", "paused_at", "current_period_end", "failed_attempts", "retry_at")
+}
+
+// QCancel - Cancel now or at the end of the current period
+func (s *Subscription) QCancel(db *gorm.DB, atPeriodEnd bool) (int, error) {
+	if code, err := s.QGetSubscription(db); err != nil {
+		return code, err
+	}
+	if s.Status == SubscriptionCanceled {
+		return 400, apperror.Conflict("invalid_status_transition", "subscription already canceled")
+	}
+	if s.Status == SubscriptionRenewing {
+		return 400, apperror.Conflict("invalid_status_transition", "subscription is being renewed, try again later")
+	}
+	if s.Status == SubscriptionInReview && !atPeriodEnd {
+		return 400, apperror.Conflict("invalid_status_transition", "subscription renewal is in review, cancel at period end")
+	}
+	if atPeriodEnd && s.Status != SubscriptionPaused {
+		s.CancelAtPeriodEnd = true
+		return s.save(db, "cancel_at_period_end")
+	}
+	return s.cancel(db)
+}
+
+// CancelAtEnd - Period ended with CancelAtPeriodEnd set
+func (s *Subscription) CancelAtEnd(db *gorm.DB) (int, error) {
+	return s.cancel(db)
+}
+
+func (s *Subscription) cancel(db *gorm.DB) (int, error) {
+	now := time.Now()
+	s.Status = SubscriptionCanceled
+	s.CanceledAt = &now
+	s.RetryAt = nil
+	return s.save(db, "status", "canceled_at", "retry_at")
+}
+
+func (s *Subscription) save(db *gorm.DB, columns ...string) (int, error) {
+	s.UpdatedAt = time.Now()
+	if err := db.Model(&s).Select(append(columns, "updated_at")).Updates(s).Error; err != nil {
+		logger(db).Error("Subscription save - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
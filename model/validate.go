@@ -0,0 +1,12 @@
+package model
+
+import (
+	"systempayment/apperror"
+
+	"gopkg.in/validator.v2"
+)
+
+// validate - validator.Validate with the errors of v's fields by json name
+func validate(v interface{}) error {
+	return apperror.Validation(v, validator.Validate(v))
+}
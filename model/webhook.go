@@ -0,0 +1,457 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"systempayment/apperror"
+	"systempayment/pagination"
+	"systempayment/util"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookEndpoint - URL receiving the events of a Merchant's payments and
+// orders, endpoints without merchant receive every event
+type WebhookEndpoint struct {
+	ID         int            `json:"id" gorm:"primaryKey" example:"1"`
+	MerchantID *int           `json:"merchant_id" gorm:"column:merchant_id;index" example:"1"`
+	URL        *string        `json:"url" example:"https://shop.example.com/webhooks/payments" validate:"nonzero,max=500"`
+	Secret     string         `json:"secret,omitempty" gorm:"serializer:encrypted" example:"whsec_2f6c..."`
+	Events     []string       `json:"events" gorm:"serializer:json;type:text" example:"payment.succeeded,order.finished"`
+	CreatedAt  time.Time      `json:"created_at"`
+	DeletedAt  gorm.DeletedAt `json:"-"`
+}
+
+// WebhookEvent - event emitted in the transaction of the change (outbox),
+// a WebhookDelivery per subscribed endpoint is created with it. PayerID is
+// the payer the data is about, to erase it with the payer
+type WebhookEvent struct {
+	ID         int                    `json:"id" gorm:"primaryKey" example:"1"`
+	MerchantID *int                   `json:"merchant_id" gorm:"column:merchant_id;index" example:"1"`
+	PayerID    *int                   `json:"-" gorm:"column:payer_id;index"`
+	Type       string                 `json:"type" gorm:"index" example:"payment.succeeded"`
+	Data       map[string]interface{} `json:"data" gorm:"serializer:json;type:text"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// WebhookDelivery - an event to be sent to one endpoint, retried with
+// exponential backoff until WebhookMaxAttempts
+type WebhookDelivery struct {
+	ID             int              `json:"id" gorm:"primaryKey" example:"1"`
+	EventID        int              `json:"event_id" gorm:"column:event_id;index" example:"1"`
+	Event          WebhookEvent     `json:"-"`
+	EndpointID     int              `json:"endpoint_id" gorm:"column:endpoint_id;index" example:"1"`
+	Endpoint       WebhookEndpoint  `json:"-"`
+	Status         string           `json:"status" gorm:"default:pending;index" example:"succeeded"`
+	Attempts       int              `json:"attempts" example:"1"`
+	NextAttemptAt  time.Time        `json:"next_attempt_at" gorm:"index"`
+	LastStatusCode int              `json:"last_status_code" example:"200"`
+	LastError      string           `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time       `json:"delivered_at,omitempty"`
+	Log            []WebhookAttempt `json:"log" gorm:"foreignKey:DeliveryID"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// WebhookAttempt - delivery log, one row per request
+type WebhookAttempt struct {
+	ID         int       `json:"id" gorm:"primaryKey" example:"1"`
+	DeliveryID int       `json:"delivery_id" gorm:"column:delivery_id;index" example:"1"`
+	StatusCode int       `json:"status_code" example:"200"`
+	Error      string    `json:"error,omitempty"`
+	Response   string    `json:"response,omitempty"`
+	DurationMs int64     `json:"duration_ms" example:"85"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Webhook event types
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	EventOrderFinished    = "order.finished"
+	EventCardSaved        = "card.saved"
+	EventRefundCreated    = "refund.created"
+	EventChargebackOpened = "chargeback.opened"
+	EventChargebackClosed = "chargeback.closed"
+)
+
+// WebhookEventTypes - every event type, endpoints subscribe to some of them
+var WebhookEventTypes = []string{
+	EventPaymentSucceeded, EventPaymentFailed, EventOrderFinished, EventCardSaved, EventRefundCreated,
+	EventChargebackOpened, EventChargebackClosed,
+}
+
+// Webhook delivery status
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookMaxAttempts - 1 minute backoff doubling, the last attempt is ~4 hours after the event
+const WebhookMaxAttempts = 9
+
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoint"
+}
+
+func (WebhookEvent) TableName() string {
+	return "webhook_event"
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+func (WebhookAttempt) TableName() string {
+	return "webhook_attempt"
+}
+
+// QCreateWebhookEndpoint - Insert into webhook_endpoint with a new signing secret
+func (e *WebhookEndpoint) QCreateWebhookEndpoint(db *gorm.DB) (int, error) {
+	var err error
+	if err = validate(e); err != nil {
+		logger(db).Error("QCreateWebhookEndpoint - ", err)
+		return 400, err
+	}
+	// the delivery worker must not reach internal services
+	if err = util.PublicURL(db.Statement.Context, *e.URL); err != nil {
+		return 400, err
+	}
+	for _, event := range e.Events {
+		if !validEventType(event) {
+			return 400, errors.New("unknown event type " + event)
+		}
+	}
+	if e.MerchantID != nil {
+		var merchant = Merchant{ID: *e.MerchantID}
+		if _, err := merchant.QGetMerchant(db); err != nil {
+			return 400, errors.New("merchant not found")
+		}
+	}
+
+	secret := make([]byte, 24)
+	if _, err = rand.Read(secret); err != nil {
+		return 500, err
+	}
+	e.Secret = "whsec_" + hex.EncodeToString(secret)
+	e.CreatedAt = time.Now()
+	if err = db.Create(e).Error; err != nil {
+		logger(db).Error("QCreateWebhookEndpoint - ", err)
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetWebhookEndpoints - Get endpoints (optional merchant), without secrets
+func (e *WebhookEndpoint) QGetWebhookEndpoints(db *gorm.DB, merchantID int) ([]WebhookEndpoint, int, error) {
+	var endpoints []WebhookEndpoint
+	query := db.Model(&WebhookEndpoint{}).Omit("secret")
+	if merchantID != 0 {
+		query = query.Where("merchant_id=?", merchantID)
+	}
+	if err := query.Order("id").Find(&endpoints).Error; err != nil {
+		logger(db).Error("QGetWebhookEndpoints - ", err)
+		return endpoints, 500, err
+	}
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	return endpoints, 200, nil
+}
+
+// QDeleteWebhookEndpoint - Stops sending events to the endpoint, pending
+// deliveries are dropped
+func (e *WebhookEndpoint) QDeleteWebhookEndpoint(db *gorm.DB) (int, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&WebhookEndpoint{}, e.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&WebhookDelivery{}).Where("endpoint_id=?", e.ID).Where("status=?", DeliveryPending).
+			Updates(map[string]interface{}{"status": DeliveryFailed, "last_error": "endpoint deleted"}).Error
+	})
+	if err != nil {
+		logger(db).Error("QDeleteWebhookEndpoint - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+func (e *WebhookEndpoint) subscribed(event string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+func validEventType(event string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == event {
+			return true
+		}
+	}
+	return false
+}
+
+// QEmitWebhookEvent - Insert the event and a delivery for each endpoint subscribed to it
+//
+// Call it with the transaction of the change, data is any JSON encodable value.
+// payerID is the payer the data is about, if any.
+func QEmitWebhookEvent(db *gorm.DB, merchantID *int, payerID *int, eventType string, data interface{}) error {
+	return emitWebhookEvent(db, merchantID, payerID, eventType, data, true)
+}
+
+// emitWebhookEvent - QEmitWebhookEvent, only to the merchant's endpoints
+// without global, for events emitted to several merchants
+func emitWebhookEvent(db *gorm.DB, merchantID *int, payerID *int, eventType string, data interface{}, global bool) error {
+	payload, err := toMap(data)
+	if err != nil {
+		logger(db).Error("QEmitWebhookEvent - ", err)
+		return err
+	}
+
+	var endpoints []WebhookEndpoint
+	query := db.Model(&WebhookEndpoint{})
+	switch {
+	case merchantID != nil && !global:
+		query = query.Where("merchant_id=?", *merchantID)
+	case merchantID != nil:
+		query = query.Where("merchant_id IS NULL OR merchant_id=?", *merchantID)
+	default:
+		query = query.Where("merchant_id IS NULL")
+	}
+	if err := query.Find(&endpoints).Error; err != nil {
+		logger(db).Error("QEmitWebhookEvent - ", err)
+		return err
+	}
+
+	event := WebhookEvent{MerchantID: merchantID, PayerID: payerID, Type: eventType, Data: payload, CreatedAt: time.Now()}
+	if err := db.Create(&event).Error; err != nil {
+		logger(db).Error("QEmitWebhookEvent - ", err)
+		return err
+	}
+	for _, endpoint := range endpoints {
+		if !endpoint.subscribed(eventType) {
+			continue
+		}
+		if _, err := event.QNewDelivery(db, endpoint.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QEmitOrderEvent - QEmitWebhookEvent for the merchant of the order
+func QEmitOrderEvent(db *gorm.DB, orderID int, eventType string, data interface{}) error {
+	merchantID, err := QOrderMerchantID(db, orderID)
+	if err != nil {
+		return err
+	}
+	payerID, err := QOrderPayerID(db, orderID)
+	if err != nil {
+		return err
+	}
+	if merchantID == 0 {
+		return QEmitWebhookEvent(db, nil, &payerID, eventType, data)
+	}
+	return QEmitWebhookEvent(db, &merchantID, &payerID, eventType, data)
+}
+
+// QEraseWebhookEvents - Replaces the data of the payer's events, their
+// pending deliveries fail and the logged responses are dropped
+func QEraseWebhookEvents(db *gorm.DB, payerID int) error {
+	events := db.Model(&WebhookEvent{}).Select("id").Where("payer_id=?", payerID)
+	deliveries := db.Model(&WebhookDelivery{}).Select("id").Where("event_id IN (?)", events)
+	if err := db.Model(&WebhookEvent{}).Where("payer_id=?", payerID).Select("data").
+		Updates(WebhookEvent{Data: map[string]interface{}{"erased": true}}).Error; err != nil {
+		logger(db).Error("QEraseWebhookEvents - ", err)
+		return err
+	}
+	if err := db.Model(&WebhookDelivery{}).Where("event_id IN (?)", events).Where("status=?", DeliveryPending).
+		Updates(map[string]interface{}{"status": DeliveryFailed, "last_error": "payer erased"}).Error; err != nil {
+		logger(db).Error("QEraseWebhookEvents - ", err)
+		return err
+	}
+	if err := db.Model(&WebhookAttempt{}).Where("delivery_id IN (?)", deliveries).
+		Update("response", "").Error; err != nil {
+		logger(db).Error("QEraseWebhookEvents - ", err)
+		return err
+	}
+	return nil
+}
+
+// QNewDelivery - Queues the event for an endpoint
+func (ev *WebhookEvent) QNewDelivery(db *gorm.DB, endpointID int) (WebhookDelivery, error) {
+	delivery := WebhookDelivery{
+		EventID:       ev.ID,
+		EndpointID:    endpointID,
+		Status:        DeliveryPending,
+		NextAttemptAt: time.Now(),
+		CreatedAt:     time.Now(),
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		logger(db).Error("QNewDelivery - ", err)
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+// QGetWebhookEvents - Get a page of events (optional merchant and type)
+func (ev *WebhookEvent) QGetWebhookEvents(db *gorm.DB, page pagination.Params, merchantID int, eventType string) ([]WebhookEvent, int, error) {
+	var events []WebhookEvent
+	query := db.Model(&WebhookEvent{})
+	if merchantID != 0 {
+		query = query.Where("merchant_id=?", merchantID)
+	}
+	if eventType != "" {
+		query = query.Where("type=?", eventType)
+	}
+	if err := page.Query(query).Find(&events).Error; err != nil {
+		logger(db).Error("QGetWebhookEvents - ", err)
+		return events, 500, err
+	}
+	return events, 200, nil
+}
+
+// QGetWebhookEvent - Get event by ID
+func (ev *WebhookEvent) QGetWebhookEvent(db *gorm.DB) (int, error) {
+	if err := db.Where("id=?", ev.ID).First(&ev).Error; err != nil {
+		logger(db).Error("QGetWebhookEvent - ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 400, err
+		}
+		return 500, err
+	}
+	return 200, nil
+}
+
+// QGetDeliveries - Deliveries of the event with their log
+func (ev *WebhookEvent) QGetDeliveries(db *gorm.DB) ([]WebhookDelivery, int, error) {
+	var deliveries []WebhookDelivery
+	if err := db.Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("event_id=?", ev.ID).Order("id").Find(&deliveries).Error; err != nil {
+		logger(db).Error("QGetDeliveries - ", err)
+		return deliveries, 500, err
+	}
+	return deliveries, 200, nil
+}
+
+// QReplay - Sends the event again to one endpoint, or to every endpoint
+// it was delivered to before when endpointID is 0
+func (ev *WebhookEvent) QReplay(db *gorm.DB, endpointID int) ([]WebhookDelivery, int, error) {
+	var deliveries []WebhookDelivery
+	if code, err := ev.QGetWebhookEvent(db); err != nil {
+		return deliveries, code, err
+	}
+
+	var endpointIDs []int
+	if endpointID != 0 {
+		endpointIDs = []int{endpointID}
+	} else if err := db.Model(&WebhookDelivery{}).Distinct("endpoint_id").
+		Where("event_id=?", ev.ID).Pluck("endpoint_id", &endpointIDs).Error; err != nil {
+		logger(db).Error("QReplay - ", err)
+		return deliveries, 500, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range endpointIDs {
+			var endpoint WebhookEndpoint
+			if err := tx.Where("id=?", id).First(&endpoint).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+				return err
+			}
+			delivery, err := ev.QNewDelivery(tx, endpoint.ID)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		return nil
+	})
+	if err != nil {
+		logger(db).Error("QReplay - ", err)
+		return deliveries, 500, err
+	}
+	if len(deliveries) == 0 {
+		return deliveries, 400, apperror.Unprocessable("no_active_endpoint", "no active endpoint to replay the event to")
+	}
+	return deliveries, 200, nil
+}
+
+// QLockNextDelivery - Locks the oldest delivery due, with its event and
+// endpoint, skipping those locked by other dispatchers
+func (d *WebhookDelivery) QLockNextDelivery(tx *gorm.DB, now time.Time) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status=?", DeliveryPending).Where("next_attempt_at<=?", now).
+		Order("next_attempt_at").First(&d).Error
+	if err == nil {
+		err = tx.Where("id=?", d.EventID).First(&d.Event).Error
+	}
+	if err == nil {
+		err = tx.Unscoped().Where("id=?", d.EndpointID).First(&d.Endpoint).Error
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger(tx).Error("QLockNextDelivery - ", err)
+	}
+	return err
+}
+
+// Attempted - logs a request and schedules the next one if it failed
+func (d *WebhookDelivery) Attempted(db *gorm.DB, attempt WebhookAttempt) error {
+	attempt.DeliveryID = d.ID
+	attempt.CreatedAt = time.Now()
+	if err := db.Create(&attempt).Error; err != nil {
+		logger(db).Error("WebhookDelivery.Attempted - ", err)
+		return err
+	}
+
+	d.Attempts++
+	d.LastStatusCode = attempt.StatusCode
+	d.LastError = attempt.Error
+	switch {
+	case attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300:
+		d.Status = DeliverySucceeded
+		d.DeliveredAt = &attempt.CreatedAt
+	case d.Attempts >= WebhookMaxAttempts:
+		d.Status = DeliveryFailed
+	default:
+		backoff := time.Duration(math.Pow(2, float64(d.Attempts-1))) * time.Minute
+		d.NextAttemptAt = attempt.CreatedAt.Add(backoff)
+	}
+	if err := db.Model(d).Select("status", "attempts", "last_status_code", "last_error",
+		"delivered_at", "next_attempt_at").Updates(d).Error; err != nil {
+		logger(db).Error("WebhookDelivery.Attempted - ", err)
+		return err
+	}
+	return nil
+}
+
+// JSON value as a map, for serializer:json columns
+func toMap(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
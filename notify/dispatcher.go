@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"systempayment/model"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ReminderLead - how long before an installment is due its reminder is sent
+const ReminderLead = 72 * time.Hour
+
+// Dispatch - Sends the pending notifications whose transaction committed.
+// Returns how many were sent.
+//
+// Every notification is locked while it's sent, several dispatchers can
+// run at the same time.
+func Dispatch(ctx context.Context, db *gorm.DB, sender Sender) (int, error) {
+	sent := 0
+	for ctx.Err() == nil {
+		done := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var n model.Notification
+			if err := n.QLockNextNotification(tx, time.Now()); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					done = true
+					return nil
+				}
+				return err
+			}
+
+			msg, err := message(tx, n)
+			if errors.Is(err, errNoRecipient) {
+				return n.Cancel(tx, err.Error())
+			}
+			if err == nil {
+				err = sender.Send(ctx, msg)
+			}
+			if err != nil {
+				log.Error("Dispatch - notification ", n.ID, ": ", err)
+				return n.Failed(tx, err)
+			}
+			sent++
+			return n.Sent(tx)
+		})
+		if err != nil {
+			return sent, err
+		}
+		if done {
+			break
+		}
+	}
+	return sent, nil
+}
+
+// EnqueueReminders - Reminds payers of the installments due in the next ReminderLead
+func EnqueueReminders(db *gorm.DB) (int, error) {
+	now := time.Now()
+	return model.QEnqueueInstallmentReminders(db, now, now.Add(ReminderLead))
+}
+
+var errNoRecipient = errors.New("payer not found or erased")
+
+// Renders the notification for its payer, in the payer's language
+func message(db *gorm.DB, n model.Notification) (Message, error) {
+	var payer = model.Payer{ID: n.PayerID}
+	if _, err := payer.QGetPayer(db); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Message{}, errNoRecipient
+		}
+		return Message{}, err
+	}
+	if payer.ErasedAt != nil || payer.Email == nil || *payer.Email == "" {
+		return Message{}, errNoRecipient
+	}
+
+	data := map[string]interface{}{}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	if payer.Name != nil {
+		data["name"] = *payer.Name
+	}
+	var country string
+	if payer.Country != nil {
+		country = *payer.Country
+	}
+
+	msg, err := Render(n.Event, Locale(country), data)
+	msg.To = *payer.Email
+	return msg, err
+}
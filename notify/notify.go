@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Message - rendered email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages (SMTP, file...)
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+//go:embed templates/*.tmpl
+var files embed.FS
+
+// one template set per locale, each event defines "<event>.subject" and "<event>.body"
+var templates = map[string]*template.Template{}
+
+func init() {
+	for _, locale := range []string{"en", "es", "pt"} {
+		templates[locale] = template.Must(template.New(locale).ParseFS(files, "templates/"+locale+".tmpl"))
+	}
+}
+
+// Locale - language of the messages for a payer country
+func Locale(country string) string {
+	switch strings.ToUpper(country) {
+	case "BR", "PT", "AO", "MZ":
+		return "pt"
+	case "AR", "BO", "CL", "CO", "CR", "DO", "EC", "ES", "GT", "HN", "MX",
+		"NI", "PA", "PE", "PY", "SV", "UY", "VE":
+		return "es"
+	default:
+		return "en"
+	}
+}
+
+// Render - subject and body of an event in a locale, data are the template values
+func Render(event string, locale string, data map[string]interface{}) (Message, error) {
+	t, ok := templates[locale]
+	if !ok {
+		t = templates["en"]
+	}
+
+	var msg Message
+	var subject, body bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, event+".subject", data); err != nil {
+		return msg, fmt.Errorf("rendering %s (%s): %w", event, locale, err)
+	}
+	if err := t.ExecuteTemplate(&body, event+".body", data); err != nil {
+		return msg, fmt.Errorf("rendering %s (%s): %w", event, locale, err)
+	}
+	msg.Subject = strings.TrimSpace(subject.String())
+	msg.Body = strings.TrimSpace(body.String()) + "\n"
+	return msg, nil
+}
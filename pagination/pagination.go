@@ -0,0 +1,259 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Page size
+const (
+	DefaultLimit = 30
+	MaxLimit     = 100
+)
+
+// Kind - type of a sort column, to read it back from a cursor
+type Kind int
+
+const (
+	Number Kind = iota
+	Time
+	Text
+)
+
+// Sort - column a list can be sorted by
+type Sort struct {
+	Name string
+	Kind Kind
+}
+
+// Sorts every list accepts
+var (
+	ID        = Sort{Name: "id", Kind: Number}
+	CreatedAt = Sort{Name: "created_at", Kind: Time}
+)
+
+// Page - response envelope of a list
+type Page struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty" example:"eyJzIjoiaWQiLCJ2IjoiMzAiLCJpZCI6MzB9"`
+	HasMore    bool        `json:"has_more" example:"true"`
+}
+
+// Params - page requested: limit, sort (optionally descending), the
+// cursor of the previous page and a created_at range
+type Params struct {
+	Limit int
+	Sort  Sort
+	Desc  bool
+	From  *time.Time
+	To    *time.Time
+	after *cursor
+}
+
+// Position after the last item of a page, bound to the sort it was made with
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Parse - Params of the request's limit, cursor, sort, from and to query
+// parameters. sort is a name of sorts (id and created_at are always
+// accepted), descending with a leading "-", defaultSort when missing. It
+// has to be a column of item, the model listed.
+func Parse(ctx *gin.Context, item interface{}, defaultSort string, sorts ...Sort) (Params, error) {
+	p := Params{Limit: DefaultLimit}
+	if limit := ctx.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return p, errors.New("limit must be a positive number")
+		}
+		p.Limit = n
+		if n > MaxLimit {
+			p.Limit = MaxLimit
+		}
+	}
+
+	sort := ctx.DefaultQuery("sort", defaultSort)
+	p.Desc = strings.HasPrefix(sort, "-")
+	name := strings.TrimPrefix(sort, "-")
+	found := false
+	for _, s := range append([]Sort{ID, CreatedAt}, sorts...) {
+		if s.Name == name {
+			p.Sort, found = s, true
+		}
+	}
+	if !found || !hasColumn(reflect.TypeOf(item), name) {
+		return p, fmt.Errorf("can't sort by %s", name)
+	}
+
+	if value := ctx.Query("cursor"); value != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(value)
+		var c cursor
+		if err == nil {
+			err = json.Unmarshal(raw, &c)
+		}
+		if err != nil {
+			return p, errors.New("invalid cursor")
+		}
+		if c.Sort != sort {
+			return p, errors.New("cursor was made with another sort")
+		}
+		p.after = &c
+		if p.Sort.Name != ID.Name {
+			if _, err := p.value(); err != nil {
+				return p, errors.New("invalid cursor")
+			}
+		}
+	}
+
+	for param, field := range map[string]**time.Time{"from": &p.From, "to": &p.To} {
+		if value := ctx.Query(param); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return p, fmt.Errorf("%s must be a YYYY-MM-DD date", param)
+			}
+			*field = &date
+		}
+	}
+	if p.To != nil {
+		// whole last day
+		to := p.To.AddDate(0, 0, 1)
+		p.To = &to
+	}
+	return p, nil
+}
+
+// Query - Applies the range, cursor, order and limit to the query. It reads
+// one item more than the limit to know if there's a next page.
+func (p Params) Query(query *gorm.DB) *gorm.DB {
+	if p.From != nil {
+		query = query.Where("created_at >= ?", *p.From)
+	}
+	if p.To != nil {
+		query = query.Where("created_at < ?", *p.To)
+	}
+
+	direction, compare := "", ">"
+	if p.Desc {
+		direction, compare = " DESC", "<"
+	}
+	if p.after != nil {
+		if p.Sort.Name == ID.Name {
+			query = query.Where("id "+compare+" ?", p.after.ID)
+		} else {
+			// checked by Parse
+			value, _ := p.value()
+			query = query.Where("("+p.Sort.Name+", id) "+compare+" (?, ?)", value, p.after.ID)
+		}
+	}
+	if p.Sort.Name != ID.Name {
+		query = query.Order(p.Sort.Name + direction)
+	}
+	return query.Order("id" + direction).Limit(p.Limit + 1)
+}
+
+// Sort value of the cursor as its column type
+func (p Params) value() (interface{}, error) {
+	switch p.Sort.Kind {
+	case Time:
+		return time.Parse(time.RFC3339Nano, p.after.Value)
+	case Number:
+		return strconv.ParseFloat(p.after.Value, 64)
+	}
+	return p.after.Value, nil
+}
+
+// Page - Envelope of the items read with Query, items is a pointer to their
+// slice. The item past the limit is left out and marks there's a next page.
+func (p Params) Page(items interface{}) Page {
+	slice := reflect.ValueOf(items).Elem()
+	if slice.IsNil() {
+		slice.Set(reflect.MakeSlice(slice.Type(), 0, 0))
+	}
+	page := Page{}
+	if slice.Len() > p.Limit {
+		slice.Set(slice.Slice(0, p.Limit))
+		page.HasMore = true
+
+		last := slice.Index(p.Limit - 1)
+		sort := p.Sort.Name
+		if p.Desc {
+			sort = "-" + sort
+		}
+		c := cursor{Sort: sort}
+		if id, ok := column(last, ID.Name).(int); ok {
+			c.ID = id
+		}
+		switch v := column(last, p.Sort.Name).(type) {
+		case *time.Time:
+			if v != nil {
+				c.Value = v.Format(time.RFC3339Nano)
+			}
+		case time.Time:
+			c.Value = v.Format(time.RFC3339Nano)
+		default:
+			c.Value = fmt.Sprint(v)
+		}
+		raw, _ := json.Marshal(c)
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	page.Data = slice.Interface()
+	return page
+}
+
+// Value of the struct field of the column, embedded structs included. The
+// json name can differ or be "-"
+func column(item reflect.Value, name string) interface{} {
+	if item.Kind() == reflect.Ptr {
+		item = item.Elem()
+	}
+	if index := columnIndex(item.Type(), name); index != nil {
+		return item.FieldByIndex(index).Interface()
+	}
+	return nil
+}
+
+func hasColumn(t reflect.Type, name string) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && columnIndex(t, name) != nil
+}
+
+// Index of the field of the column, named like gorm does
+func columnIndex(t reflect.Type, name string) []int {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := schema.ParseTagSetting(f.Tag.Get("gorm"), ";")
+		if _, ignored := tag["-"]; ignored || !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if index := columnIndex(f.Type, name); index != nil {
+				return append([]int{i}, index...)
+			}
+			continue
+		}
+		columnName := tag["COLUMN"]
+		if columnName == "" {
+			columnName = naming.ColumnName("", f.Name)
+		}
+		if columnName == name {
+			return []int{i}
+		}
+	}
+	return nil
+}
+
+var naming = schema.NamingStrategy{}
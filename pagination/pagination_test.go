@@ -0,0 +1,161 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Base struct {
+	ID int
+}
+
+type item struct {
+	Base
+	Name      string `json:"-" gorm:"column:title"`
+	Amount    float64
+	CreatedAt time.Time
+	PaidAt    *time.Time
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+var amount = Sort{Name: "amount", Kind: Number}
+var title = Sort{Name: "title", Kind: Text}
+var paidAt = Sort{Name: "paid_at", Kind: Time}
+
+func parse(query url.Values) (Params, error) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/items?"+query.Encode(), nil)
+	return Parse(ctx, item{}, "-created_at", amount, title, paidAt)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		limit int
+		sort  string
+		desc  bool
+		err   string
+	}{
+		{"defaults", url.Values{}, DefaultLimit, "created_at", true, ""},
+		{"ascending", url.Values{"sort": {"amount"}, "limit": {"10"}}, 10, "amount", false, ""},
+		{"column named by the tag", url.Values{"sort": {"-title"}}, DefaultLimit, "title", true, ""},
+		{"limit over the max", url.Values{"limit": {"1000"}}, MaxLimit, "created_at", true, ""},
+		{"zero limit", url.Values{"limit": {"0"}}, 0, "", false, "limit must be a positive number"},
+		{"unknown sort", url.Values{"sort": {"name"}}, 0, "", false, "can't sort by name"},
+		{"not a cursor", url.Values{"cursor": {"abc"}}, 0, "", false, "invalid cursor"},
+		{"bad date", url.Values{"from": {"01/02/2023"}}, 0, "", false, "from must be a YYYY-MM-DD date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parse(tt.query)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("Parse() = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() = %v", err)
+			}
+			if p.Limit != tt.limit || p.Sort.Name != tt.sort || p.Desc != tt.desc {
+				t.Errorf("Parse() = limit %d, sort %s, desc %v, want %d, %s, %v", p.Limit, p.Sort.Name, p.Desc, tt.limit, tt.sort, tt.desc)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	p, err := parse(url.Values{"from": {"2023-02-01"}, "to": {"2023-02-28"}})
+	if err != nil {
+		t.Fatalf("Parse() = %v", err)
+	}
+	if want := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC); !p.From.Equal(want) {
+		t.Errorf("From = %s, want %s", p.From, want)
+	}
+	// the whole last day
+	if want := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC); !p.To.Equal(want) {
+		t.Errorf("To = %s, want %s", p.To, want)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	created := time.Date(2023, 2, 20, 10, 0, 0, 123456789, time.UTC)
+	paid := created.Add(time.Hour)
+	items := []item{
+		{Base: Base{ID: 7}, Name: "a", Amount: 10.5, CreatedAt: created, PaidAt: &paid},
+		{Base: Base{ID: 5}, Name: "b", Amount: 20, CreatedAt: created},
+	}
+	tests := []struct {
+		sort  string
+		value interface{}
+	}{
+		{"id", nil},
+		{"-created_at", created},
+		{"paid_at", paid},
+		{"-amount", 10.5},
+		{"title", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			p, err := parse(url.Values{"sort": {tt.sort}, "limit": {"1"}})
+			if err != nil {
+				t.Fatalf("Parse() = %v", err)
+			}
+			page := p.Page(&[]item{items[0], items[1]})
+			if !page.HasMore || page.NextCursor == "" || len(page.Data.([]item)) != 1 {
+				t.Fatalf("Page() = %+v, want one item and a cursor", page)
+			}
+
+			next, err := parse(url.Values{"sort": {tt.sort}, "cursor": {page.NextCursor}})
+			if err != nil {
+				t.Fatalf("Parse(cursor) = %v", err)
+			}
+			if next.after.ID != 7 {
+				t.Errorf("cursor id = %d, want 7", next.after.ID)
+			}
+			if tt.value == nil {
+				return
+			}
+			value, err := next.value()
+			if err != nil {
+				t.Fatalf("value() = %v", err)
+			}
+			if want, ok := tt.value.(time.Time); ok {
+				if !value.(time.Time).Equal(want) {
+					t.Errorf("value() = %v, want %v", value, want)
+				}
+			} else if value != tt.value {
+				t.Errorf("value() = %v, want %v", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestCursorOfAnotherSort(t *testing.T) {
+	p, _ := parse(url.Values{"sort": {"amount"}, "limit": {"1"}})
+	page := p.Page(&[]item{{Base: Base{ID: 1}}, {Base: Base{ID: 2}}})
+	if _, err := parse(url.Values{"sort": {"-amount"}, "cursor": {page.NextCursor}}); err == nil || err.Error() != "cursor was made with another sort" {
+		t.Errorf("Parse() = %v, want the cursor refused", err)
+	}
+}
+
+func TestLastPage(t *testing.T) {
+	p, _ := parse(url.Values{"limit": {"2"}})
+	var none []item
+	page := p.Page(&none)
+	if page.HasMore || page.NextCursor != "" || page.Data == nil || len(page.Data.([]item)) != 0 {
+		t.Errorf("Page() = %+v, want an empty last page", page)
+	}
+	page = p.Page(&[]item{{Base: Base{ID: 1}}, {Base: Base{ID: 2}}})
+	if page.HasMore || page.NextCursor != "" || len(page.Data.([]item)) != 2 {
+		t.Errorf("Page() = %+v, want a full last page", page)
+	}
+}
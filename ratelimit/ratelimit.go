@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"systempayment/apperror"
+	"systempayment/httputil"
+	"systempayment/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// Rule - Limit requests allowed in Window for each value of Key. Rules with
+// Limit 0 are off, and requests without a key aren't counted by the rule
+type Rule struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    func(ctx *gin.Context) string
+}
+
+// ByIP - client's IP, read from X-Forwarded-For only when the connection
+// comes from one of the router's trusted proxies
+func ByIP(ctx *gin.Context) string {
+	return ctx.ClientIP()
+}
+
+// ByAPIKey - hash of the Authorization header, the key isn't kept. Clients
+// without one are counted by IP, so leaving it out doesn't skip the limit
+func ByAPIKey(ctx *gin.Context) string {
+	key := ctx.GetHeader("Authorization")
+	if key == "" {
+		return "ip:" + ByIP(ctx)
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "key:" + hex.EncodeToString(sum[:8])
+}
+
+// ByQueryID - numeric id in the query parameter, e.g. payer_id, as the
+// handler reads it: 01 and +1 count as 1. Requests without a valid id aren't
+// counted, the handler rejects them
+func ByQueryID(name string) func(ctx *gin.Context) string {
+	return func(ctx *gin.Context) string {
+		id, err := strconv.Atoi(ctx.Query(name))
+		if err != nil {
+			return ""
+		}
+		return strconv.Itoa(id)
+	}
+}
+
+// Middleware - Answers 429 once any of the rules goes over its limit in the
+// current window, with Retry-After set to the end of the window. Counters
+// are fixed windows, by route, rule and key.
+//
+// When the store fails the request is let through, the limits aren't worth
+// an outage.
+func Middleware(store Store, rules ...Rule) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		now := time.Now()
+
+		// headers of the rule closest to its limit
+		tightest, remaining, reset := 0, -1, now
+		for _, rule := range rules {
+			if rule.Limit <= 0 || rule.Window <= 0 {
+				continue
+			}
+			value := rule.Key(ctx)
+			if value == "" {
+				continue
+			}
+
+			start := now.Truncate(rule.Window)
+			end := start.Add(rule.Window)
+			count, err := store.Increment(ctx.Request.Context(), route+":"+rule.Name+":"+value, start, rule.Window)
+			if err != nil {
+				log.WithContext(ctx.Request.Context()).Error("Rate limit ", rule.Name, " - ", err)
+				continue
+			}
+
+			if count > rule.Limit {
+				metrics.RateLimited.WithLabelValues(route, rule.Name).Inc()
+				retry := int(end.Sub(now).Seconds()) + 1
+				setHeaders(ctx, rule.Limit, 0, end)
+				ctx.Header("Retry-After", strconv.Itoa(retry))
+				httputil.Problem(ctx, http.StatusTooManyRequests, "",
+					apperror.TooManyRequests(apperror.CodeRateLimited, "Too many requests by %s, retry in %d seconds", rule.Name, retry))
+				return
+			}
+			if left := rule.Limit - count; remaining < 0 || left < remaining {
+				tightest, remaining, reset = rule.Limit, left, end
+			}
+		}
+		if remaining >= 0 {
+			setHeaders(ctx, tightest, remaining, reset)
+		}
+		ctx.Next()
+	}
+}
+
+func setHeaders(ctx *gin.Context, limit int, remaining int, reset time.Time) {
+	ctx.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+	ctx.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	ctx.Header("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+}
@@ -0,0 +1,170 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func request(method, target string, header map[string]string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(method, target, nil)
+	ctx.Request.RemoteAddr = "203.0.113.7:51000"
+	for name, value := range header {
+		ctx.Request.Header.Set(name, value)
+	}
+	return ctx
+}
+
+func TestKeys(t *testing.T) {
+	byPayer := ByQueryID("payer_id")
+	tests := []struct {
+		name   string
+		target string
+		header map[string]string
+		key    func(ctx *gin.Context) string
+		want   string
+	}{
+		{"ip", "/", nil, ByIP, "203.0.113.7"},
+		{"api key without header", "/", nil, ByAPIKey, "ip:203.0.113.7"},
+		{"payer id", "/?payer_id=12", nil, byPayer, "12"},
+		{"payer id with a leading zero", "/?payer_id=012", nil, byPayer, "12"},
+		{"payer id not a number", "/?payer_id=abc", nil, byPayer, ""},
+		{"payer id missing", "/", nil, byPayer, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key(request("POST", tt.target, tt.header)); got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByAPIKey(t *testing.T) {
+	a := ByAPIKey(request("POST", "/", map[string]string{"Authorization": "Bearer a"}))
+	again := ByAPIKey(request("POST", "/", map[string]string{"Authorization": "Bearer a"}))
+	b := ByAPIKey(request("POST", "/", map[string]string{"Authorization": "Bearer b"}))
+	if !strings.HasPrefix(a, "key:") || len(a) != len("key:")+16 {
+		t.Errorf("ByAPIKey() = %q, want key: and 16 hex digits", a)
+	}
+	if strings.Contains(a, "Bearer") {
+		t.Errorf("ByAPIKey() = %q keeps the key", a)
+	}
+	if a != again || a == b {
+		t.Errorf("ByAPIKey() = %q, %q, %q, want the same key counted together", a, again, b)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	window := time.Minute
+	start := time.Now().Truncate(window)
+
+	steps := []struct {
+		key   string
+		start time.Time
+		want  int
+	}{
+		{"a", start, 1},
+		{"a", start, 2},
+		{"b", start, 1},
+		{"a", start, 3},
+		// next window starts over
+		{"a", start.Add(window), 1},
+		{"a", start.Add(window), 2},
+		{"b", start.Add(window), 1},
+	}
+	for i, step := range steps {
+		count, err := store.Increment(ctx, step.key, step.start, window)
+		if err != nil || count != step.want {
+			t.Errorf("step %d: Increment(%s) = %d, %v, want %d", i, step.key, count, err, step.want)
+		}
+	}
+}
+
+func TestMemoryStorePurge(t *testing.T) {
+	store := NewMemoryStore()
+	window := time.Second
+	store.Increment(context.Background(), "old", time.Now().Add(-time.Hour), window)
+	store.purged = time.Now().Add(-2 * time.Minute)
+	store.Increment(context.Background(), "new", time.Now().Truncate(window), window)
+	if _, ok := store.counters["old"]; ok || len(store.counters) != 1 {
+		t.Errorf("counters = %v, want the expired window dropped", store.counters)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Increment(ctx context.Context, key string, start time.Time, window time.Duration) (int, error) {
+	return 0, errors.New("database down")
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		store  Store
+		rules  []Rule
+		header map[string]string
+		codes  []int
+	}{
+		{"under the limit", NewMemoryStore(), []Rule{{Name: "ip", Limit: 3, Window: time.Hour, Key: ByIP}}, nil, []int{200, 200, 200}},
+		{"over the limit", NewMemoryStore(), []Rule{{Name: "ip", Limit: 2, Window: time.Hour, Key: ByIP}}, nil, []int{200, 200, 429, 429}},
+		{"tightest rule", NewMemoryStore(), []Rule{
+			{Name: "ip", Limit: 5, Window: time.Hour, Key: ByIP},
+			{Name: "api_key", Limit: 1, Window: time.Hour, Key: ByAPIKey},
+		}, map[string]string{"Authorization": "Bearer a"}, []int{200, 429}},
+		{"rule turned off", NewMemoryStore(), []Rule{{Name: "ip", Limit: 0, Window: time.Hour, Key: ByIP}}, nil, []int{200, 200}},
+		{"requests without a key", NewMemoryStore(), []Rule{{Name: "payer", Limit: 1, Window: time.Hour, Key: ByQueryID("payer_id")}}, nil, []int{200, 200}},
+		{"store down", failingStore{}, []Rule{{Name: "ip", Limit: 1, Window: time.Hour, Key: ByIP}}, nil, []int{200, 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/card", Middleware(tt.store, tt.rules...), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+			for i, want := range tt.codes {
+				req := httptest.NewRequest("POST", "/card", nil)
+				req.RemoteAddr = "203.0.113.7:51000"
+				for name, value := range tt.header {
+					req.Header.Set(name, value)
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if w.Code != want {
+					t.Fatalf("request %d = %d, want %d", i, w.Code, want)
+				}
+				if want == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d without Retry-After", i)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareHeaders(t *testing.T) {
+	r := gin.New()
+	r.POST("/card", Middleware(NewMemoryStore(),
+		Rule{Name: "ip", Limit: 5, Window: time.Hour, Key: ByIP},
+		Rule{Name: "payer", Limit: 2, Window: time.Hour, Key: ByQueryID("payer_id")},
+	), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for _, want := range []string{"1", "0"} {
+		req := httptest.NewRequest("POST", "/card?payer_id=1", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Remaining") != want {
+			t.Errorf("headers = %v, want the payer rule with %s left", w.Header(), want)
+		}
+	}
+}
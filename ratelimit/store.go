@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"systempayment/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Store - counters of the requests of each key by window
+type Store interface {
+	// Increment counts a request for key in the window starting at start
+	// and returns the requests counted so far
+	Increment(ctx context.Context, key string, start time.Time, window time.Duration) (int, error)
+}
+
+// MemoryStore - counters of this instance only, each replica of the API
+// allows the whole limit
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+	purged   time.Time
+}
+
+type counter struct {
+	start   time.Time
+	count   int
+	expires time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*counter{}}
+}
+
+func (s *MemoryStore) Increment(ctx context.Context, key string, start time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// windows over are dropped once a minute, not to grow with every IP seen
+	if now.Sub(s.purged) > time.Minute {
+		for k, c := range s.counters {
+			if now.After(c.expires) {
+				delete(s.counters, k)
+			}
+		}
+		s.purged = now
+	}
+
+	c, ok := s.counters[key]
+	if !ok || !c.start.Equal(start) {
+		c = &counter{start: start, expires: start.Add(window)}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, nil
+}
+
+// PostgresStore - counters in the rate_limit table, shared by every
+// replica. Windows over are deleted by the rate limits job
+type PostgresStore struct {
+	DB *gorm.DB
+}
+
+func (s PostgresStore) Increment(ctx context.Context, key string, start time.Time, window time.Duration) (int, error) {
+	count, _, err := model.QIncrementRateLimit(s.DB.WithContext(ctx), key, start, window)
+	return count, err
+}
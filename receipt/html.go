@@ -0,0 +1,18 @@
+package receipt
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"systempayment/model"
+)
+
+//go:embed templates/receipt.html
+var templates embed.FS
+
+var receiptHTML = template.Must(template.ParseFS(templates, "templates/receipt.html"))
+
+// HTML - writes the receipt as an HTML page
+func HTML(w io.Writer, r model.Receipt) error {
+	return receiptHTML.Execute(w, newDocument(r))
+}
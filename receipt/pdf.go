@@ -0,0 +1,93 @@
+package receipt
+
+import (
+	"io"
+	"systempayment/model"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// PDF - writes the receipt as an A4 PDF
+func PDF(w io.Writer, r model.Receipt) error {
+	d := newDocument(r)
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	// core fonts are cp1252, not UTF-8
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle("Receipt "+d.Invoice, true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+
+	muted := func(text string) {
+		if text == "" {
+			return
+		}
+		pdf.SetTextColor(102, 102, 102)
+		pdf.CellFormat(0, 5, tr(text), "", 1, "L", false, 0, "")
+		pdf.SetTextColor(34, 34, 34)
+	}
+
+	// header: merchant on the left, receipt number on the right
+	pdf.SetTextColor(34, 34, 34)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(110, 8, tr(d.MerchantName), "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 8, "Receipt", "", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(110, 5, "", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 5, tr("No. "+d.Invoice), "", 1, "R", false, 0, "")
+	pdf.CellFormat(110, 5, "", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 5, d.Date, "", 1, "R", false, 0, "")
+	if d.MerchantTaxID != "" {
+		muted("Tax ID " + d.MerchantTaxID)
+	}
+	muted(d.MerchantAddress)
+	muted(d.MerchantEmail)
+	pdf.Ln(8)
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 6, "Billed to", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 5, tr(d.PayerName), "", 1, "L", false, 0, "")
+	if d.PayerDocument != "" {
+		muted("Document " + d.PayerDocument)
+	}
+	for _, line := range d.PayerAddress {
+		muted(line)
+	}
+	muted(d.PayerEmail)
+	pdf.Ln(8)
+
+	// lines
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(95, 7, "Description", "B", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 7, "Installment", "B", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 7, "Amount", "B", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	amount := d.Net
+	if amount == "" {
+		amount = d.Total
+	}
+	pdf.CellFormat(95, 7, tr(d.Product), "", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 7, d.Installment, "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 7, amount, "", 1, "R", false, 0, "")
+	if d.Description != "" {
+		muted(d.Description)
+	}
+	for _, line := range d.TaxLines {
+		pdf.CellFormat(125, 7, tr(line.Name), "T", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 7, line.Amount, "T", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(125, 8, "Total", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 8, d.Total, "T", 1, "R", false, 0, "")
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "", 9)
+	paid := "Paid with " + d.Card
+	if d.Reference != "" {
+		paid += " - Reference " + d.Reference
+	}
+	muted(paid)
+
+	return pdf.Output(w)
+}
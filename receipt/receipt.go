@@ -0,0 +1,89 @@
+package receipt
+
+import (
+	"fmt"
+	"strings"
+	"systempayment/model"
+)
+
+// document - printable values of a model.Receipt, shared by HTML and PDF
+type document struct {
+	Invoice         string
+	Date            string
+	MerchantName    string
+	MerchantTaxID   string
+	MerchantAddress string
+	MerchantEmail   string
+	PayerName       string
+	PayerEmail      string
+	PayerDocument   string
+	PayerAddress    []string
+	Product         string
+	Description     string
+	Installment     string
+	Net             string
+	TaxLines        []taxLine
+	Total           string
+	Card            string
+	Reference       string
+}
+
+type taxLine struct {
+	Name   string
+	Amount string
+}
+
+func newDocument(r model.Receipt) document {
+	currency := str(r.Payment.Currency)
+	money := func(amount float64) string {
+		return fmt.Sprintf("%.2f %s", amount, currency)
+	}
+
+	d := document{
+		Invoice:         r.Invoice.Code(),
+		Date:            r.Payment.CreatedAt.Format("02/01/2006 15:04"),
+		MerchantName:    str(r.Merchant.Name),
+		MerchantTaxID:   str(r.Merchant.TaxID),
+		MerchantAddress: str(r.Merchant.Address),
+		MerchantEmail:   str(r.Merchant.Email),
+		PayerName:       str(r.Payer.Name),
+		PayerEmail:      str(r.Payer.Email),
+		PayerDocument:   str(r.Payer.Document),
+		Product:         str(r.Order.Product.Name),
+		Description:     str(r.Order.Product.Description),
+		Installment:     fmt.Sprintf("%d of %d", r.Installment, r.Order.TotalFees),
+		Total:           money(r.Payment.Amount),
+		Card:            strings.TrimSpace(fmt.Sprintf("%s **** %s", str(r.Card.Brand), str(r.Card.Last4))),
+		Reference:       str(r.Payment.OrderNumber),
+	}
+
+	a := r.Payer.Address
+	for _, line := range []string{
+		strings.TrimSpace(str(a.Street) + " " + str(a.Number)),
+		strings.TrimSpace(str(a.City) + ", " + str(a.State) + " " + str(a.ZipCode)),
+		str(r.Payer.Country),
+	} {
+		if line = strings.Trim(line, ", "); line != "" {
+			d.PayerAddress = append(d.PayerAddress, line)
+		}
+	}
+
+	// payments from before taxes were broken out have no net amount
+	if r.Payment.NetAmount != 0 {
+		d.Net = money(r.Payment.NetAmount)
+	}
+	for _, line := range r.Payment.TaxLines {
+		d.TaxLines = append(d.TaxLines, taxLine{
+			Name:   fmt.Sprintf("%s %g%%", line.Name, line.Rate*100),
+			Amount: money(line.Amount),
+		})
+	}
+	return d
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
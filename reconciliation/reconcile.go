@@ -0,0 +1,224 @@
+package reconciliation
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"systempayment/apperror"
+	"systempayment/model"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrAlreadyImported - a file with the same content was imported before
+var ErrAlreadyImported = apperror.Conflict("settlement_already_imported", "settlement report already imported")
+
+// Import - Reconciles a settlement report against the payment table
+//
+// Every row is matched to a Payment by dlocal payment id or order number.
+// Rows without payment are extra, matches with another amount or currency
+// are amount mismatches, payments of the period not in any report are
+// missing. Fees of matched payments are posted to the ledger. The period
+// is the rows' dates unless from/to are given.
+func Import(db *gorm.DB, name string, r io.Reader, from *time.Time, to *time.Time) (model.SettlementReport, int, error) {
+	var report model.SettlementReport
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return report, 400, err
+	}
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+	if imported, err := model.QSettlementImported(db, checksum); err != nil {
+		return report, 500, err
+	} else if imported {
+		return report, 400, ErrAlreadyImported
+	}
+
+	rows, skipped, err := Parse(bytes.NewReader(content))
+	if err != nil {
+		return report, 400, err
+	}
+	if len(rows) == 0 {
+		return report, 400, errors.New("settlement report has no payments")
+	}
+
+	report = model.SettlementReport{
+		FileName: name,
+		Checksum: checksum,
+		Rows:     len(rows),
+		Skipped:  skipped,
+	}
+	report.PeriodFrom, report.PeriodTo = period(rows)
+	if from != nil {
+		report.PeriodFrom = *from
+	}
+	if to != nil {
+		report.PeriodTo = *to
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		report.CreatedAt = time.Now()
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+
+		var discrepancies []model.SettlementDiscrepancy
+		for _, row := range rows {
+			d, matched, err := match(tx, report.ID, row)
+			if err != nil {
+				return err
+			}
+			if matched {
+				report.Matched++
+			}
+			if d != nil {
+				discrepancies = append(discrepancies, *d)
+			}
+		}
+
+		unsettled, err := model.QGetUnsettledPayments(tx, report.PeriodFrom, report.PeriodTo)
+		if err != nil {
+			return err
+		}
+		for _, p := range unsettled {
+			id := p.ID
+			discrepancies = append(discrepancies, model.SettlementDiscrepancy{
+				ReportID:    report.ID,
+				Kind:        model.DiscrepancyMissing,
+				PaymentID:   &id,
+				DlocalID:    deref(p.DlocalID),
+				OrderNumber: deref(p.OrderNumber),
+				Currency:    deref(p.Currency),
+				Recorded:    p.Amount,
+			})
+		}
+
+		for i := range discrepancies {
+			discrepancies[i].CreatedAt = time.Now()
+		}
+		if len(discrepancies) > 0 {
+			if err := tx.Create(&discrepancies).Error; err != nil {
+				return err
+			}
+		}
+		report.Discrepancies = len(discrepancies)
+		return tx.Model(&report).Select("matched", "discrepancies").Updates(&report).Error
+	})
+	if err != nil {
+		log.WithContext(db.Statement.Context).Error("Import - ", err)
+		return report, 500, err
+	}
+	return report, 200, nil
+}
+
+// Matches a row to its payment, returns the discrepancy found if any
+func match(tx *gorm.DB, reportID int, row Row) (*model.SettlementDiscrepancy, bool, error) {
+	d := model.SettlementDiscrepancy{
+		ReportID:    reportID,
+		DlocalID:    row.DlocalID,
+		OrderNumber: row.OrderNumber,
+		Currency:    row.Currency,
+		Settled:     row.Amount,
+	}
+
+	payment, err := model.QFindSettledPayment(tx, row.DlocalID, row.OrderNumber, row.Amount)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		d.Kind = model.DiscrepancyExtra
+		return &d, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	d.PaymentID = &payment.ID
+	d.Recorded = payment.Amount
+	if payment.SettlementReportID != nil {
+		// settled twice
+		d.Kind = model.DiscrepancyExtra
+		return &d, false, nil
+	}
+
+	if err := payment.Settled(tx, reportID); err != nil {
+		return nil, false, err
+	}
+	if err := model.QResolveMissing(tx, payment.ID, reportID); err != nil {
+		return nil, false, err
+	}
+	if row.Fee > 0 {
+		if err := model.QPostFee(tx, payment, row.Fee); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if math.Abs(payment.Amount-row.Amount) >= 0.005 || !strings.EqualFold(deref(payment.Currency), row.Currency) {
+		d.Kind = model.DiscrepancyAmountMismatch
+		return &d, true, nil
+	}
+	return nil, true, nil
+}
+
+// First and last day of the rows
+func period(rows []Row) (time.Time, time.Time) {
+	from, to := rows[0].Date, rows[0].Date
+	for _, row := range rows {
+		if row.Date.Before(from) {
+			from = row.Date
+		}
+		if row.Date.After(to) {
+			to = row.Date
+		}
+	}
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location()).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	return from, to
+}
+
+// ImportDir - Imports the *.csv files of dir not imported yet, returns how many
+func ImportDir(ctx context.Context, db *gorm.DB, dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(files)
+
+	imported := 0
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return imported, err
+		}
+		report, _, err := Import(db, filepath.Base(file), f, nil, nil)
+		f.Close()
+		if errors.Is(err, ErrAlreadyImported) {
+			continue
+		}
+		if err != nil {
+			// keep going, a bad file shouldn't block the others
+			log.WithContext(db.Statement.Context).Error("ImportDir - ", file, ": ", err)
+			continue
+		}
+		log.WithContext(db.Statement.Context).Info("Settlement report ", report.FileName, ": ", report.Matched, " matched, ",
+			report.Discrepancies, " discrepancies")
+		imported++
+	}
+	return imported, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
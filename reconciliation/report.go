@@ -0,0 +1,128 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Row - payment line of a dlocal settlement report
+type Row struct {
+	Line        int
+	DlocalID    string
+	OrderNumber string
+	Currency    string
+	Amount      float64
+	Fee         float64
+	Date        time.Time
+}
+
+// Accepted header names of each column, case insensitive
+var columns = map[string][]string{
+	"dlocal_id":    {"payment_id", "transaction_id", "dlocal_id", "id"},
+	"order_number": {"order_id", "order_number", "invoice", "merchant_reference"},
+	"type":         {"type", "transaction_type"},
+	"currency":     {"currency"},
+	"amount":       {"amount", "gross_amount", "payment_amount"},
+	"fee":          {"fee", "fee_amount", "fees"},
+	"date":         {"date", "settlement_date", "creation_date", "created_date"},
+}
+
+// Row types that are payments, other types (refunds, chargebacks...) are skipped
+var paymentTypes = map[string]bool{"": true, "PAYMENT": true, "PAYMENTS": true, "CAPTURE": true}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// Parse - Reads the payment rows of a settlement report CSV, returns how
+// many rows were skipped
+func Parse(r io.Reader) ([]Row, int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading header: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+		for column, aliases := range columns {
+			if _, found := index[column]; found {
+				continue
+			}
+			for _, alias := range aliases {
+				if name == alias {
+					index[column] = i
+				}
+			}
+		}
+	}
+	for _, required := range []string{"currency", "amount", "date"} {
+		if _, ok := index[required]; !ok {
+			return nil, 0, fmt.Errorf("missing column %s", required)
+		}
+	}
+	_, hasID := index["dlocal_id"]
+	_, hasOrder := index["order_number"]
+	if !hasID && !hasOrder {
+		return nil, 0, errors.New("missing column payment_id or order_id")
+	}
+
+	var rows []Row
+	skipped := 0
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		field := func(column string) string {
+			i, ok := index[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		if !paymentTypes[strings.ToUpper(field("type"))] {
+			skipped++
+			continue
+		}
+
+		row := Row{
+			Line:        line,
+			DlocalID:    field("dlocal_id"),
+			OrderNumber: field("order_number"),
+			Currency:    strings.ToUpper(field("currency")),
+		}
+		if row.Amount, err = strconv.ParseFloat(field("amount"), 64); err != nil {
+			return nil, 0, fmt.Errorf("line %d: invalid amount %q", line, field("amount"))
+		}
+		if fee := field("fee"); fee != "" {
+			if row.Fee, err = strconv.ParseFloat(fee, 64); err != nil {
+				return nil, 0, fmt.Errorf("line %d: invalid fee %q", line, fee)
+			}
+		}
+		if row.Date, err = parseDate(field("date")); err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, skipped, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
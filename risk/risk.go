@@ -0,0 +1,190 @@
+package risk
+
+import (
+	"strings"
+	"systempayment/config"
+	"systempayment/model"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Outcomes, from the mildest. The worst outcome of the rules is the decision
+const (
+	Allow  = "allow"
+	Review = "review"
+	Deny   = "deny"
+)
+
+// Reasons, the rules that matched
+const (
+	ReasonBlockedEmail    = "blocked_email"
+	ReasonBlockedDocument = "blocked_document"
+	ReasonAmountDeny      = "amount_over_deny_limit"
+	ReasonAmountReview    = "amount_over_review_limit"
+	ReasonNewPayer        = "new_payer_large_amount"
+	ReasonCountryMismatch = "card_country_mismatch"
+	ReasonPayerVelocity   = "payer_velocity"
+	ReasonCardVelocity    = "card_velocity"
+	// a review denied the order before
+	ReasonReviewDenied = "review_denied"
+)
+
+// Decision - outcome of the rules and the ones that matched
+type Decision struct {
+	Outcome string
+	Reasons []string
+}
+
+// Input - what's about to be charged. Card saves have no card and amount
+// yet, only the blocklists apply to them
+type Input struct {
+	Payer    model.Payer
+	Card     *model.Card
+	Amount   float64
+	Currency string
+}
+
+// rule - Adds an outcome and its reason for every check that matched
+type rule func(db *gorm.DB, in Input, add func(outcome string, reason string)) error
+
+var rules = []rule{blocklist, amount, newPayer, countryMismatch, velocity}
+
+var settings config.Risk
+var blockedEmails, blockedDocuments map[string]bool
+
+// Configure sets the rules' thresholds and blocklists
+func Configure(c config.Risk) {
+	settings = c
+	blockedEmails = map[string]bool{}
+	for _, email := range c.BlockedEmails {
+		blockedEmails[normalizeEmail(email)] = true
+	}
+	blockedDocuments = map[string]bool{}
+	for _, document := range c.BlockedDocuments {
+		blockedDocuments[normalizeDocument(document)] = true
+	}
+}
+
+// Enabled reports whether the rules are checked
+func Enabled() bool {
+	return settings.Enabled
+}
+
+// Evaluate - Runs every rule, allowed when they're turned off
+func Evaluate(db *gorm.DB, in Input) (Decision, int, error) {
+	decision := Decision{Outcome: Allow}
+	if !settings.Enabled {
+		return decision, 200, nil
+	}
+	add := func(outcome string, reason string) {
+		if severity(outcome) > severity(decision.Outcome) {
+			decision.Outcome = outcome
+		}
+		decision.Reasons = append(decision.Reasons, reason)
+	}
+	for _, r := range rules {
+		if err := r(db, in, add); err != nil {
+			return decision, 500, err
+		}
+	}
+	return decision, 200, nil
+}
+
+func severity(outcome string) int {
+	switch outcome {
+	case Deny:
+		return 2
+	case Review:
+		return 1
+	}
+	return 0
+}
+
+func blocklist(db *gorm.DB, in Input, add func(string, string)) error {
+	if in.Payer.Email != nil && blockedEmails[normalizeEmail(*in.Payer.Email)] {
+		add(Deny, ReasonBlockedEmail)
+	}
+	if in.Payer.Document != nil && blockedDocuments[normalizeDocument(*in.Payer.Document)] {
+		add(Deny, ReasonBlockedDocument)
+	}
+	return nil
+}
+
+// amount - Thresholds of the currency, currencies without one aren't checked
+func amount(db *gorm.DB, in Input, add func(string, string)) error {
+	if in.Amount <= 0 {
+		return nil
+	}
+	if limit, ok := settings.DenyAmounts[in.Currency]; ok && in.Amount >= limit {
+		add(Deny, ReasonAmountDeny)
+	} else if limit, ok := settings.ReviewAmounts[in.Currency]; ok && in.Amount >= limit {
+		add(Review, ReasonAmountReview)
+	}
+	return nil
+}
+
+// newPayer - Large amounts of payers created recently
+func newPayer(db *gorm.DB, in Input, add func(string, string)) error {
+	limit, ok := settings.NewPayerAmounts[in.Currency]
+	if !ok || in.Amount <= 0 || settings.NewPayerAge <= 0 {
+		return nil
+	}
+	if time.Since(in.Payer.CreatedAt) < settings.NewPayerAge && in.Amount >= limit {
+		add(Review, ReasonNewPayer)
+	}
+	return nil
+}
+
+// countryMismatch - Card issued in another country than the payer's, only
+// for cards dlocal reported the country of
+func countryMismatch(db *gorm.DB, in Input, add func(string, string)) error {
+	if !settings.CountryMismatch || in.Card == nil || in.Card.Country == nil || in.Payer.Country == nil {
+		return nil
+	}
+	if *in.Card.Country != "" && !strings.EqualFold(*in.Card.Country, *in.Payer.Country) {
+		add(Review, ReasonCountryMismatch)
+	}
+	return nil
+}
+
+// velocity - Payments sent to dlocal by the payer and with the card in the
+// window, the ones stopped by the rules don't count
+func velocity(db *gorm.DB, in Input, add func(string, string)) error {
+	if in.Card == nil {
+		return nil
+	}
+	sent := []string{model.AttemptApproved, model.AttemptRejected, model.AttemptFailed}
+	check := func(attempts model.PaymentAttempt, limit config.Limit, reason string) error {
+		if limit.Requests <= 0 {
+			return nil
+		}
+		count, _, err := attempts.QCountPaymentAttempts(db, sent, time.Now().Add(-limit.Window))
+		if err != nil {
+			return err
+		}
+		if count >= int64(limit.Requests) {
+			add(Deny, reason)
+		}
+		return nil
+	}
+	if err := check(model.PaymentAttempt{Kind: model.AttemptPayment, PayerID: in.Payer.ID}, settings.PayerVelocity, ReasonPayerVelocity); err != nil {
+		return err
+	}
+	return check(model.PaymentAttempt{Kind: model.AttemptPayment, CardID: &in.Card.ID}, settings.CardVelocity, ReasonCardVelocity)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Documents are compared without dots, dashes or spaces
+func normalizeDocument(document string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, document)
+}
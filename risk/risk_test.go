@@ -0,0 +1,96 @@
+package risk
+
+import (
+	"reflect"
+	"systempayment/config"
+	"systempayment/model"
+	"testing"
+	"time"
+)
+
+func str(s string) *string {
+	return &s
+}
+
+func TestEvaluate(t *testing.T) {
+	Configure(config.Risk{
+		Enabled:          true,
+		ReviewAmounts:    map[string]float64{"USD": 500},
+		DenyAmounts:      map[string]float64{"USD": 5000},
+		CountryMismatch:  true,
+		NewPayerAge:      24 * time.Hour,
+		NewPayerAmounts:  map[string]float64{"USD": 100},
+		BlockedEmails:    []string{" Fraud@Mail.com "},
+		BlockedDocuments: []string{"1.234.567-8"},
+	})
+	defer Configure(config.Risk{})
+
+	old := model.Payer{Email: str("jhondoe@mail.com"), Document: str("23415162"), Country: str("UY"), CreatedAt: time.Now().AddDate(0, -1, 0)}
+	recent := old
+	recent.CreatedAt = time.Now()
+	blocked := old
+	blocked.Email = str("fraud@mail.com")
+	blocked.Document = str("12345678")
+	uy := &model.Card{Country: str("uy")}
+	ar := &model.Card{Country: str("AR")}
+	unknown := &model.Card{}
+
+	tests := []struct {
+		name    string
+		in      Input
+		outcome string
+		reasons []string
+	}{
+		{"nothing matches", Input{Payer: old, Card: uy, Amount: 100, Currency: "USD"}, Allow, nil},
+		{"blocked payer saving a card", Input{Payer: blocked}, Deny, []string{ReasonBlockedEmail, ReasonBlockedDocument}},
+		{"review amount", Input{Payer: old, Card: uy, Amount: 500, Currency: "USD"}, Review, []string{ReasonAmountReview}},
+		{"deny amount is not also reviewed", Input{Payer: old, Card: uy, Amount: 5000, Currency: "USD"}, Deny, []string{ReasonAmountDeny}},
+		{"currency without thresholds", Input{Payer: recent, Card: uy, Amount: 100000, Currency: "UYU"}, Allow, nil},
+		{"new payer large amount", Input{Payer: recent, Card: uy, Amount: 100, Currency: "USD"}, Review, []string{ReasonNewPayer}},
+		{"new payer small amount", Input{Payer: recent, Card: uy, Amount: 99, Currency: "USD"}, Allow, nil},
+		{"card from another country", Input{Payer: old, Card: ar, Amount: 10, Currency: "USD"}, Review, []string{ReasonCountryMismatch}},
+		{"card country unknown", Input{Payer: old, Card: unknown, Amount: 10, Currency: "USD"}, Allow, nil},
+		{"worst outcome wins", Input{Payer: blocked, Card: ar, Amount: 500, Currency: "USD"}, Deny, []string{ReasonBlockedEmail, ReasonBlockedDocument, ReasonAmountReview, ReasonCountryMismatch}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, code, err := Evaluate(nil, tt.in)
+			if err != nil || code != 200 {
+				t.Fatalf("Evaluate() = %d, %v", code, err)
+			}
+			if decision.Outcome != tt.outcome {
+				t.Errorf("outcome = %q, want %q", decision.Outcome, tt.outcome)
+			}
+			if !reflect.DeepEqual(decision.Reasons, tt.reasons) {
+				t.Errorf("reasons = %v, want %v", decision.Reasons, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestEvaluateDisabled(t *testing.T) {
+	Configure(config.Risk{DenyAmounts: map[string]float64{"USD": 1}, BlockedEmails: []string{"fraud@mail.com"}})
+	defer Configure(config.Risk{})
+
+	decision, code, err := Evaluate(nil, Input{Payer: model.Payer{Email: str("fraud@mail.com")}, Amount: 10, Currency: "USD"})
+	if err != nil || code != 200 || decision.Outcome != Allow || len(decision.Reasons) != 0 {
+		t.Errorf("Evaluate() = %+v, %d, %v, want allowed", decision, code, err)
+	}
+}
+
+func TestNormalizeDocument(t *testing.T) {
+	tests := []struct {
+		document string
+		want     string
+	}{
+		{"1.234.567-8", "12345678"},
+		{" 12 345 678 ", "12345678"},
+		{"ab-123", "AB123"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeDocument(tt.document); got != tt.want {
+			t.Errorf("normalizeDocument(%q) = %q, want %q", tt.document, got, tt.want)
+		}
+	}
+}
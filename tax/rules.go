@@ -0,0 +1,72 @@
+package tax
+
+import "fmt"
+
+// Rule - one tax applied to a category
+type Rule struct {
+	Name string
+	Rate float64
+	// OnGross - the rate applies to the gross amount, the tax is part of its
+	// own base (ICMS/ISS "por dentro"). Otherwise it applies to the net
+	// amount (VAT)
+	OnGross bool
+}
+
+// Table - Calculator with fixed rules per category, categories missing
+// from the table are an error
+type Table map[string][]Rule
+
+// Default tables
+var (
+	Uruguay = Table{
+		Standard: {{Name: "IVA", Rate: 0.22}},
+		Reduced:  {{Name: "IVA", Rate: 0.10}},
+		Service:  {{Name: "IVA", Rate: 0.22}},
+		Exempt:   {},
+	}
+	Brazil = Table{
+		Standard: {{Name: "ICMS", Rate: 0.18, OnGross: true}},
+		Reduced:  {{Name: "ICMS", Rate: 0.07, OnGross: true}},
+		Service:  {{Name: "ISS", Rate: 0.05, OnGross: true}},
+		Exempt:   {},
+	}
+	Mexico = Table{
+		Standard: {{Name: "IVA", Rate: 0.16}},
+		Reduced:  {{Name: "IVA", Rate: 0}},
+		Service:  {{Name: "IVA", Rate: 0.16}},
+		Exempt:   {},
+	}
+)
+
+func (t Table) Calculate(category string, gross float64) (Breakdown, error) {
+	rules, ok := t[category]
+	if !ok {
+		return Breakdown{}, fmt.Errorf("no tax rules for category %q", category)
+	}
+
+	// gross = net * (1 + net rates) + gross * gross rates
+	var netRates, grossRates float64
+	for _, r := range rules {
+		if r.OnGross {
+			grossRates += r.Rate
+		} else {
+			netRates += r.Rate
+		}
+	}
+	net := gross * (1 - grossRates) / (1 + netRates)
+
+	b := Breakdown{Gross: gross, Lines: []Line{}}
+	for _, r := range rules {
+		line := Line{Name: r.Name, Rate: r.Rate, Base: round(net)}
+		if r.OnGross {
+			line.Base = gross
+		}
+		line.Amount = round(line.Base * r.Rate)
+		b.Tax += line.Amount
+		b.Lines = append(b.Lines, line)
+	}
+	// net absorbs rounding so net + tax == gross
+	b.Tax = round(b.Tax)
+	b.Net = round(gross - b.Tax)
+	return b, nil
+}
@@ -0,0 +1,80 @@
+package tax
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Product tax categories
+const (
+	Standard = "standard"
+	Reduced  = "reduced"
+	Service  = "service"
+	Exempt   = "exempt"
+)
+
+// Categories - valid product tax categories
+var Categories = []string{Standard, Reduced, Service, Exempt}
+
+// Line - one tax included in an amount
+type Line struct {
+	Name   string  `json:"name" example:"IVA"`
+	Rate   float64 `json:"rate" example:"0.22"`
+	Base   float64 `json:"base" example:"81.97"`
+	Amount float64 `json:"amount" example:"18.03"`
+}
+
+// Breakdown - gross amount split in net + taxes
+type Breakdown struct {
+	Net   float64 `json:"net"`
+	Tax   float64 `json:"tax"`
+	Gross float64 `json:"gross"`
+	Lines []Line  `json:"lines"`
+}
+
+// Calculator - taxes of one country
+//
+// Prices are tax inclusive, Calculate breaks the gross amount down.
+type Calculator interface {
+	Calculate(category string, gross float64) (Breakdown, error)
+}
+
+var calculators = map[string]Calculator{
+	"UY": Uruguay,
+	"BR": Brazil,
+	"MX": Mexico,
+}
+
+// Register - sets the Calculator used for a country (ISO 3166 alpha-2)
+func Register(country string, c Calculator) {
+	calculators[strings.ToUpper(country)] = c
+}
+
+// ValidCategory - true if category is one of Categories
+func ValidCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Calculate - breaks down a gross amount for a payer country and product category
+//
+// Countries without a Calculator have no taxes, net = gross.
+func Calculate(country, category string, gross float64) (Breakdown, error) {
+	if !ValidCategory(category) {
+		return Breakdown{}, fmt.Errorf("invalid tax category %q", category)
+	}
+	c, ok := calculators[strings.ToUpper(country)]
+	if !ok {
+		return Breakdown{Net: gross, Gross: gross, Lines: []Line{}}, nil
+	}
+	return c.Calculate(category, gross)
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
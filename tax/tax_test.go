@@ -0,0 +1,55 @@
+package tax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name     string
+		country  string
+		category string
+		gross    float64
+		want     Breakdown
+	}{
+		{"uruguay standard", "UY", Standard, 100, Breakdown{Net: 81.97, Tax: 18.03, Gross: 100, Lines: []Line{{Name: "IVA", Rate: 0.22, Base: 81.97, Amount: 18.03}}}},
+		{"uruguay reduced", "uy", Reduced, 110, Breakdown{Net: 100, Tax: 10, Gross: 110, Lines: []Line{{Name: "IVA", Rate: 0.10, Base: 100, Amount: 10}}}},
+		{"brazil on gross", "BR", Standard, 100, Breakdown{Net: 82, Tax: 18, Gross: 100, Lines: []Line{{Name: "ICMS", Rate: 0.18, Base: 100, Amount: 18}}}},
+		{"brazil service", "BR", Service, 200, Breakdown{Net: 190, Tax: 10, Gross: 200, Lines: []Line{{Name: "ISS", Rate: 0.05, Base: 200, Amount: 10}}}},
+		{"mexico standard", "MX", Standard, 116, Breakdown{Net: 100, Tax: 16, Gross: 116, Lines: []Line{{Name: "IVA", Rate: 0.16, Base: 100, Amount: 16}}}},
+		{"mexico zero rate", "MX", Reduced, 50, Breakdown{Net: 50, Tax: 0, Gross: 50, Lines: []Line{{Name: "IVA", Rate: 0, Base: 50, Amount: 0}}}},
+		{"exempt", "UY", Exempt, 99.99, Breakdown{Net: 99.99, Gross: 99.99, Lines: []Line{}}},
+		{"country without taxes", "AR", Standard, 100, Breakdown{Net: 100, Gross: 100, Lines: []Line{}}},
+		{"net absorbs rounding", "UY", Standard, 0.01, Breakdown{Net: 0.01, Tax: 0, Gross: 0.01, Lines: []Line{{Name: "IVA", Rate: 0.22, Base: 0.01, Amount: 0}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Calculate(tt.country, tt.category, tt.gross)
+			if err != nil {
+				t.Fatalf("Calculate() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Calculate() = %+v, want %+v", got, tt.want)
+			}
+			if round(got.Net+got.Tax) != got.Gross {
+				t.Errorf("net %v + tax %v != gross %v", got.Net, got.Tax, got.Gross)
+			}
+		})
+	}
+}
+
+func TestCalculateErrors(t *testing.T) {
+	if _, err := Calculate("UY", "luxury", 100); err == nil {
+		t.Error("Calculate() of an unknown category, want an error")
+	}
+	// valid category missing from a country's table
+	Register("ZZ", Table{Standard: {{Name: "VAT", Rate: 0.2}}})
+	defer delete(calculators, "ZZ")
+	if _, err := Calculate("zz", Reduced, 100); err == nil {
+		t.Error("Calculate() of a category without rules, want an error")
+	}
+	if b, err := Calculate("zz", Standard, 120); err != nil || b.Net != 100 || b.Tax != 20 {
+		t.Errorf("Calculate() = %+v, %v, want the registered table", b, err)
+	}
+}
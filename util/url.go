@@ -0,0 +1,43 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+)
+
+// cgnat - shared address space (100.64.0.0/10), private to the carrier
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// PublicIP - false for loopback, private, link-local (cloud metadata
+// included), multicast and unspecified addresses
+func PublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || cgnat.Contains(ip))
+}
+
+// PublicURL - Fails unless raw is an absolute https URL whose host resolves
+// only to public addresses
+func PublicURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("url must be an absolute https URL")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if !PublicIP(ip) {
+			return errors.New("url host must be a public address")
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return errors.New("url host can't be resolved")
+	}
+	for _, addr := range addrs {
+		if !PublicIP(addr.IP) {
+			return errors.New("url host must be a public address")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"systempayment/model"
+	"systempayment/util"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Timeout - how long an endpoint has to answer
+const Timeout = 10 * time.Second
+
+// Envelope - body POSTed to the endpoints
+type Envelope struct {
+	ID         int                    `json:"id"`
+	Type       string                 `json:"type"`
+	MerchantID *int                   `json:"merchant_id"`
+	CreatedAt  time.Time              `json:"created_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// Sign - value of the X-Webhook-Signature header:
+// t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>" with the endpoint secret>
+//
+// Receivers recompute it and reject old timestamps to avoid replays.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(t + "."))
+	h.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(h.Sum(nil))
+}
+
+// NewClient - HTTP client for the deliveries. It only connects to public
+// addresses, checked when dialing so hosts resolving to internal services
+// later on are refused too, and doesn't follow redirects
+func NewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: Timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !util.PublicIP(ip) {
+				return fmt.Errorf("%s is not a public address", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: Timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Dispatch - Sends the deliveries that are due. Returns how many succeeded.
+//
+// Every delivery is locked while it's sent, several dispatchers can run at
+// the same time.
+func Dispatch(ctx context.Context, db *gorm.DB, client *http.Client) (int, error) {
+	delivered := 0
+	for ctx.Err() == nil {
+		done := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var d model.WebhookDelivery
+			if err := d.QLockNextDelivery(tx, time.Now()); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					done = true
+					return nil
+				}
+				return err
+			}
+
+			attempt := send(ctx, client, d)
+			if err := d.Attempted(tx, attempt); err != nil {
+				return err
+			}
+			if d.Status == model.DeliverySucceeded {
+				delivered++
+			} else {
+				log.Warn("Webhook delivery ", d.ID, " to ", *d.Endpoint.URL, " failed: ",
+					attempt.StatusCode, " ", attempt.Error)
+			}
+			return nil
+		})
+		if err != nil {
+			return delivered, err
+		}
+		if done {
+			break
+		}
+	}
+	return delivered, nil
+}
+
+// POSTs the event to the endpoint
+func send(ctx context.Context, client *http.Client, d model.WebhookDelivery) model.WebhookAttempt {
+	var attempt model.WebhookAttempt
+	body, err := json.Marshal(Envelope{
+		ID:         d.Event.ID,
+		Type:       d.Event.Type,
+		MerchantID: d.Event.MerchantID,
+		CreatedAt:  d.Event.CreatedAt,
+		Data:       d.Event.Data,
+	})
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	if d.Endpoint.DeletedAt.Valid {
+		attempt.Error = "endpoint deleted"
+		return attempt
+	}
+
+	// endpoints registered before only https was allowed
+	if u, err := url.Parse(*d.Endpoint.URL); err != nil || u.Scheme != "https" {
+		attempt.Error = "endpoint url must be https"
+		return attempt
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *d.Endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "systempayment-webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", strconv.Itoa(d.Event.ID))
+	req.Header.Set("X-Webhook-Event", d.Event.Type)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(d.ID))
+	req.Header.Set("X-Webhook-Signature", Sign(d.Endpoint.Secret, time.Now(), body))
+
+	start := time.Now()
+	res, err := client.Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+
+	// keep the start of the answer for the delivery log
+	response, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	attempt.StatusCode = res.StatusCode
+	attempt.Response = strings.ReplaceAll(strings.ToValidUTF8(string(response), ""), "\x00", "")
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("endpoint answered %s", res.Status)
+	}
+	return attempt
+}